@@ -52,3 +52,14 @@ func GetCollection(collectionName string) *mongo.Collection {
 	}
 	return Client.Database(DatabaseName).Collection(collectionName)
 }
+
+// WaitForClient blocks until Connect has set up the client or the timeout
+// elapses. Index builders and background jobs are started from init(), which
+// runs before main gets a chance to connect.
+func WaitForClient(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for Client == nil && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+	return Client != nil
+}
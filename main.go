@@ -5,6 +5,7 @@ import (
 
 	"Agromi/database"
 	"Agromi/routes"
+	"Agromi/routes/auth"
 	"Agromi/utils"
 
 	// Force Redeploy: Trigger fresh build
@@ -16,6 +17,7 @@ func main() {
 	// 1. Connect to MongoDB
 	database.Connect()
 	utils.InitFirebase() // Restore Firebase Init
+	auth.InitJWT()       // Exits when JWT_SECRET is missing
 
 	// 2. Initialize Gin Router
 	app := gin.Default()
@@ -0,0 +1,264 @@
+package account
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"Agromi/core/router"
+	"Agromi/database"
+	account_models "Agromi/routes/account/models"
+	"Agromi/routes/auth"
+	"Agromi/storage"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	deletionInterval = time.Hour
+	downloadURLTTL   = 15 * time.Minute
+	maxExportsListed = 20
+)
+
+func init() {
+	router.Register(func(r *gin.Engine) {
+		group := r.Group("/api/account")
+		group.Use(auth.RequireAuth(), auth.RequireRole(auth.RoleUser))
+		{
+			group.GET("/deletion", GetDeletion)
+			group.POST("/deletion", RequestDeletion)
+			group.DELETE("/deletion", CancelDeletion)
+
+			group.POST("/export", RequestExport)
+			group.GET("/export", ListExports)
+			group.GET("/export/:id/download", DownloadExport)
+		}
+	})
+
+	go createAccountIndexes()
+	go runExportJob()
+	go runDeletionJob()
+}
+
+// loadUser fetches the caller's user profile; consultants have their own deletion flow
+func loadUser(ctx context.Context, c *gin.Context) (auth.User, bool) {
+	var user auth.User
+	err := database.GetCollection("users").FindOne(ctx, bson.M{"_id": auth.CurrentUserID(c)}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return user, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return user, false
+	}
+	return user, true
+}
+
+// GetDeletion reports whether the account is scheduled for deletion
+func GetDeletion(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, ok := loadUser(ctx, c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"scheduled": user.DeletionScheduledAt != nil, "deletion_scheduled_at": user.DeletionScheduledAt})
+}
+
+// RequestDeletion schedules the caller's account for erasure after the grace period.
+// The user can keep signing in and cancel until then.
+func RequestDeletion(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, ok := loadUser(ctx, c)
+	if !ok {
+		return
+	}
+	// Admin accounts are removed by other admins
+	if user.UserType != "farmer" && user.UserType != "consumer" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only farmer and consumer accounts can be deleted this way"})
+		return
+	}
+	if user.DeletionScheduledAt != nil {
+		c.JSON(http.StatusOK, gin.H{"message": "Account already scheduled for deletion", "deletion_scheduled_at": user.DeletionScheduledAt})
+		return
+	}
+
+	scheduled := time.Now().Add(account_models.DeletionGrace)
+	_, err := database.GetCollection("users").UpdateOne(ctx, bson.M{"_id": user.ID}, bson.M{"$set": bson.M{"deletion_scheduled_at": scheduled}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule deletion"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account scheduled for deletion in 30 days", "deletion_scheduled_at": scheduled})
+}
+
+// CancelDeletion keeps the account
+func CancelDeletion(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := database.GetCollection("users").UpdateOne(ctx,
+		bson.M{"_id": auth.CurrentUserID(c), "deletion_scheduled_at": bson.M{"$exists": true}},
+		bson.M{"$unset": bson.M{"deletion_scheduled_at": ""}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No deletion scheduled"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Deletion cancelled"})
+}
+
+// RequestExport queues a ZIP of everything stored about the caller
+func RequestExport(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, ok := loadUser(ctx, c)
+	if !ok {
+		return
+	}
+
+	coll := database.GetCollection("data_exports")
+	var last account_models.Export
+	err := coll.FindOne(ctx, bson.M{"user_id": user.ID}, options.FindOne().SetSort(bson.M{"created_at": -1})).Decode(&last)
+	if err != nil && err != mongo.ErrNoDocuments {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if err == nil {
+		switch {
+		case last.Status == account_models.ExportPending || last.Status == account_models.ExportRunning:
+			c.JSON(http.StatusConflict, gin.H{"error": "An export is already in progress", "export": last})
+			return
+		case last.Status != account_models.ExportFailed && time.Since(last.CreatedAt) < account_models.ExportCooldown:
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "You can request one export per day", "export": last})
+			return
+		}
+	}
+
+	export := account_models.Export{
+		ID:        primitive.NewObjectID(),
+		UserID:    user.ID,
+		Status:    account_models.ExportPending,
+		CreatedAt: time.Now(),
+	}
+	if _, err := coll.InsertOne(ctx, export); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue export"})
+		return
+	}
+	c.JSON(http.StatusAccepted, export)
+}
+
+// ListExports shows the caller's recent exports, newest first
+func ListExports(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.M{"created_at": -1}).SetLimit(maxExportsListed)
+	cursor, err := database.GetCollection("data_exports").Find(ctx, bson.M{"user_id": auth.CurrentUserID(c)}, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	exports := []account_models.Export{}
+	if err := cursor.All(ctx, &exports); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Decoding failed"})
+		return
+	}
+	c.JSON(http.StatusOK, exports)
+}
+
+// DownloadExport redirects to a short-lived signed URL of a finished archive
+func DownloadExport(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var export account_models.Export
+	err = database.GetCollection("data_exports").FindOne(ctx, bson.M{"_id": id, "user_id": auth.CurrentUserID(c)}).Decode(&export)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Export not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if export.Status != account_models.ExportReady || export.Key == "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Export is " + export.Status})
+		return
+	}
+
+	url, err := storage.Default().SignedURL(export.Key, downloadURLTTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign URL"})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusFound, url)
+}
+
+// runDeletionJob erases accounts whose grace period has ended
+func runDeletionJob() {
+	if !database.WaitForClient(30 * time.Second) {
+		return
+	}
+
+	ticker := time.NewTicker(deletionInterval)
+	defer ticker.Stop()
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+		if n, err := eraseDue(ctx, time.Now()); err != nil {
+			log.Println("account deletion:", err)
+		} else if n > 0 {
+			log.Println("account deletion: erased", n, "accounts")
+		}
+		cancel()
+		<-ticker.C
+	}
+}
+
+// eraseDue erases every account scheduled before now
+func eraseDue(ctx context.Context, now time.Time) (int, error) {
+	cursor, err := database.GetCollection("users").Find(ctx,
+		bson.M{"deletion_scheduled_at": bson.M{"$lte": now}},
+		options.Find().SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return 0, err
+	}
+	var due []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &due); err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, u := range due {
+		if _, err := Erase(ctx, u.ID, "user"); err != nil {
+			log.Println("account deletion:", u.ID.Hex(), err)
+			continue
+		}
+		n++
+	}
+	return n, nil
+}
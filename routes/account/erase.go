@@ -0,0 +1,199 @@
+package account
+
+import (
+	"context"
+	"time"
+
+	"Agromi/database"
+	account_models "Agromi/routes/account/models"
+	"Agromi/routes/auth"
+	"Agromi/storage"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// What erasure does with a document referencing the user
+type eraseMode int
+
+const (
+	eraseDelete    eraseMode = iota // Private to the user: removed
+	eraseAnonymize                  // Part of someone else's history: the user's ID is replaced by a ghost ID
+)
+
+// incidentGridDecimals rounds kept incident locations to 0.01°, the village grid of public profiles
+const incidentGridDecimals = 2
+
+// dataRef is one field that holds a user's ID
+type dataRef struct {
+	Collection string
+	Field      string
+	Mode       eraseMode
+	NameField  string   // Cached display name next to the ID, replaced on anonymize
+	Set        bson.M   // Extra changes made on anonymize
+	Omit       []string // Left out of exports
+	NoExport   bool     // Not the user's data (e.g. who blocked them) or internal bookkeeping
+}
+
+// personalData lists every place a farmer or consumer's ID is stored. Both the export and the
+// erasure walk it, so a new collection holding user data only needs adding here.
+var personalData = []dataRef{
+	// Farm records
+	{Collection: "farm_plots", Field: "owner_id", Mode: eraseDelete},
+	{Collection: "crop_tasks", Field: "owner_id", Mode: eraseDelete},
+	{Collection: "ledger_entries", Field: "owner_id", Mode: eraseDelete},
+	{Collection: "soil_tests", Field: "owner_id", Mode: eraseDelete},
+	{Collection: "soil_recommendations", Field: "owner_id", Mode: eraseDelete},
+	{Collection: "mandi_alerts", Field: "user_id", Mode: eraseDelete},
+	{Collection: "weather_advisories", Field: "user_id", Mode: eraseDelete},
+	{Collection: "pest_outbreak_alerts", Field: "user_id", Mode: eraseDelete},
+
+	// Social graph
+	{Collection: "follows", Field: "follower_id", Mode: eraseDelete},
+	{Collection: "follows", Field: "followee_id", Mode: eraseDelete},
+	{Collection: "user_blocks", Field: "blocker_id", Mode: eraseDelete},
+	{Collection: "user_blocks", Field: "blocked_id", Mode: eraseDelete, NoExport: true},
+	{Collection: "tag_follows", Field: "user_id", Mode: eraseDelete},
+	{Collection: "notifications", Field: "recipient_id", Mode: eraseDelete},
+	{Collection: "notifications", Field: "related_id", Mode: eraseDelete, NoExport: true}, // "X followed you"
+
+	// Content others have replied to stays, without the author
+	{Collection: "community_posts", Field: "sender_id", Mode: eraseAnonymize, NameField: "sender_name",
+		Set: bson.M{"is_deleted": true, "content": "", "media_url": "", "attachments": bson.A{}, "tags": bson.A{}, "topic_tags": bson.A{}}},
+	{Collection: "community_post_revisions", Field: "editor_id", Mode: eraseDelete},
+	{Collection: "community_answers", Field: "author_id", Mode: eraseAnonymize, NameField: "author_name"},
+	{Collection: "community_poll_votes", Field: "voter_id", Mode: eraseAnonymize},
+	{Collection: "comments", Field: "sender_id", Mode: eraseAnonymize, NameField: "sender_name"},
+	{Collection: "likes", Field: "sender_id", Mode: eraseAnonymize},
+	{Collection: "reviews", Field: "sender_id", Mode: eraseAnonymize},
+	{Collection: "reviews", Field: "reply.owner_id", Mode: eraseAnonymize},     // Replies to reviews of their listings
+	{Collection: "pest_incidents", Field: "reporter_id", Mode: eraseAnonymize}, // Locations are coarsened first, see Erase
+	{Collection: "media_files", Field: "owner_id", Mode: eraseAnonymize},       // Unreferenced files are removed by the media cleanup job
+	{Collection: "calendar_templates", Field: "author_id", Mode: eraseAnonymize},
+
+	// Chats: own messages go, the other side keeps what they received
+	{Collection: "messages", Field: "sender_id", Mode: eraseDelete},
+	{Collection: "messages", Field: "receiver_id", Mode: eraseAnonymize},
+
+	// Marketplace: listings go, orders and consultations are financial records
+	{Collection: "market_products", Field: "owner_id", Mode: eraseDelete},
+	{Collection: "market_orders", Field: "buyer_id", Mode: eraseAnonymize},
+	{Collection: "market_orders", Field: "seller_id", Mode: eraseAnonymize},
+	{Collection: "consultations", Field: "farmer_id", Mode: eraseAnonymize},
+
+	// Bookkeeping
+	{Collection: "sessions", Field: "user_id", Mode: eraseDelete, Omit: []string{"refresh_hash", "rotated_hashes", "token"}},
+	{Collection: "media_upload_sessions", Field: "owner_id", Mode: eraseDelete, NoExport: true},
+	{Collection: "feed_impressions", Field: "viewer_id", Mode: eraseDelete, NoExport: true},
+	{Collection: "feed_rank_logs", Field: "viewer_id", Mode: eraseDelete, NoExport: true},
+	{Collection: "farmer_recommendations", Field: "_id", Mode: eraseDelete, NoExport: true},
+	{Collection: "data_exports", Field: "user_id", Mode: eraseDelete, NoExport: true},
+}
+
+// Erase removes a farmer or consumer account. Private data is deleted; content that is part of
+// other people's threads, orders or statistics is kept under a fresh ghost ID that cannot be
+// traced back to the user. requestedBy is "user" or "admin".
+func Erase(ctx context.Context, userID primitive.ObjectID, requestedBy string) (account_models.Erasure, error) {
+	ghost := primitive.NewObjectID()
+	result := account_models.Erasure{
+		GhostID:     ghost,
+		RequestedBy: requestedBy,
+		Deleted:     map[string]int64{},
+		Anonymized:  map[string]int64{},
+	}
+
+	// Sign out everywhere first so nothing is written while we clean up
+	auth.RevokeSessions(ctx, userID, auth.RevokeUser)
+	removeExportArchives(ctx, userID)
+
+	// Incidents stay for outbreak statistics, but an exact point would still lead back to the farm.
+	// This runs before the reporter is replaced so a failed run can be repeated.
+	res, err := database.GetCollection("pest_incidents").UpdateMany(ctx,
+		bson.M{"reporter_id": userID},
+		bson.A{bson.M{"$set": bson.M{"location.coordinates": bson.M{"$map": bson.M{
+			"input": "$location.coordinates",
+			"in":    bson.M{"$round": bson.A{"$$this", incidentGridDecimals}},
+		}}}}},
+	)
+	if err != nil {
+		return result, err
+	}
+	result.Anonymized["pest_incidents.location"] = res.ModifiedCount
+
+	for _, ref := range personalData {
+		coll := database.GetCollection(ref.Collection)
+		filter := bson.M{ref.Field: userID}
+		if ref.Mode == eraseDelete {
+			res, err := coll.DeleteMany(ctx, filter)
+			if err != nil {
+				return result, err
+			}
+			result.Deleted[ref.Collection] += res.DeletedCount
+			continue
+		}
+		set := bson.M{ref.Field: ghost}
+		if ref.NameField != "" {
+			set[ref.NameField] = account_models.DeletedUserName
+		}
+		for k, v := range ref.Set {
+			set[k] = v
+		}
+		res, err := coll.UpdateMany(ctx, filter, bson.M{"$set": set})
+		if err != nil {
+			return result, err
+		}
+		result.Anonymized[ref.Collection] += res.ModifiedCount
+	}
+
+	// References inside arrays
+	res, err = database.GetCollection("market_products").UpdateMany(ctx,
+		bson.M{"comments.user_id": userID},
+		bson.M{"$set": bson.M{"comments.$[c].user_id": ghost, "comments.$[c].user_name": account_models.DeletedUserName}},
+		options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"c.user_id": userID}}}),
+	)
+	if err != nil {
+		return result, err
+	}
+	result.Anonymized["market_products.comments"] = res.ModifiedCount
+
+	groups := database.GetCollection("chat_groups")
+	if res, err = groups.UpdateMany(ctx, bson.M{"member_ids": userID}, bson.M{"$pull": bson.M{"member_ids": userID}}); err != nil {
+		return result, err
+	}
+	result.Anonymized["chat_groups"] = res.ModifiedCount
+	groups.UpdateMany(ctx, bson.M{"admin_id": userID}, bson.M{"$set": bson.M{"admin_id": ghost}})
+
+	database.GetCollection("farmer_recommendations").UpdateMany(ctx,
+		bson.M{"items.user_id": userID},
+		bson.M{"$pull": bson.M{"items": bson.M{"user_id": userID}}},
+	)
+
+	// The profile itself goes last, so a failed run is picked up again by the deletion job
+	auth.UnlinkIdentity(ctx, auth.RoleUser, userID)
+	del, err := database.GetCollection("users").DeleteOne(ctx, bson.M{"_id": userID})
+	if err != nil {
+		return result, err
+	}
+	result.Deleted["users"] = del.DeletedCount
+
+	result.ErasedAt = time.Now()
+	result.ID = primitive.NewObjectID()
+	database.GetCollection("account_erasures").InsertOne(ctx, result)
+	return result, nil
+}
+
+// removeExportArchives deletes the stored ZIPs of the user's exports
+func removeExportArchives(ctx context.Context, userID primitive.ObjectID) {
+	cursor, err := database.GetCollection("data_exports").Find(ctx, bson.M{"user_id": userID, "key": bson.M{"$exists": true}})
+	if err != nil {
+		return
+	}
+	var exports []account_models.Export
+	if cursor.All(ctx, &exports) != nil {
+		return
+	}
+	for _, e := range exports {
+		storage.Default().Delete(ctx, e.Key)
+	}
+}
@@ -0,0 +1,254 @@
+package account
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"Agromi/database"
+	account_models "Agromi/routes/account/models"
+	"Agromi/storage"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const exportInterval = time.Minute
+
+// runExportJob builds pending exports one at a time and removes expired archives
+func runExportJob() {
+	if !database.WaitForClient(30 * time.Second) {
+		return
+	}
+
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+	for {
+		for {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+			job, ok := claimExport(ctx)
+			if ok {
+				if err := buildExport(ctx, job); err != nil {
+					log.Println("data export:", job.ID.Hex(), err)
+				}
+			}
+			cancel()
+			if !ok {
+				break
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		if n := expireExports(ctx); n > 0 {
+			log.Println("data export: removed", n, "expired archives")
+		}
+		cancel()
+		<-ticker.C
+	}
+}
+
+// claimExport takes the oldest pending export, or one whose worker died
+func claimExport(ctx context.Context) (account_models.Export, bool) {
+	now := time.Now()
+	var job account_models.Export
+	err := database.GetCollection("data_exports").FindOneAndUpdate(ctx,
+		bson.M{"$or": []bson.M{
+			{"status": account_models.ExportPending},
+			{"status": account_models.ExportRunning, "started_at": bson.M{"$lt": now.Add(-account_models.ExportStaleAfter)}},
+		}},
+		bson.M{"$set": bson.M{"status": account_models.ExportRunning, "started_at": now}},
+		options.FindOneAndUpdate().SetSort(bson.M{"created_at": 1}).SetReturnDocument(options.After),
+	).Decode(&job)
+	return job, err == nil
+}
+
+// buildExport writes one JSON file per collection into a ZIP and stores it
+func buildExport(ctx context.Context, job account_models.Export) error {
+	coll := database.GetCollection("data_exports")
+	fail := func(err error) error {
+		coll.UpdateOne(ctx, bson.M{"_id": job.ID}, bson.M{"$set": bson.M{"status": account_models.ExportFailed, "error": err.Error()}})
+		return err
+	}
+
+	tmp, err := os.CreateTemp("", "export-*.zip")
+	if err != nil {
+		return fail(err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	counts, err := writeArchive(ctx, tmp, job.UserID)
+	if err != nil {
+		return fail(err)
+	}
+	size, err := tmp.Seek(0, io.SeekEnd)
+	if err != nil {
+		return fail(err)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return fail(err)
+	}
+
+	key := fmt.Sprintf("exports/%s/%s.zip", job.UserID.Hex(), job.ID.Hex())
+	if err := storage.Default().Put(ctx, key, tmp, size, "application/zip"); err != nil {
+		return fail(err)
+	}
+
+	now := time.Now()
+	coll.UpdateOne(ctx, bson.M{"_id": job.ID}, bson.M{
+		"$set": bson.M{
+			"status":       account_models.ExportReady,
+			"key":          key,
+			"size_bytes":   size,
+			"collections":  counts,
+			"completed_at": now,
+			"expires_at":   now.Add(account_models.ExportTTL),
+		},
+		"$unset": bson.M{"error": ""},
+	})
+	return nil
+}
+
+// writeArchive adds profile.json, identity.json and <collection>.json for everything in personalData
+func writeArchive(ctx context.Context, w io.Writer, userID primitive.ObjectID) (map[string]int, error) {
+	zw := zip.NewWriter(w)
+	counts := map[string]int{}
+
+	add := func(name string, v interface{}) error {
+		f, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	var profile bson.M
+	opts := options.FindOne().SetProjection(bson.M{"auth_token_num": 0, "name_search": 0})
+	if err := database.GetCollection("users").FindOne(ctx, bson.M{"_id": userID}, opts).Decode(&profile); err != nil {
+		return nil, err
+	}
+	if err := add("profile.json", profile); err != nil {
+		return nil, err
+	}
+	var identity bson.M
+	if database.GetCollection("identities").FindOne(ctx, bson.M{"user_id": userID}, options.FindOne().SetProjection(bson.M{"_id": 0})).Decode(&identity) == nil {
+		if err := add("identity.json", identity); err != nil {
+			return nil, err
+		}
+	}
+
+	// Collections referenced by several fields are merged into one file
+	docs := map[string][]bson.M{}
+	var order []string
+	for _, ref := range personalData {
+		if ref.NoExport {
+			continue
+		}
+		findOpts := options.Find().SetSort(bson.M{"_id": 1})
+		if len(ref.Omit) > 0 {
+			omit := bson.M{}
+			for _, f := range ref.Omit {
+				omit[f] = 0
+			}
+			findOpts.SetProjection(omit)
+		}
+		cursor, err := database.GetCollection(ref.Collection).Find(ctx, bson.M{ref.Field: userID}, findOpts)
+		if err != nil {
+			return nil, err
+		}
+		var rows []bson.M
+		if err := cursor.All(ctx, &rows); err != nil {
+			return nil, err
+		}
+		if _, ok := docs[ref.Collection]; !ok {
+			order = append(order, ref.Collection)
+		}
+		docs[ref.Collection] = append(docs[ref.Collection], rows...)
+	}
+	for _, name := range order {
+		rows := docs[name]
+		if rows == nil {
+			rows = []bson.M{}
+		}
+		counts[name] = len(rows)
+		if err := add(name+".json", rows); err != nil {
+			return nil, err
+		}
+	}
+
+	// Comments on listings are stored inside the products
+	comments, err := productComments(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	counts["market_product_comments"] = len(comments)
+	if err := add("market_product_comments.json", comments); err != nil {
+		return nil, err
+	}
+
+	return counts, zw.Close()
+}
+
+func productComments(ctx context.Context, userID primitive.ObjectID) ([]bson.M, error) {
+	cursor, err := database.GetCollection("market_products").Aggregate(ctx, []bson.M{
+		{"$match": bson.M{"comments.user_id": userID}},
+		{"$unwind": "$comments"},
+		{"$match": bson.M{"comments.user_id": userID}},
+		{"$project": bson.M{"_id": 0, "product_id": "$_id", "product_name": "$name", "comment": "$comments"}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	rows := []bson.M{}
+	return rows, cursor.All(ctx, &rows)
+}
+
+// expireExports deletes archives past their expiry
+func expireExports(ctx context.Context) int {
+	coll := database.GetCollection("data_exports")
+	cursor, err := coll.Find(ctx, bson.M{"status": account_models.ExportReady, "expires_at": bson.M{"$lte": time.Now()}})
+	if err != nil {
+		return 0
+	}
+	var exports []account_models.Export
+	if cursor.All(ctx, &exports) != nil {
+		return 0
+	}
+	n := 0
+	for _, e := range exports {
+		if err := storage.Default().Delete(ctx, e.Key); err != nil && err != storage.ErrNotFound {
+			continue
+		}
+		coll.UpdateOne(ctx, bson.M{"_id": e.ID}, bson.M{"$set": bson.M{"status": account_models.ExportExpired}, "$unset": bson.M{"key": ""}})
+		n++
+	}
+	return n
+}
+
+// createAccountIndexes backs the export queue and the deletion job
+func createAccountIndexes() {
+	if !database.WaitForClient(30 * time.Second) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, _ = database.GetCollection("data_exports").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	_, _ = database.GetCollection("users").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "deletion_scheduled_at", Value: 1}},
+		Options: options.Index().SetSparse(true),
+	})
+}
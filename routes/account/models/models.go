@@ -0,0 +1,50 @@
+package account_models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Deletion and export parameters
+const (
+	DeletionGrace    = 30 * 24 * time.Hour // Time to change one's mind; signing in still works until then
+	ExportTTL        = 7 * 24 * time.Hour  // Finished archives are removed after this
+	ExportCooldown   = 24 * time.Hour      // One export per user per day
+	ExportStaleAfter = 30 * time.Minute    // A running export older than this is assumed lost and retried
+	DeletedUserName  = "Deleted user"      // Shown in place of the name on content kept after erasure
+)
+
+// Export statuses
+const (
+	ExportPending = "pending"
+	ExportRunning = "running"
+	ExportReady   = "ready"
+	ExportFailed  = "failed"
+	ExportExpired = "expired" // Archive removed after ExportTTL
+)
+
+// Export is a "download my data" job (collection: data_exports)
+type Export struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID      primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Status      string             `bson:"status" json:"status"`
+	Key         string             `bson:"key,omitempty" json:"-"` // Storage key of the ZIP
+	SizeBytes   int64              `bson:"size_bytes,omitempty" json:"size_bytes,omitempty"`
+	Collections map[string]int     `bson:"collections,omitempty" json:"collections,omitempty"` // Documents exported per collection
+	Error       string             `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	StartedAt   *time.Time         `bson:"started_at,omitempty" json:"started_at,omitempty"`
+	CompletedAt *time.Time         `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time         `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+}
+
+// Erasure records that an account was erased, without saying whose it was (collection: account_erasures)
+type Erasure struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	GhostID     primitive.ObjectID `bson:"ghost_id" json:"ghost_id"`         // Replaces the user's ID on kept content
+	RequestedBy string             `bson:"requested_by" json:"requested_by"` // "user" or "admin"
+	Deleted     map[string]int64   `bson:"deleted" json:"deleted"`           // Documents removed per collection
+	Anonymized  map[string]int64   `bson:"anonymized" json:"anonymized"`     // Documents rewritten per collection
+	ErasedAt    time.Time          `bson:"erased_at" json:"erased_at"`
+}
@@ -0,0 +1,345 @@
+package admin_audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"Agromi/database"
+	"Agromi/routes/auth"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	collection      = "admin_audit_log"
+	headCollection  = "admin_audit_head" // One document anchoring the newest seq and hash
+	headID          = "head"
+	reasonHeader    = "X-Audit-Reason"
+	reasonKey       = "audit_reason"
+	minReasonChars  = 5
+	maxReasonChars  = 500
+	appendRetries   = 5
+	ActorAnonymous  = "anonymous" // Request carried no valid token
+	redactedValue   = "[redacted]"
+	genesisPrevHash = ""
+)
+
+// Fields never copied into the log
+var redactedFields = map[string]bool{
+	"auth_token_num": true,
+	"name_search":    true,
+	"refresh_hash":   true,
+	"rotated_hashes": true,
+	"token":          true,
+}
+
+// FieldChange is one top-level field that differs before and after an action
+type FieldChange struct {
+	Field  string      `bson:"field" json:"field"`
+	Before interface{} `bson:"before" json:"before"`
+	After  interface{} `bson:"after" json:"after"`
+}
+
+// Entry is one privileged action (collection: admin_audit_log). Entries are only ever inserted;
+// each carries the hash of the previous one so edits and deletions show up in VerifyChain.
+type Entry struct {
+	ID         primitive.ObjectID  `bson:"_id" json:"id"`
+	Seq        int64               `bson:"seq" json:"seq"`
+	At         time.Time           `bson:"at" json:"at"`
+	ActorID    *primitive.ObjectID `bson:"actor_id,omitempty" json:"actor_id,omitempty"`
+	ActorName  string              `bson:"actor_name,omitempty" json:"actor_name,omitempty"`
+	ActorType  string              `bson:"actor_type" json:"actor_type"`
+	IP         string              `bson:"ip" json:"ip"`
+	UserAgent  string              `bson:"user_agent,omitempty" json:"user_agent,omitempty"`
+	Method     string              `bson:"method" json:"method"`
+	Path       string              `bson:"path" json:"path"`
+	Action     string              `bson:"action" json:"action"`           // e.g. "farmer.delete"
+	TargetType string              `bson:"target_type" json:"target_type"` // Collection of the target
+	TargetID   primitive.ObjectID  `bson:"target_id,omitempty" json:"target_id,omitempty"`
+	Reason     string              `bson:"reason,omitempty" json:"reason,omitempty"`
+	Changes    []FieldChange       `bson:"changes" json:"changes"`
+	Details    bson.M              `bson:"details,omitempty" json:"details,omitempty"`
+	PrevHash   string              `bson:"prev_hash" json:"prev_hash"`
+	Hash       string              `bson:"hash" json:"hash"`
+}
+
+// Event describes an action for Record
+type Event struct {
+	Action     string
+	Collection string             // Where the target lives; its state afterwards is read from here
+	TargetID   primitive.ObjectID // Zero for actions without a single target, e.g. imports
+	Before     bson.M             // Snapshot taken before the change; without it (and Created) no diff is recorded
+	After      bson.M             // Only needed when the target is not in Collection afterwards
+	Created    bool               // The action created the target, so every field is new
+	Details    bson.M             // Extra context such as counts or query parameters
+}
+
+// RequireReason rejects destructive requests that do not say why, via the X-Audit-Reason
+// header or the reason query parameter. The reason ends up in the audit entry.
+func RequireReason() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reason := strings.TrimSpace(c.GetHeader(reasonHeader))
+		if reason == "" {
+			reason = strings.TrimSpace(c.Query("reason"))
+		}
+		if len([]rune(reason)) < minReasonChars {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "A reason is required for this action (X-Audit-Reason header or reason parameter)"})
+			return
+		}
+		if len([]rune(reason)) > maxReasonChars {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Reason is too long"})
+			return
+		}
+		c.Set(reasonKey, reason)
+		c.Next()
+	}
+}
+
+// reasonOf returns the checked reason, or an optional one on routes that do not require it
+func reasonOf(c *gin.Context) string {
+	if reason := c.GetString(reasonKey); reason != "" {
+		return reason
+	}
+	reason := strings.TrimSpace(c.GetHeader(reasonHeader))
+	if reason == "" {
+		reason = strings.TrimSpace(c.Query("reason"))
+	}
+	if r := []rune(reason); len(r) > maxReasonChars {
+		reason = string(r[:maxReasonChars])
+	}
+	return reason
+}
+
+// Snapshot reads a document for a before/after comparison; nil if it does not exist
+func Snapshot(ctx context.Context, collection string, id primitive.ObjectID) bson.M {
+	var doc bson.M
+	if database.GetCollection(collection).FindOne(ctx, bson.M{"_id": id}).Decode(&doc) != nil {
+		return nil
+	}
+	return doc
+}
+
+// Only keeps the given fields of a snapshot, for targets whose personal data must not
+// outlive them in the log (e.g. erased accounts)
+func Only(doc bson.M, fields ...string) bson.M {
+	if doc == nil {
+		return nil
+	}
+	out := bson.M{}
+	for _, f := range fields {
+		if v, ok := doc[f]; ok {
+			out[f] = v
+		}
+	}
+	return out
+}
+
+// Record appends an entry for an action that has already happened. Failures are logged and
+// never undo or fail the action itself.
+func Record(ctx context.Context, c *gin.Context, ev Event) {
+	after := ev.After
+	if after == nil && (ev.Before != nil || ev.Created) && ev.Collection != "" && !ev.TargetID.IsZero() {
+		after = Snapshot(ctx, ev.Collection, ev.TargetID)
+	}
+
+	entry := Entry{
+		At:         time.Now().Truncate(time.Millisecond),
+		ActorType:  ActorAnonymous,
+		IP:         c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+		Method:     c.Request.Method,
+		Path:       c.FullPath(),
+		Action:     ev.Action,
+		TargetType: ev.Collection,
+		TargetID:   ev.TargetID,
+		Reason:     reasonOf(c),
+		Changes:    diff(ev.Before, after),
+		Details:    ev.Details,
+	}
+	if actorID := auth.CurrentUserID(c); !actorID.IsZero() {
+		entry.ActorID = &actorID
+		entry.ActorName, entry.ActorType = actorProfile(ctx, actorID)
+	}
+
+	if err := appendEntry(ctx, &entry); err != nil {
+		log.Println("audit:", entry.Action, entry.TargetID.Hex(), err)
+	}
+}
+
+// actorProfile names the caller; consultants are in their own collection
+func actorProfile(ctx context.Context, id primitive.ObjectID) (string, string) {
+	var profile struct {
+		Name     string `bson:"name"`
+		UserType string `bson:"user_type"`
+	}
+	opts := options.FindOne().SetProjection(bson.M{"name": 1, "user_type": 1})
+	if database.GetCollection("users").FindOne(ctx, bson.M{"_id": id}, opts).Decode(&profile) == nil {
+		return profile.Name, profile.UserType
+	}
+	if database.GetCollection("consultants").FindOne(ctx, bson.M{"_id": id}, opts).Decode(&profile) == nil {
+		return profile.Name, "consultant"
+	}
+	return "", "unknown"
+}
+
+// head is the anchor of the chain, kept outside the log: deleting the newest entries leaves every
+// remaining link intact, so VerifyChain compares the end of the log with it.
+type head struct {
+	ID   string    `bson:"_id"`
+	Seq  int64     `bson:"seq"`
+	Hash string    `bson:"hash"`
+	At   time.Time `bson:"at"`
+}
+
+// advanceHead moves the anchor to the entry unless a later one is already anchored
+func advanceHead(ctx context.Context, seq int64, hash string) error {
+	_, err := database.GetCollection(headCollection).UpdateOne(ctx,
+		bson.M{"_id": headID, "seq": bson.M{"$lt": seq}},
+		bson.M{"$set": bson.M{"seq": seq, "hash": hash, "at": time.Now()}},
+		options.Update().SetUpsert(true),
+	)
+	// The upsert collides with the existing anchor when a newer entry got there first
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+// loadHead returns the anchor; ok is false when nothing has been anchored yet
+func loadHead(ctx context.Context) (head, bool, error) {
+	var h head
+	err := database.GetCollection(headCollection).FindOne(ctx, bson.M{"_id": headID}).Decode(&h)
+	if err == mongo.ErrNoDocuments {
+		return h, false, nil
+	}
+	return h, err == nil, err
+}
+
+// appendEntry links the entry to the latest one, inserts it and advances the head anchor.
+// The unique seq index makes concurrent writers collide, and the loser retries on top of the winner.
+func appendEntry(ctx context.Context, entry *Entry) error {
+	coll := database.GetCollection(collection)
+	var err error
+	for i := 0; i < appendRetries; i++ {
+		var last Entry
+		entry.Seq, entry.PrevHash = 1, genesisPrevHash
+		err = coll.FindOne(ctx, bson.M{}, options.FindOne().SetSort(bson.M{"seq": -1}).SetProjection(bson.M{"seq": 1, "hash": 1})).Decode(&last)
+		if err == nil {
+			entry.Seq, entry.PrevHash = last.Seq+1, last.Hash
+		} else if err != mongo.ErrNoDocuments {
+			return err
+		}
+		entry.ID = primitive.NewObjectID()
+		entry.Hash = entryHash(*entry)
+		_, err = coll.InsertOne(ctx, entry)
+		if err == nil {
+			return advanceHead(ctx, entry.Seq, entry.Hash)
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// entryHash covers every field except the hash itself. Values are normalized first so the
+// hash of an entry read back from the database matches the one computed before inserting.
+func entryHash(e Entry) string {
+	actor := ""
+	if e.ActorID != nil {
+		actor = e.ActorID.Hex()
+	}
+	changes := make([]interface{}, len(e.Changes))
+	for i, ch := range e.Changes {
+		changes[i] = []interface{}{ch.Field, normalize(ch.Before), normalize(ch.After)}
+	}
+	raw, _ := json.Marshal([]interface{}{
+		e.PrevHash, e.Seq, e.ID.Hex(), e.At.UnixMilli(),
+		actor, e.ActorName, e.ActorType, e.IP, e.UserAgent, e.Method, e.Path,
+		e.Action, e.TargetType, e.TargetID.Hex(), e.Reason,
+		changes, normalize(e.Details),
+	})
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// normalize turns the document and number types the driver may decode into plain maps,
+// slices and float64, so equal values compare and serialize the same way
+func normalize(v interface{}) interface{} {
+	switch t := v.(type) {
+	case bson.M:
+		out := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			out[k] = normalize(val)
+		}
+		return out
+	case map[string]interface{}:
+		return normalize(bson.M(t))
+	case bson.D:
+		out := make(map[string]interface{}, len(t))
+		for _, e := range t {
+			out[e.Key] = normalize(e.Value)
+		}
+		return out
+	case bson.A:
+		out := make([]interface{}, len(t))
+		for i, val := range t {
+			out[i] = normalize(val)
+		}
+		return out
+	case []interface{}:
+		return normalize(bson.A(t))
+	case int:
+		return float64(t)
+	case int32:
+		return float64(t)
+	case int64:
+		return float64(t)
+	case time.Time:
+		return primitive.NewDateTimeFromTime(t)
+	}
+	return v
+}
+
+// diff lists the top-level fields that differ, with sensitive ones redacted
+func diff(before, after bson.M) []FieldChange {
+	keys := map[string]bool{}
+	for k := range before {
+		keys[k] = true
+	}
+	for k := range after {
+		keys[k] = true
+	}
+	delete(keys, "_id")
+	fields := make([]string, 0, len(keys))
+	for k := range keys {
+		fields = append(fields, k)
+	}
+	sort.Strings(fields)
+
+	changes := []FieldChange{}
+	for _, f := range fields {
+		b, a := normalize(before[f]), normalize(after[f])
+		if reflect.DeepEqual(b, a) {
+			continue
+		}
+		ch := FieldChange{Field: f, Before: before[f], After: after[f]}
+		if redactedFields[f] {
+			ch.Before, ch.After = redactedValue, redactedValue
+		}
+		changes = append(changes, ch)
+	}
+	return changes
+}
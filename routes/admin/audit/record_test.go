@@ -0,0 +1,136 @@
+package admin_audit
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// roundTrip stores and reads back an entry the way the driver does
+func roundTrip(t *testing.T, e Entry) Entry {
+	t.Helper()
+	raw, err := bson.Marshal(e)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var out Entry
+	if err := bson.Unmarshal(raw, &out); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	return out
+}
+
+func TestEntryHashSurvivesBSON(t *testing.T) {
+	actor := primitive.NewObjectID()
+	at := time.Date(2026, 3, 14, 9, 26, 53, 589793238, time.Local) // Sub-millisecond part is dropped by BSON
+
+	tests := []struct {
+		name  string
+		entry Entry
+	}{
+		{"empty details", Entry{}},
+		{"no actor", Entry{Action: "farmer.delete", ActorType: "system"}},
+		{
+			"int fields in changes",
+			Entry{
+				ActorID: &actor,
+				Action:  "farmer.update",
+				Changes: []FieldChange{
+					{Field: "age", Before: 41, After: int64(42)},
+					{Field: "rating", Before: int32(3), After: 4.5},
+					{Field: "name", Before: "Ramesh", After: nil},
+				},
+			},
+		},
+		{
+			"nested documents and arrays",
+			Entry{
+				ActorID: &actor,
+				Action:  "market.order.status",
+				Changes: []FieldChange{
+					{Field: "address", Before: bson.M{"pin": 411001, "lines": []interface{}{"Plot 4", "Pune"}}, After: bson.D{{Key: "pin", Value: int64(411002)}}},
+					{Field: "updated_at", Before: at, After: at.Add(time.Hour)},
+				},
+				Details: bson.M{
+					"count": 3,
+					"ids":   bson.A{primitive.NewObjectID(), primitive.NewObjectID()},
+					"meta":  map[string]interface{}{"source": "csv", "rows": int64(120), "when": at},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := tt.entry
+			e.ID, e.Seq, e.At, e.PrevHash = primitive.NewObjectID(), 7, at, genesisPrevHash
+			e.Hash = entryHash(e)
+
+			back := roundTrip(t, e)
+			if got := entryHash(back); got != e.Hash {
+				t.Errorf("hash after round trip = %s, want %s", got, e.Hash)
+			}
+		})
+	}
+}
+
+func TestEntryHashDetectsEdits(t *testing.T) {
+	base := Entry{
+		ID: primitive.NewObjectID(), Seq: 3, At: time.UnixMilli(1700000000000), PrevHash: genesisPrevHash,
+		ActorType: "admin", Action: "farmer.update", Reason: "duplicate account",
+		Changes: []FieldChange{{Field: "phone", Before: "9000011111", After: "9000022222"}},
+		Details: bson.M{"count": 1},
+	}
+	want := entryHash(base)
+
+	edits := map[string]func(e *Entry){
+		"seq":       func(e *Entry) { e.Seq++ },
+		"at":        func(e *Entry) { e.At = e.At.Add(time.Millisecond) },
+		"prev hash": func(e *Entry) { e.PrevHash = "00" },
+		"reason":    func(e *Entry) { e.Reason = "" },
+		"change":    func(e *Entry) { e.Changes[0].After = "9000033333" },
+		"details":   func(e *Entry) { e.Details = bson.M{"count": 2} },
+	}
+	for name, edit := range edits {
+		t.Run(name, func(t *testing.T) {
+			e := base
+			e.Changes = append([]FieldChange{}, base.Changes...)
+			edit(&e)
+			if entryHash(e) == want {
+				t.Errorf("editing %s did not change the hash", name)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	at := time.UnixMilli(1700000000000)
+	tests := []struct {
+		name string
+		in   interface{}
+		want interface{}
+	}{
+		{"int", 5, float64(5)},
+		{"int32", int32(5), float64(5)},
+		{"int64", int64(5), float64(5)},
+		{"float", 2.5, 2.5},
+		{"string", "x", "x"},
+		{"nil", nil, nil},
+		{"time", at, primitive.NewDateTimeFromTime(at)},
+		{"datetime", primitive.NewDateTimeFromTime(at), primitive.NewDateTimeFromTime(at)},
+		{"bson.D", bson.D{{Key: "a", Value: 1}}, map[string]interface{}{"a": float64(1)}},
+		{"bson.M", bson.M{"a": int32(1)}, map[string]interface{}{"a": float64(1)}},
+		{"map", map[string]interface{}{"a": int64(1)}, map[string]interface{}{"a": float64(1)}},
+		{"bson.A", bson.A{1, "b"}, []interface{}{float64(1), "b"}},
+		{"slice", []interface{}{bson.M{"n": 2}}, []interface{}{map[string]interface{}{"n": float64(2)}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalize(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("normalize(%#v) = %#v, want %#v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,337 @@
+package admin_audit
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"Agromi/core/router"
+	"Agromi/database"
+	"Agromi/routes/auth"
+	"Agromi/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const maxExportRows = 10000
+
+func init() {
+	router.Register(func(r *gin.Engine) {
+		group := r.Group("/api/admin/audit")
+		group.Use(auth.RequireAuth(), auth.RequireRole(auth.RoleAdmin))
+		{
+			group.GET("", ListEntries) // format=csv|json downloads every match
+			group.GET("/verify", VerifyChain)
+			group.GET("/:id", GetEntry)
+		}
+	})
+
+	go createAuditIndexes()
+}
+
+// parseTime accepts RFC 3339 or a plain date
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
+
+// entryFilter builds the query from actor_id, action (prefix, e.g. "farmer." or "farmer.delete"),
+// target_type, target_id, ip, q (reason text), from and to (to is inclusive for plain dates)
+func entryFilter(c *gin.Context) (bson.M, bool) {
+	filter := bson.M{}
+	for param, field := range map[string]string{"actor_id": "actor_id", "target_id": "target_id"} {
+		if s := c.Query(param); s != "" {
+			id, err := primitive.ObjectIDFromHex(s)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param})
+				return nil, false
+			}
+			filter[field] = id
+		}
+	}
+	if action := strings.TrimSpace(c.Query("action")); action != "" {
+		filter["action"] = bson.M{"$regex": "^" + regexp.QuoteMeta(action)}
+	}
+	if targetType := strings.TrimSpace(c.Query("target_type")); targetType != "" {
+		filter["target_type"] = targetType
+	}
+	if actorType := strings.TrimSpace(c.Query("actor_type")); actorType != "" {
+		filter["actor_type"] = actorType
+	}
+	if ip := strings.TrimSpace(c.Query("ip")); ip != "" {
+		filter["ip"] = ip
+	}
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		filter["reason"] = bson.M{"$regex": regexp.QuoteMeta(q), "$options": "i"}
+	}
+
+	at := bson.M{}
+	if s := c.Query("from"); s != "" {
+		t, err := parseTime(s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from"})
+			return nil, false
+		}
+		at["$gte"] = t
+	}
+	if s := c.Query("to"); s != "" {
+		t, err := parseTime(s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to"})
+			return nil, false
+		}
+		if len(s) == len("2006-01-02") {
+			t = t.AddDate(0, 0, 1)
+		}
+		at["$lt"] = t
+	}
+	if len(at) > 0 {
+		filter["at"] = at
+	}
+	return filter, true
+}
+
+// ListEntries is the audit viewer, newest first, with cursor pages. With format=csv or
+// format=json it downloads up to 10000 matching entries instead; the export is itself audited.
+func ListEntries(c *gin.Context) {
+	filter, ok := entryFilter(c)
+	if !ok {
+		return
+	}
+	format := c.Query("format")
+	if format != "" && format != "csv" && format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or json"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	coll := database.GetCollection(collection)
+	opts := options.Find().SetSort(bson.M{"seq": -1})
+
+	if format != "" {
+		opts.SetLimit(maxExportRows)
+		cursor, err := coll.Find(ctx, filter, opts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		entries := []Entry{}
+		if err := cursor.All(ctx, &entries); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Decoding failed"})
+			return
+		}
+		Record(ctx, c, Event{Action: "audit.export", Collection: collection, Details: bson.M{
+			"format": format, "filter": c.Request.URL.RawQuery, "rows": len(entries),
+		}})
+		writeExport(c, format, entries)
+		return
+	}
+
+	limit := utils.ParseLimit(c.Query("limit"), 50, 200)
+	if raw := c.Query("cursor"); raw != "" {
+		cur, err := utils.DecodeCursor(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		filter["seq"] = bson.M{"$lt": int64(cur.Key)}
+	}
+	opts.SetLimit(limit + 1)
+
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	entries := []Entry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Decoding failed"})
+		return
+	}
+
+	nextCursor := ""
+	if int64(len(entries)) > limit {
+		entries = entries[:limit]
+		last := entries[len(entries)-1]
+		nextCursor = utils.EncodeCursor(float64(last.Seq), last.ID)
+	}
+	c.JSON(http.StatusOK, gin.H{"entries": entries, "next_cursor": nextCursor})
+}
+
+func writeExport(c *gin.Context, format string, entries []Entry) {
+	name := "audit_" + time.Now().Format("20060102_150405")
+	if format == "json" {
+		c.Header("Content-Disposition", "attachment; filename="+name+".json")
+		c.JSON(http.StatusOK, entries)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", "attachment; filename="+name+".csv")
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"seq", "at", "actor_id", "actor_name", "actor_type", "ip", "action", "target_type", "target_id", "reason", "changes", "hash"})
+	for _, e := range entries {
+		actor, target := "", ""
+		if e.ActorID != nil {
+			actor = e.ActorID.Hex()
+		}
+		if !e.TargetID.IsZero() {
+			target = e.TargetID.Hex()
+		}
+		changes, _ := json.Marshal(e.Changes)
+		w.Write([]string{
+			strconv.FormatInt(e.Seq, 10), e.At.UTC().Format(time.RFC3339), actor, e.ActorName, e.ActorType, e.IP,
+			e.Action, e.TargetType, target, e.Reason, string(changes), e.Hash,
+		})
+	}
+	w.Flush()
+}
+
+// GetEntry returns one entry with its full diff
+func GetEntry(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var entry Entry
+	err = database.GetCollection(collection).FindOne(ctx, bson.M{"_id": id}).Decode(&entry)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusOK, entry)
+}
+
+// VerifyChain walks the log in order and reports the first entry that was edited, or the
+// first gap where entries were removed. The end of the log must match the head anchor, which
+// catches the newest entries being removed. Query: from_seq (default 1).
+func VerifyChain(c *gin.Context) {
+	from, err := strconv.ParseInt(c.DefaultQuery("from_seq", "1"), 10, 64)
+	if err != nil || from < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from_seq"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	coll := database.GetCollection(collection)
+	prevHash := genesisPrevHash
+	if from > 1 {
+		var prev Entry
+		if err := coll.FindOne(ctx, bson.M{"seq": from - 1}).Decode(&prev); err != nil {
+			c.JSON(http.StatusOK, gin.H{"valid": false, "broken_at": from - 1, "problem": "missing entry"})
+			return
+		}
+		prevHash = prev.Hash
+	}
+
+	// Read before the walk, so entries appended meanwhile are not mistaken for missing ones
+	anchor, ok, err := loadHead(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	cursor, err := coll.Find(ctx, bson.M{"seq": bson.M{"$gte": from}}, options.Find().SetSort(bson.M{"seq": 1}))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	expected, checked := from, 0
+	for cursor.Next(ctx) {
+		var e Entry
+		if err := cursor.Decode(&e); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Decoding failed"})
+			return
+		}
+		problem := ""
+		switch {
+		case e.Seq != expected:
+			problem = "missing entry"
+			e.Seq = expected
+		case e.PrevHash != prevHash:
+			problem = "previous entry changed"
+		case entryHash(e) != e.Hash:
+			problem = "entry changed"
+		}
+		if problem != "" {
+			c.JSON(http.StatusOK, gin.H{"valid": false, "broken_at": e.Seq, "problem": problem, "checked": checked})
+			return
+		}
+		prevHash = e.Hash
+		expected++
+		checked++
+	}
+	if err := cursor.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	lastSeq := expected - 1
+	switch {
+	case !ok && lastSeq > 0:
+		c.JSON(http.StatusOK, gin.H{"valid": false, "broken_at": lastSeq, "problem": "head anchor missing", "checked": checked})
+		return
+	case ok && anchor.Seq > lastSeq:
+		c.JSON(http.StatusOK, gin.H{"valid": false, "broken_at": lastSeq + 1, "problem": "newest entries removed", "checked": checked, "head_seq": anchor.Seq})
+		return
+	case ok:
+		var anchored Entry
+		if err := coll.FindOne(ctx, bson.M{"seq": anchor.Seq}).Decode(&anchored); err != nil || anchored.Hash != anchor.Hash {
+			c.JSON(http.StatusOK, gin.H{"valid": false, "broken_at": anchor.Seq, "problem": "entry does not match head anchor", "checked": checked})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"valid": true, "checked": checked, "last_seq": lastSeq, "head_seq": anchor.Seq})
+}
+
+// createAuditIndexes keeps seq unique (the chain depends on it) and backs the viewer filters.
+// Logs written before the head anchor existed are anchored at their current newest entry.
+func createAuditIndexes() {
+	if !database.WaitForClient(30 * time.Second) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, _ = database.GetCollection(collection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "seq", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "actor_id", Value: 1}, {Key: "seq", Value: -1}}},
+		{Keys: bson.D{{Key: "target_id", Value: 1}, {Key: "seq", Value: -1}}},
+		{Keys: bson.D{{Key: "action", Value: 1}, {Key: "seq", Value: -1}}},
+		{Keys: bson.D{{Key: "at", Value: -1}}},
+	})
+
+	if _, ok, err := loadHead(ctx); err == nil && !ok {
+		var last Entry
+		if database.GetCollection(collection).FindOne(ctx, bson.M{}, options.FindOne().SetSort(bson.M{"seq": -1})).Decode(&last) == nil {
+			_ = advanceHead(ctx, last.Seq, last.Hash)
+		}
+	}
+}
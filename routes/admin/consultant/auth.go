@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"Agromi/database"
+	admin_audit "Agromi/routes/admin/audit"
+	"Agromi/routes/auth"
 	"Agromi/routes/consultant/models"
 
 	"github.com/gin-gonic/gin"
@@ -52,6 +54,7 @@ func AdminCreateConsultant(c *gin.Context) {
 	body.UpdatedAt = time.Now()
 	body.IsBlocked = false
 
+	// Linked to a Firebase account by phone on first login
 	rand.Seed(time.Now().UnixNano())
 	body.AuthTokenNum = fmt.Sprintf("ADMIN-CONS-%d-%d", time.Now().Unix(), rand.Intn(10000))
 
@@ -60,6 +63,7 @@ func AdminCreateConsultant(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create consultant"})
 		return
 	}
+	admin_audit.Record(ctx, c, admin_audit.Event{Action: "consultant.create", Collection: "consultants", TargetID: body.ID, Created: true})
 
 	c.JSON(http.StatusCreated, gin.H{"message": "Consultant created by admin", "id": body.ID})
 }
@@ -82,11 +86,20 @@ func BlockConsultant(c *gin.Context) {
 	defer cancel()
 	coll := database.GetCollection("consultants")
 
+	before := admin_audit.Snapshot(ctx, "consultants", objID)
 	_, err = coll.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": bson.M{"is_blocked": isBlocked}})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 		return
 	}
+	if isBlocked {
+		auth.RevokeSessions(ctx, objID, auth.RevokeBlocked)
+	}
+	auditAction := "consultant.unblock"
+	if isBlocked {
+		auditAction = "consultant.block"
+	}
+	admin_audit.Record(ctx, c, admin_audit.Event{Action: auditAction, Collection: "consultants", TargetID: objID, Before: before})
 
 	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Consultant %sed successfully", action)})
 }
@@ -104,17 +117,21 @@ func DeleteConsultant(c *gin.Context) {
 	defer cancel()
 	coll := database.GetCollection("consultants")
 
+	before := admin_audit.Snapshot(ctx, "consultants", objID)
 	_, err = coll.DeleteOne(ctx, bson.M{"_id": objID})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete consultant"})
 		return
 	}
+	auth.RevokeSessions(ctx, objID, auth.RevokeAdmin)
+	auth.UnlinkIdentity(ctx, auth.RoleConsultant, objID)
+	admin_audit.Record(ctx, c, admin_audit.Event{Action: "consultant.delete", Collection: "consultants", TargetID: objID, Before: before})
 
 	c.JSON(http.StatusOK, gin.H{"message": "Consultant deleted permenantly"})
 }
 
 func RegisterAuthRoutes(router *gin.RouterGroup) {
 	router.POST("/create", AdminCreateConsultant)
-	router.PUT("/manage/block/:id", BlockConsultant)
-	router.DELETE("/manage/delete/:id", DeleteConsultant)
+	router.PUT("/manage/block/:id", admin_audit.RequireReason(), BlockConsultant)
+	router.DELETE("/manage/delete/:id", admin_audit.RequireReason(), DeleteConsultant)
 }
@@ -2,6 +2,7 @@ package admin_consultant
 
 import (
 	"Agromi/core/router"
+	"Agromi/routes/auth"
 
 	"github.com/gin-gonic/gin"
 )
@@ -9,6 +10,7 @@ import (
 func init() {
 	router.Register(func(r *gin.Engine) {
 		adminGroup := r.Group("/api/admin/consultant")
+		adminGroup.Use(auth.RequireAuth(), auth.RequireRole(auth.RoleAdmin))
 		{
 			RegisterAuthRoutes(adminGroup)
 			RegisterAnalyticsRoutes(adminGroup)
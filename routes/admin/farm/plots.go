@@ -0,0 +1,141 @@
+package admin_farm
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"Agromi/core/router"
+	"Agromi/database"
+	"Agromi/routes/auth"
+	farm_models "Agromi/routes/farm/models"
+	"Agromi/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	router.Register(func(r *gin.Engine) {
+		group := r.Group("/api/admin/farm")
+		group.Use(auth.RequireAuth(), auth.RequireRole(auth.RoleAdmin))
+		{
+			group.POST("/plots/within", PlotsWithin)
+		}
+	})
+}
+
+// PlotsWithin finds plots inside a region polygon (e.g. a district boundary) with a summary.
+// Body: area (GeoJSON Polygon or MultiPolygon), mode ("within" default, or "intersects"),
+// optional crop / season / year filters on the season history, limit, cursor.
+func PlotsWithin(c *gin.Context) {
+	var body struct {
+		Area   bson.M `json:"area" binding:"required"`
+		Mode   string `json:"mode"`
+		Crop   string `json:"crop"`
+		Season string `json:"season"`
+		Year   int    `json:"year"`
+		Limit  string `json:"limit"`
+		Cursor string `json:"cursor"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if t, _ := body.Area["type"].(string); t != "Polygon" && t != "MultiPolygon" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "area must be a GeoJSON Polygon or MultiPolygon"})
+		return
+	}
+
+	op := "$geoWithin"
+	switch body.Mode {
+	case "", "within":
+	case "intersects":
+		op = "$geoIntersects" // Also plots crossing the region's border
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be within or intersects"})
+		return
+	}
+
+	filter := bson.M{"boundary": bson.M{op: bson.M{"$geometry": body.Area}}}
+	seasonMatch := bson.M{}
+	if body.Crop != "" {
+		seasonMatch["crop_name"] = bson.M{"$regex": "^" + regexp.QuoteMeta(strings.TrimSpace(body.Crop)) + "$", "$options": "i"}
+	}
+	if body.Season != "" {
+		seasonMatch["season"] = strings.ToLower(body.Season)
+	}
+	if body.Year != 0 {
+		seasonMatch["year"] = body.Year
+	}
+	if len(seasonMatch) > 0 {
+		filter["seasons"] = bson.M{"$elemMatch": seasonMatch}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	coll := database.GetCollection("farm_plots")
+
+	// Summary over the whole region, first page only
+	var summary *bson.M
+	if body.Cursor == "" {
+		cursor, err := coll.Aggregate(ctx, []bson.M{
+			{"$match": filter},
+			{"$group": bson.M{
+				"_id":         nil,
+				"plots":       bson.M{"$sum": 1},
+				"farmers":     bson.M{"$addToSet": "$owner_id"},
+				"total_acres": bson.M{"$sum": "$area_acres"},
+			}},
+			{"$project": bson.M{"_id": 0, "plots": 1, "farmers": bson.M{"$size": "$farmers"}, "total_acres": 1}},
+		})
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid area geometry"})
+			return
+		}
+		var rows []bson.M
+		if err := cursor.All(ctx, &rows); err == nil {
+			s := bson.M{"plots": 0, "farmers": 0, "total_acres": 0}
+			if len(rows) > 0 {
+				s = rows[0]
+			}
+			summary = &s
+		}
+	}
+
+	if body.Cursor != "" {
+		cur, err := utils.DecodeCursor(body.Cursor)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		filter["_id"] = bson.M{"$gt": cur.ID}
+	}
+	limit := utils.ParseLimit(body.Limit, 100, 500)
+	cursor, err := coll.Find(ctx, filter, options.Find().SetSort(bson.M{"_id": 1}).SetLimit(limit+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid area geometry"})
+		return
+	}
+	plots := []farm_models.Plot{}
+	if err := cursor.All(ctx, &plots); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error parsing plots"})
+		return
+	}
+
+	nextCursor := ""
+	if int64(len(plots)) > limit {
+		plots = plots[:limit]
+		nextCursor = utils.EncodeCursor(0, plots[len(plots)-1].ID)
+	}
+
+	resp := gin.H{"plots": plots, "next_cursor": nextCursor}
+	if summary != nil {
+		resp["summary"] = summary
+	}
+	c.JSON(http.StatusOK, resp)
+}
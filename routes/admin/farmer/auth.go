@@ -7,8 +7,10 @@ import (
 
 	"Agromi/core/router"
 	"Agromi/database"
+	"Agromi/routes/account"
+	admin_audit "Agromi/routes/admin/audit"
+	"Agromi/routes/auth"
 
-	// Importing User model
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -18,10 +20,11 @@ func init() {
 	router.Register(func(r *gin.Engine) {
 		adminGroup := r.Group("/api/admin/farmer")
 		// Middleware to check admin auth should be here, skipping for now as per instructions
+		adminGroup.Use(auth.RequireAuth(), auth.RequireRole(auth.RoleAdmin))
 		{
-			adminGroup.PUT("/block/:id", blockFarmer)
-			adminGroup.DELETE("/delete/:id", deleteFarmer)
-			adminGroup.POST("/revoke-tokens/:id", revokeTokens)
+			adminGroup.PUT("/block/:id", admin_audit.RequireReason(), blockFarmer)
+			adminGroup.DELETE("/delete/:id", admin_audit.RequireReason(), deleteFarmer)
+			adminGroup.POST("/revoke-tokens/:id", admin_audit.RequireReason(), revokeTokens)
 		}
 	})
 }
@@ -51,16 +54,23 @@ func blockFarmer(c *gin.Context) {
 	defer cancel()
 
 	usersColl := database.GetCollection("users")
+	before := admin_audit.Snapshot(ctx, "users", objID)
 	_, err = usersColl.UpdateOne(ctx, bson.M{"_id": objID, "user_type": "farmer"}, bson.M{"$set": bson.M{"is_blocked": input.Block}})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update status"})
 		return
 	}
 
-	msg := "Farmer blocked"
+	// A blocked farmer is signed out everywhere, not just refused at the next login
+	if input.Block {
+		auth.RevokeSessions(ctx, objID, auth.RevokeBlocked)
+	}
+
+	action, msg := "farmer.block", "Farmer blocked"
 	if !input.Block {
-		msg = "Farmer unblocked"
+		action, msg = "farmer.unblock", "Farmer unblocked"
 	}
+	admin_audit.Record(ctx, c, admin_audit.Event{Action: action, Collection: "users", TargetID: objID, Before: before})
 	c.JSON(http.StatusOK, gin.H{"message": msg})
 }
 
@@ -72,22 +82,31 @@ func deleteFarmer(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second) // Erasure touches every collection
 	defer cancel()
 
-	usersColl := database.GetCollection("users")
-	res, err := usersColl.DeleteOne(ctx, bson.M{"_id": objID, "user_type": "farmer"})
+	count, err := database.GetCollection("users").CountDocuments(ctx, bson.M{"_id": objID, "user_type": "farmer"})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete farmer"})
 		return
 	}
-	if res.DeletedCount == 0 {
+	if count == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Farmer not found"})
 		return
 	}
 
-	// Also delete sessions
-	database.GetCollection("sessions").DeleteMany(ctx, bson.M{"user_id": objID})
+	// Keep no personal data of an erased account in the log
+	before := admin_audit.Only(admin_audit.Snapshot(ctx, "users", objID), "user_type", "is_blocked", "created_at")
+
+	// Same cascade as a self-service deletion, without the grace period
+	erasure, err := account.Erase(ctx, objID, "admin")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete farmer"})
+		return
+	}
+	admin_audit.Record(ctx, c, admin_audit.Event{Action: "farmer.delete", Collection: "users", TargetID: objID, Before: before, Details: bson.M{
+		"deleted": erasure.Deleted, "anonymized": erasure.Anonymized,
+	}})
 
 	c.JSON(http.StatusOK, gin.H{"message": "Farmer deleted successfully"})
 }
@@ -103,12 +122,13 @@ func revokeTokens(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// Delete all sessions for this user
-	_, err = database.GetCollection("sessions").DeleteMany(ctx, bson.M{"user_id": objID})
+	// Revoking the sessions also invalidates access tokens already issued for them
+	revoked, err := auth.RevokeSessions(ctx, objID, auth.RevokeAdmin)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke tokens"})
 		return
 	}
+	admin_audit.Record(ctx, c, admin_audit.Event{Action: "farmer.revoke_tokens", Collection: "users", TargetID: objID, Details: bson.M{"revoked_sessions": revoked}})
 
-	c.JSON(http.StatusOK, gin.H{"message": "All tokens revoked for farmer"})
+	c.JSON(http.StatusOK, gin.H{"message": "All tokens revoked for farmer", "revoked_sessions": revoked})
 }
@@ -7,8 +7,7 @@ import (
 
 	"Agromi/core/router"
 	"Agromi/database"
-
-	// "Agromi/routes/auth" // Only if we need User struct, otherwise bson.M is fine for counts
+	"Agromi/routes/auth"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
@@ -18,6 +17,7 @@ import (
 func init() {
 	router.Register(func(r *gin.Engine) {
 		group := r.Group("/api/admin/filter")
+		group.Use(auth.RequireAuth(), auth.RequireRole(auth.RoleAdmin))
 		{
 			group.GET("/stats", getStats)
 			group.GET("/active-users", getActiveUsersList)
@@ -83,7 +83,8 @@ func getActiveUsersList(c *gin.Context) {
 	}
 	defer cursor.Close(ctx)
 
-	var users []bson.M // Returning raw BSON to avoid cyclic dep or strict struct if not needed
+	// Decoded into auth.User so login identifiers stay out of the response
+	var users []auth.User
 	if err = cursor.All(ctx, &users); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding users"})
 		return
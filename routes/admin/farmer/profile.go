@@ -7,16 +7,19 @@ import (
 
 	"Agromi/core/router"
 	"Agromi/database"
+	admin_audit "Agromi/routes/admin/audit"
 	"Agromi/routes/auth"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func init() {
 	router.Register(func(r *gin.Engine) {
 		group := r.Group("/api/admin/farmer/profile")
+		group.Use(auth.RequireAuth(), auth.RequireRole(auth.RoleAdmin))
 		{
 			group.POST("/create", createFarmerDirect)
 			group.PUT("/update/:id", updateFarmer)
@@ -36,17 +39,26 @@ func createFarmerDirect(c *gin.Context) {
 		ProfilePhotoURL  string        `json:"profile_photo_url"`
 		Crops            []auth.Crop   `json:"crops"`
 		Location         auth.Location `json:"location"`
+		State            string        `json:"state"`
+		District         string        `json:"district"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	for i := range input.Crops {
+		if err := input.Crops[i].Normalize(time.Now()); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
 
 	user := auth.User{
 		ID:               primitive.NewObjectID(),
 		Phone:            input.Phone,
 		Name:             input.Name,
+		NameSearch:       auth.SearchName(input.Name),
 		UserType:         "farmer",
 		IsBlocked:        false,
 		CreatedAt:        time.Now(),
@@ -54,6 +66,11 @@ func createFarmerDirect(c *gin.Context) {
 		ProfilePhotoURL:  input.ProfilePhotoURL,
 		Crops:            input.Crops,
 		Location:         input.Location,
+		State:            input.State,
+		District:         input.District,
+	}
+	if input.Location.Latitude != 0 || input.Location.Longitude != 0 {
+		user.GeoLocation = &auth.GeoJSON{Type: "Point", Coordinates: []float64{input.Location.Longitude, input.Location.Latitude}}
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -73,6 +90,7 @@ func createFarmerDirect(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create farmer"})
 		return
 	}
+	admin_audit.Record(ctx, c, admin_audit.Event{Action: "farmer.create", Collection: "users", TargetID: user.ID, Created: true})
 
 	c.JSON(http.StatusCreated, user)
 }
@@ -85,28 +103,71 @@ func updateFarmer(c *gin.Context) {
 		return
 	}
 
-	var updateData map[string]interface{}
-	if err := c.ShouldBindJSON(&updateData); err != nil {
+	// Same whitelist as the farmer's own profile, plus phone and the full crop list.
+	// Identity, role and block status have their own endpoints.
+	var input struct {
+		auth.ProfileUpdate
+		Phone *string      `json:"phone"`
+		Crops *[]auth.Crop `json:"crops"`
+	}
+	if err := auth.DecodeStrict(c.Request.Body, &input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Prevent updating ID
-	delete(updateData, "id")
-	delete(updateData, "_id")
+	set, unset, err := input.Apply()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Phone != nil {
+		set["phone"] = *input.Phone
+	}
+	if input.Crops != nil {
+		crops := *input.Crops
+		for i := range crops {
+			if err := crops[i].Normalize(time.Now()); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		set["crops"] = crops
+	}
+	if len(set) == 0 && len(unset) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to update"})
+		return
+	}
+
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	_, err = database.GetCollection("users").UpdateOne(
+	before := admin_audit.Snapshot(ctx, "users", objID)
+	res, err := database.GetCollection("users").UpdateOne(
 		ctx,
 		bson.M{"_id": objID, "user_type": "farmer"},
-		bson.M{"$set": updateData},
+		update,
 	)
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Phone number already in use"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update farmer"})
 		return
 	}
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Farmer not found"})
+		return
+	}
+	admin_audit.Record(ctx, c, admin_audit.Event{Action: "farmer.update", Collection: "users", TargetID: objID, Before: before})
 
 	c.JSON(http.StatusOK, gin.H{"message": "Farmer updated successfully"})
 }
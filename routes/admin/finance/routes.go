@@ -3,6 +3,7 @@ package finance_routes
 import (
 	"Agromi/core/router"
 	sponsor "Agromi/routes/admin/finance/sponsor"
+	"Agromi/routes/auth"
 
 	"github.com/gin-gonic/gin"
 )
@@ -10,11 +11,13 @@ import (
 func init() {
 	router.Register(func(r *gin.Engine) {
 		adminGroup := r.Group("/api/admin")
+		adminGroup.Use(auth.RequireAuth(), auth.RequireRole(auth.RoleAdmin))
 		// Sponsor
 		sponsor.RegisterRoutes(adminGroup)
 
 		// Verify
 		financeGroup := r.Group("/api/admin/finance")
+		financeGroup.Use(auth.RequireAuth(), auth.RequireRole(auth.RoleAdmin))
 		RegisterVerifyRoutes(financeGroup) // Direct call, same package
 	})
 }
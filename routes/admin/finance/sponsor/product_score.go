@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"Agromi/database"
+	admin_audit "Agromi/routes/admin/audit"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
@@ -36,11 +37,13 @@ func UpdateProductScore(c *gin.Context) {
 	coll := database.GetCollection("market_products")
 
 	// Updating 'priority' field as the score
+	before := admin_audit.Snapshot(ctx, "market_products", objID)
 	_, err = coll.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": bson.M{"priority": body.Score}})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product score"})
 		return
 	}
+	admin_audit.Record(ctx, c, admin_audit.Event{Action: "product.score", Collection: "market_products", TargetID: objID, Before: before})
 
 	c.JSON(http.StatusOK, gin.H{"message": "Product score updated successfully", "new_score": body.Score})
 }
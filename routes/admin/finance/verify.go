@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"Agromi/database"
+	admin_audit "Agromi/routes/admin/audit"
 	"Agromi/routes/consultant/models"
 
 	"github.com/gin-gonic/gin"
@@ -59,12 +60,14 @@ func VerifyEntity(c *gin.Context) {
 	}
 
 	coll := database.GetCollection(collName)
+	before := admin_audit.Snapshot(ctx, collName, objID)
 	_, err = coll.UpdateOne(ctx, bson.M{"_id": objID}, update)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update verification status"})
 		return
 	}
+	admin_audit.Record(ctx, c, admin_audit.Event{Action: body.Type + ".verify", Collection: collName, TargetID: objID, Before: before})
 
 	c.JSON(http.StatusOK, gin.H{"message": "Verification status updated", "entity": body.Type, "new_status": body.IsVerified})
 }
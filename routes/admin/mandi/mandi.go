@@ -0,0 +1,183 @@
+package admin_mandi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"Agromi/core/router"
+	"Agromi/database"
+	admin_audit "Agromi/routes/admin/audit"
+	"Agromi/routes/auth"
+	"Agromi/routes/mandi"
+	mandi_models "Agromi/routes/mandi/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	router.Register(func(r *gin.Engine) {
+		group := r.Group("/api/admin/mandi")
+		group.Use(auth.RequireAuth(), auth.RequireRole(auth.RoleAdmin))
+		{
+			group.POST("/import", ImportPrices)
+			group.GET("/markets", ListMarkets)
+			group.PUT("/markets/location", SetMarketLocation)
+		}
+	})
+}
+
+// ImportPrices ingests a data.gov.in mandi price file.
+// Accepts a multipart "file" (format from the extension) or a raw body with ?format=csv|json.
+func ImportPrices(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, mandi_models.MaxImportBytes)
+
+	var reader io.Reader
+	source := c.Query("source")
+	format := strings.ToLower(c.Query("format"))
+
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file required"})
+			return
+		}
+		f, err := fh.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot read file"})
+			return
+		}
+		defer f.Close()
+		reader = f
+		if source == "" {
+			source = fh.Filename
+		}
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fh.Filename)), ".")
+		}
+	} else {
+		reader = c.Request.Body
+		if format == "" && strings.Contains(c.ContentType(), "json") {
+			format = "json"
+		}
+		if format == "" && strings.Contains(c.ContentType(), "csv") {
+			format = "csv"
+		}
+	}
+
+	var rows []map[string]string
+	var err error
+	switch format {
+	case "csv":
+		rows, err = mandi.ParseCSV(reader)
+	case "json":
+		rows, err = mandi.ParseJSON(reader)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or json"})
+		return
+	}
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(rows) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No records in file"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	res, err := mandi.Import(ctx, rows, source)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Import failed", "result": res})
+		return
+	}
+	admin_audit.Record(ctx, c, admin_audit.Event{Action: "mandi.import", Collection: "mandi_prices", Details: bson.M{
+		"source": source, "format": format, "records": res.Records, "inserted": res.Inserted, "updated": res.Updated, "skipped": res.Skipped,
+	}})
+
+	c.JSON(http.StatusOK, res)
+}
+
+// ListMarkets lists known mandis; missing_location=true shows the ones still needing coordinates
+func ListMarkets(c *gin.Context) {
+	filter := bson.M{}
+	if state := c.Query("state"); state != "" {
+		filter["state"] = bson.M{"$regex": "^" + regexp.QuoteMeta(state) + "$", "$options": "i"}
+	}
+	if c.Query("missing_location") == "true" {
+		filter["location"] = bson.M{"$exists": false}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "state", Value: 1}, {Key: "district", Value: 1}, {Key: "name", Value: 1}}).SetLimit(1000)
+	cursor, err := database.GetCollection("mandi_markets").Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	markets := []mandi_models.Market{}
+	if err := cursor.All(ctx, &markets); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error parsing markets"})
+		return
+	}
+	c.JSON(http.StatusOK, markets)
+}
+
+// SetMarketLocation stores a mandi's coordinates so it shows up in nearby price searches
+func SetMarketLocation(c *gin.Context) {
+	var body struct {
+		Key string  `json:"key" binding:"required"`
+		Lat float64 `json:"lat" binding:"required"`
+		Lon float64 `json:"lon" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if body.Lat < -90 || body.Lat > 90 || body.Lon < -180 || body.Lon > 180 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid coordinates"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := database.GetCollection("mandi_markets").UpdateOne(ctx,
+		bson.M{"_id": strings.ToLower(body.Key)},
+		bson.M{"$set": bson.M{
+			"location":   mandi_models.GeoJSON{Type: "Point", Coordinates: []float64{body.Lon, body.Lat}},
+			"updated_at": time.Now(),
+		}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Update failed"})
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Market not found"})
+		return
+	}
+	// Markets are keyed by name, so the key goes in the details
+	admin_audit.Record(ctx, c, admin_audit.Event{Action: "mandi.market_location", Collection: "mandi_markets", Details: bson.M{
+		"key": strings.ToLower(body.Key), "lat": body.Lat, "lon": body.Lon,
+	}})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Location saved"})
+}
@@ -6,14 +6,18 @@ import (
 	"time"
 
 	"Agromi/database"
+	admin_audit "Agromi/routes/admin/audit"
+	"Agromi/routes/auth"
+	"Agromi/routes/market/order"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
-// UpdateProductFields helper to update specific fields
-func updateProduct(c *gin.Context, update bson.M) {
+// UpdateProductFields helper to update specific fields; action names the audit entry
+func updateProduct(c *gin.Context, action string, update bson.M) {
 	idHex := c.Param("id")
 	objID, err := primitive.ObjectIDFromHex(idHex)
 	if err != nil {
@@ -25,28 +29,30 @@ func updateProduct(c *gin.Context, update bson.M) {
 	defer cancel()
 
 	coll := database.GetCollection("market_products")
+	before := admin_audit.Snapshot(ctx, "market_products", objID)
 	_, err = coll.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": update})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Update failed"})
 		return
 	}
+	admin_audit.Record(ctx, c, admin_audit.Event{Action: action, Collection: "market_products", TargetID: objID, Before: before})
 
 	c.JSON(http.StatusOK, gin.H{"message": "Product updated successfully"})
 }
 
 // BlockProduct
 func BlockProduct(c *gin.Context) {
-	updateProduct(c, bson.M{"is_blocked": true})
+	updateProduct(c, "product.block", bson.M{"is_blocked": true})
 }
 
 // UnblockProduct
 func UnblockProduct(c *gin.Context) {
-	updateProduct(c, bson.M{"is_blocked": false})
+	updateProduct(c, "product.unblock", bson.M{"is_blocked": false})
 }
 
 // SponsorProduct
 func SponsorProduct(c *gin.Context) {
-	updateProduct(c, bson.M{"is_sponsored": true})
+	updateProduct(c, "product.sponsor", bson.M{"is_sponsored": true})
 }
 
 // ChangePriority
@@ -58,7 +64,7 @@ func ChangePriority(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	updateProduct(c, bson.M{"priority": body.Priority})
+	updateProduct(c, "product.priority", bson.M{"priority": body.Priority})
 }
 
 // DeleteProduct
@@ -74,22 +80,58 @@ func DeleteProduct(c *gin.Context) {
 	defer cancel()
 
 	coll := database.GetCollection("market_products")
+	before := admin_audit.Snapshot(ctx, "market_products", objID)
 	_, err = coll.DeleteOne(ctx, bson.M{"_id": objID})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Delete failed"})
 		return
 	}
+	admin_audit.Record(ctx, c, admin_audit.Event{Action: "product.delete", Collection: "market_products", TargetID: objID, Before: before})
 
 	c.JSON(http.StatusOK, gin.H{"message": "Product deleted"})
 }
 
+// UpdateCatalogueOrderStatus moves an order for an admin catalogue item, which has no seller account
+func UpdateCatalogueOrderStatus(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var body struct {
+		Status string `json:"status" binding:"required,oneof=accepted completed cancelled"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	before := admin_audit.Snapshot(ctx, "market_orders", objID)
+	_, err = order.SetStatus(ctx, bson.M{"_id": objID, "seller_id": primitive.NilObjectID}, body.Status, auth.CurrentUserID(c))
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusConflict, gin.H{"error": "Order not found, not a catalogue order, or not in a state that allows this change"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Update failed"})
+		return
+	}
+	admin_audit.Record(ctx, c, admin_audit.Event{Action: "order." + body.Status, Collection: "market_orders", TargetID: objID, Before: before})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Order " + body.Status})
+}
+
 func RegisterManageRoutes(router *gin.RouterGroup) {
 	manageGroup := router.Group("/manage")
 	{
-		manageGroup.PUT("/block/:id", BlockProduct)
+		manageGroup.PUT("/block/:id", admin_audit.RequireReason(), BlockProduct)
 		manageGroup.PUT("/unblock/:id", UnblockProduct)
 		manageGroup.PUT("/sponsor/:id", SponsorProduct)
 		manageGroup.PUT("/priority/:id", ChangePriority)
-		manageGroup.DELETE("/delete/:id", DeleteProduct)
+		manageGroup.DELETE("/delete/:id", admin_audit.RequireReason(), DeleteProduct)
+		manageGroup.PUT("/order/status/:id", UpdateCatalogueOrderStatus)
 	}
 }
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"Agromi/database"
+	admin_audit "Agromi/routes/admin/audit"
 	market "Agromi/routes/market/models"
 
 	"github.com/gin-gonic/gin"
@@ -35,6 +36,7 @@ func AddRentItem(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add rental item"})
 		return
 	}
+	admin_audit.Record(ctx, c, admin_audit.Event{Action: "product.create_rent", Collection: "market_products", TargetID: product.ID, Created: true})
 
 	c.JSON(http.StatusCreated, gin.H{"message": "Rental item added", "id": product.ID})
 }
@@ -5,6 +5,7 @@ import (
 	admin_buy "Agromi/routes/admin/market/buy"
 	admin_rent "Agromi/routes/admin/market/rent"
 	admin_sell "Agromi/routes/admin/market/sell"
+	"Agromi/routes/auth"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,6 +13,7 @@ import (
 func init() {
 	router.Register(func(r *gin.Engine) {
 		marketGroup := r.Group("/api/admin/market")
+		marketGroup.Use(auth.RequireAuth(), auth.RequireRole(auth.RoleAdmin))
 		{
 			admin_buy.RegisterRoutes(marketGroup)
 			admin_rent.RegisterRoutes(marketGroup)
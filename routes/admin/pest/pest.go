@@ -0,0 +1,145 @@
+package admin_pest
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"Agromi/core/router"
+	"Agromi/database"
+	"Agromi/routes/auth"
+	pest_models "Agromi/routes/pest/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	router.Register(func(r *gin.Engine) {
+		group := r.Group("/api/admin/pest")
+		group.Use(auth.RequireAuth(), auth.RequireRole(auth.RoleAdmin))
+		{
+			group.GET("/heatmap", Heatmap)
+			group.GET("/outbreaks", ListOutbreaks)
+		}
+	})
+}
+
+// Heatmap buckets incidents into a lat/lon grid for map overlays.
+// Query: cell_deg (grid size, default 0.1), crop, label, category, status, from/to (YYYY-MM-DD, default last 30 days).
+// Each cell has its centre, the incident count and a severity-weighted intensity.
+func Heatmap(c *gin.Context) {
+	cell, err := strconv.ParseFloat(c.Query("cell_deg"), 64)
+	if err != nil || cell < 0.01 || cell > 5 {
+		cell = 0.1
+	}
+
+	to := time.Now()
+	if t, err := time.Parse("2006-01-02", c.Query("to")); err == nil {
+		to = t.AddDate(0, 0, 1)
+	}
+	from := to.AddDate(0, 0, -30)
+	if f, err := time.Parse("2006-01-02", c.Query("from")); err == nil {
+		from = f
+	}
+
+	match := bson.M{"reported_at": bson.M{"$gte": from, "$lt": to}}
+	for _, key := range []string{"crop", "label", "category"} {
+		if v := strings.ToLower(strings.TrimSpace(c.Query(key))); v != "" {
+			match[key] = v
+		}
+	}
+	if status := c.Query("status"); status != "" {
+		match["status"] = status
+	} else {
+		match["status"] = bson.M{"$ne": pest_models.StatusRejected}
+	}
+
+	weight := bson.M{"$switch": bson.M{
+		"branches": []bson.M{
+			{"case": bson.M{"$eq": []string{"$severity", "high"}}, "then": pest_models.SeverityWeight["high"]},
+			{"case": bson.M{"$eq": []string{"$severity", "medium"}}, "then": pest_models.SeverityWeight["medium"]},
+		},
+		"default": pest_models.SeverityWeight["low"],
+	}}
+	bucket := func(axis int) bson.M {
+		return bson.M{"$floor": bson.M{"$divide": []interface{}{bson.M{"$arrayElemAt": []interface{}{"$location.coordinates", axis}}, cell}}}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cursor, err := database.GetCollection("pest_incidents").Aggregate(ctx, []bson.M{
+		{"$match": match},
+		{"$group": bson.M{
+			"_id":       bson.M{"row": bucket(1), "col": bucket(0)},
+			"count":     bson.M{"$sum": 1},
+			"intensity": bson.M{"$sum": weight},
+			"labels":    bson.M{"$addToSet": "$label"},
+		}},
+		{"$sort": bson.M{"intensity": -1}},
+		{"$limit": 5000},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	var rows []struct {
+		ID struct {
+			Row float64 `bson:"row"`
+			Col float64 `bson:"col"`
+		} `bson:"_id"`
+		Count     int      `bson:"count"`
+		Intensity int      `bson:"intensity"`
+		Labels    []string `bson:"labels"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error parsing heatmap"})
+		return
+	}
+
+	round := func(v float64) float64 { return math.Round(v*1e4) / 1e4 }
+	cells := make([]gin.H, 0, len(rows))
+	for _, r := range rows {
+		cells = append(cells, gin.H{
+			"lat":       round((r.ID.Row + 0.5) * cell),
+			"lon":       round((r.ID.Col + 0.5) * cell),
+			"count":     r.Count,
+			"intensity": r.Intensity,
+			"labels":    r.Labels,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"cell_deg": cell, "from": from, "to": to, "cells": cells})
+}
+
+// ListOutbreaks lists outbreaks, most recently active first. Query: status, crop
+func ListOutbreaks(c *gin.Context) {
+	filter := bson.M{}
+	if status := c.Query("status"); status != "" {
+		filter["status"] = status
+	}
+	if crop := strings.ToLower(strings.TrimSpace(c.Query("crop"))); crop != "" {
+		filter["crop"] = crop
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.M{"last_seen": -1}).SetLimit(200)
+	cursor, err := database.GetCollection("pest_outbreaks").Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	outbreaks := []pest_models.Outbreak{}
+	if err := cursor.All(ctx, &outbreaks); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error parsing outbreaks"})
+		return
+	}
+	c.JSON(http.StatusOK, outbreaks)
+}
@@ -7,6 +7,10 @@ import (
 	"time"
 
 	"Agromi/database"
+	admin_audit "Agromi/routes/admin/audit"
+	"Agromi/routes/auth"
+	community_models "Agromi/routes/community/models"
+	"Agromi/routes/social"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
@@ -21,23 +25,229 @@ func DeleteCommentAdmin(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	coll := database.GetCollection("comments")
-	_, err := coll.DeleteOne(ctx, bson.M{"_id": objID})
+	// Replies go with the comment so no orphaned sub-threads are left behind
+	before := admin_audit.Snapshot(ctx, "comments", objID)
+	deleted, err := social.DeleteCommentThread(ctx, bson.M{"_id": objID})
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted by admin"})
+	admin_audit.Record(ctx, c, admin_audit.Event{Action: "comment.delete", Collection: "comments", TargetID: objID, Before: before, Details: bson.M{"deleted_count": deleted}})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted by admin", "deleted_count": deleted})
+}
+
+// DeleteReviewAdmin removes an abusive or fake review and recomputes the target's rating
+func DeleteReviewAdmin(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	before := admin_audit.Snapshot(ctx, "reviews", objID)
+	found, err := social.DeleteReview(ctx, objID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+		return
+	}
+
+	admin_audit.Record(ctx, c, admin_audit.Event{Action: "review.delete", Collection: "reviews", TargetID: objID, Before: before})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Review deleted by admin"})
+}
+
+// DeletePostAdmin soft-deletes any post; it can be brought back with RestorePostAdmin
+func DeletePostAdmin(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	adminID, _ := primitive.ObjectIDFromHex(c.Query("admin_id"))
+	if adminID.IsZero() {
+		adminID = auth.CurrentUserID(c)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	set := bson.M{"is_deleted": true, "deleted_at": now, "updated_at": now}
+	if !adminID.IsZero() {
+		set["deleted_by"] = adminID
+	}
+	before := admin_audit.Snapshot(ctx, "community_posts", objID)
+	res, err := database.GetCollection("community_posts").UpdateOne(ctx,
+		bson.M{"_id": objID, "is_deleted": bson.M{"$ne": true}},
+		bson.M{"$set": set, "$unset": bson.M{"pin": ""}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete"})
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found or already deleted"})
+		return
+	}
+
+	admin_audit.Record(ctx, c, admin_audit.Event{Action: "post.delete", Collection: "community_posts", TargetID: objID, Before: before})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Post deleted by admin"})
+}
+
+// RestorePostAdmin undoes a soft delete by the author or an admin
+func RestorePostAdmin(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	before := admin_audit.Snapshot(ctx, "community_posts", objID)
+	res, err := database.GetCollection("community_posts").UpdateOne(ctx,
+		bson.M{"_id": objID, "is_deleted": true},
+		bson.M{"$set": bson.M{"updated_at": time.Now()}, "$unset": bson.M{"is_deleted": "", "deleted_at": "", "deleted_by": ""}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore"})
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Deleted post not found"})
+		return
+	}
+
+	admin_audit.Record(ctx, c, admin_audit.Event{Action: "post.restore", Collection: "community_posts", TargetID: objID, Before: before})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Post restored"})
+}
+
+// PinPostAdmin pins a post to the top of the feed, optionally only for viewers within radius_km of lat/lon
+func PinPostAdmin(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var body struct {
+		AdminID  string   `json:"admin_id"`
+		Region   string   `json:"region"`
+		Lat      *float64 `json:"lat"`
+		Lon      *float64 `json:"lon"`
+		RadiusKm float64  `json:"radius_km"` // 0 = everywhere
+		Hours    int      `json:"hours"`     // Pin duration, default 72
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if body.Hours <= 0 {
+		body.Hours = community_models.DefaultPinHours
+	}
+	if body.Hours > community_models.MaxPinHours {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Pin duration too long"})
+		return
+	}
+
+	now := time.Now()
+	pin := community_models.PinInfo{
+		Region:    body.Region,
+		PinnedAt:  now,
+		ExpiresAt: now.Add(time.Duration(body.Hours) * time.Hour),
+	}
+	pin.PinnedBy, _ = primitive.ObjectIDFromHex(body.AdminID)
+	if pin.PinnedBy.IsZero() {
+		pin.PinnedBy = auth.CurrentUserID(c)
+	}
+	if body.RadiusKm < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "radius_km cannot be negative"})
+		return
+	}
+	if body.RadiusKm > 0 {
+		if body.Lat == nil || body.Lon == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Regional pins need lat and lon"})
+			return
+		}
+		pin.RadiusKm = body.RadiusKm
+		pin.Center = &community_models.GeoJSON{Type: "Point", Coordinates: []float64{*body.Lon, *body.Lat}}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	before := admin_audit.Snapshot(ctx, "community_posts", objID)
+	res, err := database.GetCollection("community_posts").UpdateOne(ctx,
+		bson.M{"_id": objID, "is_deleted": bson.M{"$ne": true}},
+		bson.M{"$set": bson.M{"pin": pin}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to pin"})
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+
+	admin_audit.Record(ctx, c, admin_audit.Event{Action: "post.pin", Collection: "community_posts", TargetID: objID, Before: before})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Post pinned", "pin": pin})
+}
+
+// UnpinPostAdmin removes a pin before it expires
+func UnpinPostAdmin(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	before := admin_audit.Snapshot(ctx, "community_posts", objID)
+	res, err := database.GetCollection("community_posts").UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$unset": bson.M{"pin": ""}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unpin"})
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+
+	admin_audit.Record(ctx, c, admin_audit.Event{Action: "post.unpin", Collection: "community_posts", TargetID: objID, Before: before})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Post unpinned"})
 }
 
 func init() {
 	router.Register(func(r *gin.Engine) {
 		group := r.Group("/api/admin/social")
+		group.Use(auth.RequireAuth(), auth.RequireRole(auth.RoleAdmin))
 		{
-			group.DELETE("/manage/comment/:id", DeleteCommentAdmin)
-			// Add review deletion here if needed
+			group.DELETE("/manage/comment/:id", admin_audit.RequireReason(), DeleteCommentAdmin)
+			group.DELETE("/manage/review/:id", admin_audit.RequireReason(), DeleteReviewAdmin)
+			group.DELETE("/manage/post/:id", admin_audit.RequireReason(), DeletePostAdmin)
+			group.PUT("/manage/post/restore/:id", RestorePostAdmin)
+			group.PUT("/manage/post/pin/:id", PinPostAdmin)
+			group.DELETE("/manage/post/pin/:id", UnpinPostAdmin)
+			group.GET("/poll/:id/export", ExportPollResults)
 		}
 	})
 }
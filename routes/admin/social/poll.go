@@ -0,0 +1,139 @@
+package admin_social
+
+import (
+	"context"
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"time"
+
+	"Agromi/database"
+	community_models "Agromi/routes/community/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// pollSegment is the vote count of one option within a region or crop segment
+type pollSegment struct {
+	OptionID int    `bson:"option_id" json:"option_id"`
+	Option   string `bson:"-" json:"option"`
+	State    string `bson:"state,omitempty" json:"state,omitempty"`
+	District string `bson:"district,omitempty" json:"district,omitempty"`
+	Crop     string `bson:"crop,omitempty" json:"crop,omitempty"`
+	Votes    int    `bson:"votes" json:"votes"`
+}
+
+// ExportPollResults breaks a poll's votes down by the voters' state/district and crops.
+// Query: format (json|csv, default json)
+func ExportPollResults(c *gin.Context) {
+	pollID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or csv"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var post community_models.Post
+	err = database.GetCollection("community_posts").FindOne(ctx, bson.M{"_id": pollID, "post_type": community_models.PostTypePoll}).Decode(&post)
+	if err != nil || post.Poll == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Poll not found"})
+		return
+	}
+
+	pipeline := []bson.M{
+		{"$match": bson.M{"poll_id": pollID}},
+		{"$lookup": bson.M{"from": "users", "localField": "voter_id", "foreignField": "_id", "as": "user"}},
+		{"$unwind": bson.M{"path": "$user", "preserveNullAndEmptyArrays": true}},
+		{"$unwind": "$option_ids"},
+		{"$facet": bson.M{
+			"by_region": []bson.M{
+				{"$group": bson.M{
+					"_id": bson.M{
+						"option_id": "$option_ids",
+						"state":     bson.M{"$ifNull": []interface{}{"$user.state", "Unknown"}},
+						"district":  bson.M{"$ifNull": []interface{}{"$user.district", "Unknown"}},
+					},
+					"votes": bson.M{"$sum": 1},
+				}},
+				{"$project": bson.M{"_id": 0, "option_id": "$_id.option_id", "state": "$_id.state", "district": "$_id.district", "votes": 1}},
+				{"$sort": bson.D{{Key: "state", Value: 1}, {Key: "district", Value: 1}, {Key: "option_id", Value: 1}}},
+			},
+			"by_crop": []bson.M{
+				{"$unwind": bson.M{"path": "$user.crops", "preserveNullAndEmptyArrays": true}},
+				// A voter listing the same crop twice still counts once
+				{"$group": bson.M{"_id": bson.M{
+					"option_id": "$option_ids",
+					"crop":      bson.M{"$toLower": bson.M{"$ifNull": []interface{}{"$user.crops.name", "none"}}},
+					"voter":     "$voter_id",
+				}}},
+				{"$group": bson.M{
+					"_id":   bson.M{"option_id": "$_id.option_id", "crop": "$_id.crop"},
+					"votes": bson.M{"$sum": 1},
+				}},
+				{"$project": bson.M{"_id": 0, "option_id": "$_id.option_id", "crop": "$_id.crop", "votes": 1}},
+				{"$sort": bson.D{{Key: "crop", Value: 1}, {Key: "option_id", Value: 1}}},
+			},
+		}},
+	}
+
+	cursor, err := database.GetCollection("community_poll_votes").Aggregate(ctx, pipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	var facets []struct {
+		ByRegion []pollSegment `bson:"by_region"`
+		ByCrop   []pollSegment `bson:"by_crop"`
+	}
+	if err := cursor.All(ctx, &facets); err != nil || len(facets) == 0 {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error aggregating votes"})
+		return
+	}
+	byRegion, byCrop := facets[0].ByRegion, facets[0].ByCrop
+
+	optionText := func(id int) string {
+		if id >= 0 && id < len(post.Poll.Options) {
+			return post.Poll.Options[id].Text
+		}
+		return ""
+	}
+	for i := range byRegion {
+		byRegion[i].Option = optionText(byRegion[i].OptionID)
+	}
+	for i := range byCrop {
+		byCrop[i].Option = optionText(byCrop[i].OptionID)
+	}
+
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv")
+		c.Header("Content-Disposition", "attachment; filename=poll_"+pollID.Hex()+".csv")
+		w := csv.NewWriter(c.Writer)
+		w.Write([]string{"segment", "state", "district", "crop", "option_id", "option", "votes"})
+		for _, s := range byRegion {
+			w.Write([]string{"region", s.State, s.District, "", strconv.Itoa(s.OptionID), s.Option, strconv.Itoa(s.Votes)})
+		}
+		for _, s := range byCrop {
+			w.Write([]string{"crop", "", "", s.Crop, strconv.Itoa(s.OptionID), s.Option, strconv.Itoa(s.Votes)})
+		}
+		w.Flush()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"poll_id":     pollID,
+		"question":    post.Content,
+		"options":     post.Poll.Options,
+		"voter_count": post.Poll.VoterCount,
+		"by_region":   byRegion,
+		"by_crop":     byCrop,
+	})
+}
@@ -0,0 +1,109 @@
+package advisory
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"Agromi/database"
+	advisory_models "Agromi/routes/advisory/models"
+	"Agromi/routes/auth"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GetMyAdvisories returns the farmer's active weather advisories and the forecast they came from.
+// Rules are re-run on each call so a new crop or location shows up without waiting for the job.
+func GetMyAdvisories(c *gin.Context) {
+	userID := auth.CurrentUserID(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	var user auth.User
+	if err := database.GetCollection("users").FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	forecast, err := refreshUser(ctx, user)
+	if err == errNoLocation {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		// Advisories already stored are still worth showing
+		log.Println("Advisories: forecast unavailable:", err)
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "event_date", Value: 1}, {Key: "_id", Value: 1}}).SetLimit(50)
+	cursor, err := database.GetCollection("weather_advisories").Find(ctx,
+		bson.M{"user_id": userID, "valid_until": bson.M{"$gte": time.Now()}},
+		opts,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	advisories := []advisory_models.Advisory{}
+	if err := cursor.All(ctx, &advisories); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error parsing advisories"})
+		return
+	}
+
+	resp := gin.H{"advisories": advisories}
+	if len(forecast.Days) > 0 {
+		resp["forecast"] = forecast
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// runAdvisoryJob refreshes every located farmer's advisories. Farmers in one grid cell share a forecast fetch.
+func runAdvisoryJob() {
+	if !database.WaitForClient(30 * time.Second) {
+		log.Println("Advisories: database not ready, job not started")
+		return
+	}
+
+	ticker := time.NewTicker(forecastTTL)
+	defer ticker.Stop()
+	for {
+		refreshAll()
+		<-ticker.C
+	}
+}
+
+func refreshAll() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	filter := bson.M{
+		"user_type":    "farmer",
+		"is_blocked":   bson.M{"$ne": true},
+		"geo_location": bson.M{"$exists": true},
+	}
+	opts := options.Find().SetProjection(bson.M{"crops": 1, "geo_location": 1})
+	cursor, err := database.GetCollection("users").Find(ctx, filter, opts)
+	if err != nil {
+		log.Println("Advisories: user query failed:", err)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	users, failed := 0, 0
+	for cursor.Next(ctx) {
+		var user auth.User
+		if cursor.Decode(&user) != nil {
+			continue
+		}
+		users++
+		if _, err := refreshUser(ctx, user); err != nil {
+			failed++
+		}
+	}
+	log.Printf("Advisories: refreshed %d farmers (%d without forecast)", users, failed)
+}
@@ -0,0 +1,118 @@
+package advisory
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"Agromi/database"
+	advisory_models "Agromi/routes/advisory/models"
+	"Agromi/routes/auth"
+	farm_models "Agromi/routes/farm/models"
+	"Agromi/routes/social"
+	"Agromi/weather"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Forecasts are refetched after this long
+const forecastTTL = 3 * time.Hour
+
+// errNoLocation is returned for farmers without a saved location
+var errNoLocation = errors.New("set your farm location to get weather advisories")
+
+// forecastFor returns the cached forecast of the point's grid cell, fetching it when stale
+func forecastFor(ctx context.Context, lat, lon float64) (weather.Forecast, error) {
+	cell, _, _ := weather.Cell(lat, lon)
+	coll := database.GetCollection("weather_forecasts")
+
+	var cached advisory_models.CachedForecast
+	err := coll.FindOne(ctx, bson.M{"_id": cell, "expires_at": bson.M{"$gt": time.Now()}}).Decode(&cached)
+	if err == nil {
+		return cached.Forecast, nil
+	}
+
+	f, err := weather.Default().Forecast(ctx, lat, lon)
+	if err != nil {
+		return f, err
+	}
+	coll.UpdateOne(ctx,
+		bson.M{"_id": cell},
+		bson.M{"$set": bson.M{"forecast": f, "expires_at": time.Now().Add(forecastTTL)}},
+		options.Update().SetUpsert(true),
+	)
+	return f, nil
+}
+
+// growingCrops lists the farmer's crops in the field: profile crops and plot seasons not yet harvested
+func growingCrops(ctx context.Context, user auth.User, now time.Time) []growingCrop {
+	var out []growingCrop
+	seen := map[string]bool{}
+	add := func(name string, sowing *time.Time) {
+		if sowing == nil || strings.TrimSpace(name) == "" {
+			return
+		}
+		stage := cropStage(name, *sowing, now)
+		key := strings.ToLower(name) + "|" + stage
+		if stage == "" || seen[key] {
+			return
+		}
+		seen[key] = true
+		out = append(out, growingCrop{Name: name, Stage: stage})
+	}
+
+	for _, c := range user.Crops {
+		add(c.Name, c.SowingDate)
+	}
+
+	opts := options.Find().SetProjection(bson.M{"seasons": 1})
+	cursor, err := database.GetCollection("farm_plots").Find(ctx, bson.M{"owner_id": user.ID}, opts)
+	if err == nil {
+		var plots []farm_models.Plot
+		if cursor.All(ctx, &plots) == nil {
+			for _, p := range plots {
+				for _, s := range p.Seasons {
+					if s.HarvestDate == nil {
+						add(s.CropName, s.SowingDate)
+					}
+				}
+			}
+		}
+	}
+	return out
+}
+
+// refreshUser evaluates the rules for one farmer and stores new advisories.
+// New advisories are sent as notifications; ones already stored are not repeated.
+func refreshUser(ctx context.Context, user auth.User) (weather.Forecast, error) {
+	if user.GeoLocation == nil || len(user.GeoLocation.Coordinates) != 2 {
+		return weather.Forecast{}, errNoLocation
+	}
+	lon, lat := user.GeoLocation.Coordinates[0], user.GeoLocation.Coordinates[1]
+
+	f, err := forecastFor(ctx, lat, lon)
+	if err != nil {
+		return f, err
+	}
+
+	now := time.Now()
+	coll := database.GetCollection("weather_advisories")
+	for _, a := range evaluate(f, growingCrops(ctx, user, now), now) {
+		a.ID = primitive.NewObjectID()
+		a.UserID = user.ID
+		a.Notified = true
+		res, err := coll.UpdateOne(ctx,
+			bson.M{"user_id": user.ID, "dedupe_key": a.DedupeKey},
+			bson.M{"$setOnInsert": a},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil || res.UpsertedCount == 0 {
+			continue
+		}
+		social.CreateNotification(ctx, user.ID, "weather_advisory", a.Title+": "+a.Message, a.ID)
+	}
+	return f, nil
+}
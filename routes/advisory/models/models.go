@@ -0,0 +1,50 @@
+package advisory_models
+
+import (
+	"time"
+
+	"Agromi/weather"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Severities
+const (
+	SeverityInfo    = "info"
+	SeverityWarning = "warning"
+	SeveritySevere  = "severe"
+)
+
+// Crop growth stages, from days since sowing
+const (
+	StageGermination = "germination"
+	StageVegetative  = "vegetative"
+	StageFlowering   = "flowering"
+	StageMaturity    = "maturity"
+)
+
+// CachedForecast is a provider forecast stored per grid cell (collection: weather_forecasts)
+type CachedForecast struct {
+	Cell      string           `bson:"_id" json:"cell"`
+	Forecast  weather.Forecast `bson:"forecast" json:"forecast"`
+	ExpiresAt time.Time        `bson:"expires_at" json:"expires_at"`
+}
+
+// Advisory is one weather advisory for a farmer (collection: weather_advisories).
+// DedupeKey (rule, crop and event date) keeps a recurring forecast from notifying twice.
+type Advisory struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Cell       string             `bson:"cell" json:"cell"`
+	Rule       string             `bson:"rule" json:"rule"`
+	CropName   string             `bson:"crop_name,omitempty" json:"crop_name,omitempty"`
+	Stage      string             `bson:"stage,omitempty" json:"stage,omitempty"`
+	Severity   string             `bson:"severity" json:"severity"`
+	Title      string             `bson:"title" json:"title"`
+	Message    string             `bson:"message" json:"message"`
+	EventDate  time.Time          `bson:"event_date" json:"event_date"`
+	ValidUntil time.Time          `bson:"valid_until" json:"valid_until"`
+	DedupeKey  string             `bson:"dedupe_key" json:"-"`
+	Notified   bool               `bson:"notified" json:"-"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+}
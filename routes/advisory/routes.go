@@ -0,0 +1,43 @@
+package advisory
+
+import (
+	"context"
+	"time"
+
+	"Agromi/core/router"
+	"Agromi/database"
+	"Agromi/routes/auth"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	router.Register(func(r *gin.Engine) {
+		r.GET("/api/farmer/advisories", auth.RequireAuth(), auth.RequireRole(auth.RoleUser), GetMyAdvisories)
+	})
+
+	go createAdvisoryIndexes()
+	go runAdvisoryJob()
+}
+
+// createAdvisoryIndexes dedupes advisories per farmer and expires old forecasts and advisories
+func createAdvisoryIndexes() {
+	if !database.WaitForClient(30 * time.Second) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, _ = database.GetCollection("weather_advisories").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "dedupe_key", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "valid_until", Value: 1}}},
+		{Keys: bson.D{{Key: "valid_until", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(14 * 24 * 3600)},
+	})
+	_, _ = database.GetCollection("weather_forecasts").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(24 * 3600)},
+	})
+}
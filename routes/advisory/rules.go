@@ -0,0 +1,227 @@
+package advisory
+
+import (
+	"fmt"
+	"time"
+
+	advisory_models "Agromi/routes/advisory/models"
+	"Agromi/weather"
+)
+
+// growingCrop is a crop currently in the field
+type growingCrop struct {
+	Name  string
+	Stage string
+}
+
+// finding is what a rule concludes for one crop (or the farm in general)
+type finding struct {
+	Severity  string
+	Title     string
+	Message   string
+	EventDate time.Time
+}
+
+// rule inspects the forecast for one crop; crop is nil for farm-wide advice
+type rule struct {
+	Name string
+	Eval func(days []weather.Day, crop *growingCrop) *finding
+}
+
+// Thresholds
+const (
+	heavyRain48hMM  = 40.0
+	heavyRainDayMM  = 30.0
+	severeRainMM    = 65.0
+	sprayWindKph    = 25.0
+	heatStressC     = 40.0
+	coldWaveC       = 4.0
+	fungalHumidity  = 85.0
+	dryRainTotalMM  = 2.0
+	dryHeatC        = 35.0
+	lookAheadDays   = 5
+	rainWindowDays  = 3 // Today plus the next 48h
+	fungalStreak    = 2
+	minRainProbPerc = 60.0
+)
+
+// window returns the first n forecast days
+func window(days []weather.Day, n int) []weather.Day {
+	if len(days) < n {
+		return days
+	}
+	return days[:n]
+}
+
+func cropName(crop *growingCrop) string {
+	if crop == nil {
+		return ""
+	}
+	return crop.Name
+}
+
+// rules run in order for every growing crop, and once with a nil crop for farm-wide advice
+var rules = []rule{
+	{"heavy_rain", func(days []weather.Day, crop *growingCrop) *finding {
+		total, peak := 0.0, weather.Day{}
+		for _, d := range window(days, rainWindowDays) {
+			total += d.RainMM
+			if d.RainMM > peak.RainMM {
+				peak = d
+			}
+		}
+		if total < heavyRain48hMM && (peak.RainMM < heavyRainDayMM || peak.RainProb < minRainProbPerc) {
+			return nil
+		}
+		f := &finding{Severity: advisory_models.SeverityWarning, EventDate: peak.Date, Title: "Heavy rain in 48h"}
+		if total >= severeRainMM {
+			f.Severity = advisory_models.SeveritySevere
+		}
+		switch {
+		case crop == nil:
+			f.Message = fmt.Sprintf("About %.0f mm of rain expected in the next 48 hours. Clear field drains.", total)
+		case crop.Stage == advisory_models.StageMaturity:
+			f.Message = fmt.Sprintf("Heavy rain (%.0f mm) in 48h: harvest mature %s early if you can and keep the produce under cover.", total, crop.Name)
+		default:
+			f.Message = fmt.Sprintf("Heavy rain (%.0f mm) in 48h: delay spraying and fertilizer on your %s, it would wash off.", total, crop.Name)
+		}
+		return f
+	}},
+	{"high_wind", func(days []weather.Day, crop *growingCrop) *finding {
+		if crop == nil || crop.Stage == advisory_models.StageMaturity {
+			return nil
+		}
+		for _, d := range window(days, rainWindowDays) {
+			if d.WindKph >= sprayWindKph {
+				return &finding{
+					Severity:  advisory_models.SeverityInfo,
+					EventDate: d.Date,
+					Title:     "Strong wind, avoid spraying",
+					Message:   fmt.Sprintf("Wind up to %.0f km/h on %s: spray drift is likely, postpone spraying on your %s.", d.WindKph, d.Date.Format("02 Jan"), crop.Name),
+				}
+			}
+		}
+		return nil
+	}},
+	{"heat_stress", func(days []weather.Day, crop *growingCrop) *finding {
+		for _, d := range window(days, lookAheadDays) {
+			if d.TempMaxC < heatStressC {
+				continue
+			}
+			f := &finding{Severity: advisory_models.SeverityWarning, EventDate: d.Date, Title: "Heat wave"}
+			switch {
+			case crop == nil:
+				f.Message = fmt.Sprintf("Temperatures up to %.0f°C on %s. Keep livestock in shade with water.", d.TempMaxC, d.Date.Format("02 Jan"))
+			case crop.Stage == advisory_models.StageFlowering:
+				f.Severity = advisory_models.SeveritySevere
+				f.Message = fmt.Sprintf("%.0f°C on %s while your %s is flowering: irrigate in the evening to limit flower drop.", d.TempMaxC, d.Date.Format("02 Jan"), crop.Name)
+			default:
+				f.Message = fmt.Sprintf("%.0f°C on %s: keep the soil of your %s moist and avoid midday field work.", d.TempMaxC, d.Date.Format("02 Jan"), crop.Name)
+			}
+			return f
+		}
+		return nil
+	}},
+	{"cold_wave", func(days []weather.Day, crop *growingCrop) *finding {
+		for _, d := range window(days, lookAheadDays) {
+			if d.TempMinC > coldWaveC {
+				continue
+			}
+			f := &finding{Severity: advisory_models.SeverityWarning, EventDate: d.Date, Title: "Frost risk"}
+			if crop == nil {
+				f.Message = fmt.Sprintf("Night temperatures down to %.0f°C on %s.", d.TempMinC, d.Date.Format("02 Jan"))
+			} else {
+				f.Message = fmt.Sprintf("Down to %.0f°C on %s: a light irrigation the evening before protects your %s from frost.", d.TempMinC, d.Date.Format("02 Jan"), crop.Name)
+			}
+			return f
+		}
+		return nil
+	}},
+	{"fungal_risk", func(days []weather.Day, crop *growingCrop) *finding {
+		if crop == nil || (crop.Stage != advisory_models.StageVegetative && crop.Stage != advisory_models.StageFlowering) {
+			return nil
+		}
+		streak := 0
+		for _, d := range window(days, lookAheadDays) {
+			if d.Humidity >= fungalHumidity && d.TempMaxC >= 20 && d.TempMaxC <= 32 {
+				streak++
+			} else {
+				streak = 0
+			}
+			if streak == fungalStreak {
+				return &finding{
+					Severity:  advisory_models.SeverityInfo,
+					EventDate: d.Date,
+					Title:     "Fungal disease risk",
+					Message:   fmt.Sprintf("Warm, humid days ahead: check your %s for leaf spots and mildew and keep a fungicide ready.", crop.Name),
+				}
+			}
+		}
+		return nil
+	}},
+	{"dry_spell", func(days []weather.Day, crop *growingCrop) *finding {
+		if crop == nil || (crop.Stage != advisory_models.StageVegetative && crop.Stage != advisory_models.StageFlowering) || len(days) == 0 {
+			return nil
+		}
+		total, hottest := 0.0, days[0]
+		for _, d := range days {
+			total += d.RainMM
+			if d.TempMaxC > hottest.TempMaxC {
+				hottest = d
+			}
+		}
+		if total >= dryRainTotalMM || hottest.TempMaxC < dryHeatC {
+			return nil
+		}
+		return &finding{
+			Severity:  advisory_models.SeverityInfo,
+			EventDate: days[0].Date,
+			Title:     "Dry spell, plan irrigation",
+			Message:   fmt.Sprintf("No rain expected for %d days and up to %.0f°C: plan irrigation for your %s.", len(days), hottest.TempMaxC, crop.Name),
+		}
+	}},
+}
+
+// evaluate runs every rule against the forecast.
+// Farm-wide advice is only given when no crop-specific advice from the same rule exists.
+func evaluate(f weather.Forecast, crops []growingCrop, now time.Time) []advisory_models.Advisory {
+	var out []advisory_models.Advisory
+	for _, r := range rules {
+		matched := false
+		add := func(crop *growingCrop, fd *finding) {
+			stage := ""
+			if crop != nil {
+				stage = crop.Stage
+			}
+			event := fd.EventDate
+			if event.IsZero() {
+				event = now
+			}
+			out = append(out, advisory_models.Advisory{
+				Cell:       f.Cell,
+				Rule:       r.Name,
+				CropName:   cropName(crop),
+				Stage:      stage,
+				Severity:   fd.Severity,
+				Title:      fd.Title,
+				Message:    fd.Message,
+				EventDate:  event,
+				ValidUntil: event.Add(24 * time.Hour),
+				DedupeKey:  r.Name + "|" + cropName(crop) + "|" + event.Format("2006-01-02"),
+				CreatedAt:  now,
+			})
+		}
+		for i := range crops {
+			if fd := r.Eval(f.Days, &crops[i]); fd != nil {
+				add(&crops[i], fd)
+				matched = true
+			}
+		}
+		if !matched {
+			if fd := r.Eval(f.Days, nil); fd != nil {
+				add(nil, fd)
+			}
+		}
+	}
+	return out
+}
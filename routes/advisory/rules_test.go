@@ -0,0 +1,144 @@
+package advisory
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	advisory_models "Agromi/routes/advisory/models"
+	"Agromi/weather"
+)
+
+// Fixture cells in testdata/weather; anything else reads default.json
+const (
+	fixtureDir  = "../../testdata/weather"
+	monsoonLat  = 0.05 // default.json: heavy rain, wind and humid days
+	monsoonLon  = 0.05
+	heatwaveLat = 19.05 // 190_730.json: dry and above 40°C
+	heatwaveLon = 73.05
+	coldLat     = 30.05 // 300_750.json: frost on day 2
+	coldLon     = 75.05
+)
+
+func TestEvaluateFixtures(t *testing.T) {
+	provider := weather.NewFileProvider(fixtureDir)
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		lat, lon float64
+		crops    []growingCrop
+		want     []string // rule|crop|severity|days from today, in output order
+	}{
+		{
+			name: "monsoon, flowering and mature crops",
+			lat:  monsoonLat, lon: monsoonLon,
+			crops: []growingCrop{{"cotton", advisory_models.StageFlowering}, {"wheat", advisory_models.StageMaturity}},
+			want: []string{
+				"heavy_rain|cotton|severe|2",
+				"heavy_rain|wheat|severe|2",
+				"high_wind|cotton|info|2",
+				"fungal_risk|cotton|info|2",
+			},
+		},
+		{
+			name: "monsoon, no crops gives farm-wide advice only",
+			lat:  monsoonLat, lon: monsoonLon,
+			want: []string{"heavy_rain||severe|2"},
+		},
+		{
+			name: "monsoon, germinating crop skips stage-specific rules",
+			lat:  monsoonLat, lon: monsoonLon,
+			crops: []growingCrop{{"maize", advisory_models.StageGermination}},
+			want:  []string{"heavy_rain|maize|severe|2", "high_wind|maize|info|2"},
+		},
+		{
+			name: "heat wave",
+			lat:  heatwaveLat, lon: heatwaveLon,
+			crops: []growingCrop{{"soybean", advisory_models.StageFlowering}, {"onion", advisory_models.StageVegetative}},
+			want: []string{
+				"heat_stress|soybean|severe|3",
+				"heat_stress|onion|warning|3",
+				"dry_spell|soybean|info|0",
+				"dry_spell|onion|info|0",
+			},
+		},
+		{
+			name: "heat wave, mature crop",
+			lat:  heatwaveLat, lon: heatwaveLon,
+			crops: []growingCrop{{"wheat", advisory_models.StageMaturity}},
+			want:  []string{"heat_stress|wheat|warning|3"},
+		},
+		{
+			name: "cold wave",
+			lat:  coldLat, lon: coldLon,
+			crops: []growingCrop{{"wheat", advisory_models.StageVegetative}},
+			want:  []string{"cold_wave|wheat|warning|2"},
+		},
+		{
+			name: "cold wave, no crops",
+			lat:  coldLat, lon: coldLon,
+			want: []string{"cold_wave||warning|2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := provider.Forecast(context.Background(), tt.lat, tt.lon)
+			if err != nil {
+				t.Fatalf("Forecast: %v", err)
+			}
+			if cell, _, _ := weather.Cell(tt.lat, tt.lon); f.Cell != cell {
+				t.Fatalf("forecast cell = %q, want %q", f.Cell, cell)
+			}
+
+			out := evaluate(f, tt.crops, now)
+			got := make([]string, len(out))
+			for i, a := range out {
+				days := int(a.EventDate.Sub(today).Hours() / 24)
+				got[i] = fmt.Sprintf("%s|%s|%s|%d", a.Rule, a.CropName, a.Severity, days)
+
+				if want := a.Rule + "|" + a.CropName + "|" + a.EventDate.Format("2006-01-02"); a.DedupeKey != want {
+					t.Errorf("dedupe key = %q, want %q", a.DedupeKey, want)
+				}
+				if a.ValidUntil != a.EventDate.Add(24*time.Hour) {
+					t.Errorf("%s valid until %v, want a day after %v", a.Rule, a.ValidUntil, a.EventDate)
+				}
+			}
+			if len(got) == 0 {
+				got = nil
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("advisories = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCropStage(t *testing.T) {
+	sowing := time.Date(2026, 6, 15, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		crop string
+		days int
+		want string
+	}{
+		{"cotton", -1, ""},
+		{"cotton", 0, advisory_models.StageGermination},
+		{"cotton", 15, advisory_models.StageVegetative},
+		{"Cotton ", 60, advisory_models.StageFlowering},
+		{"cotton", 120, advisory_models.StageMaturity},
+		{"cotton", 180, ""},
+		{"wheat", 94, advisory_models.StageFlowering},
+		{"wheat", 95, advisory_models.StageMaturity},
+		{"millet", 11, advisory_models.StageGermination}, // Unknown crops use defaultStages
+		{"millet", 50, advisory_models.StageFlowering},
+		{"millet", 120, ""},
+	}
+	for _, tt := range tests {
+		if got := cropStage(tt.crop, sowing, sowing.AddDate(0, 0, tt.days)); got != tt.want {
+			t.Errorf("cropStage(%q, day %d) = %q, want %q", tt.crop, tt.days, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package advisory
+
+import (
+	"strings"
+	"time"
+
+	advisory_models "Agromi/routes/advisory/models"
+)
+
+// stageDays are the days after sowing at which flowering and maturity begin
+type stageDays struct {
+	Vegetative, Flowering, Maturity, Harvest int
+}
+
+// Approximate durations for common Indian crops; others use defaultStages
+var cropStages = map[string]stageDays{
+	"cotton":    {15, 60, 120, 180},
+	"wheat":     {12, 60, 95, 130},
+	"rice":      {15, 65, 95, 125},
+	"paddy":     {15, 65, 95, 125},
+	"maize":     {10, 50, 80, 110},
+	"soybean":   {10, 40, 75, 105},
+	"groundnut": {12, 35, 80, 115},
+	"chickpea":  {12, 45, 80, 110},
+	"gram":      {12, 45, 80, 110},
+	"mustard":   {10, 40, 80, 120},
+	"sugarcane": {30, 120, 270, 360},
+	"tomato":    {10, 40, 70, 120},
+	"onion":     {15, 60, 100, 130},
+	"potato":    {15, 40, 75, 100},
+}
+
+var defaultStages = stageDays{12, 50, 85, 120}
+
+// cropStage returns the growth stage of a crop sown on sowing, or "" once it is past harvest
+func cropStage(crop string, sowing time.Time, now time.Time) string {
+	s, ok := cropStages[strings.ToLower(strings.TrimSpace(crop))]
+	if !ok {
+		s = defaultStages
+	}
+	days := int(now.Sub(sowing).Hours() / 24)
+	switch {
+	case days < 0:
+		return ""
+	case days < s.Vegetative:
+		return advisory_models.StageGermination
+	case days < s.Flowering:
+		return advisory_models.StageVegetative
+	case days < s.Maturity:
+		return advisory_models.StageFlowering
+	case days < s.Harvest:
+		return advisory_models.StageMaturity
+	}
+	return ""
+}
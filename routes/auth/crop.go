@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Area units and their size in acres
+const (
+	AreaUnitAcre    = "acre"
+	AreaUnitHectare = "hectare"
+	AreaUnitGuntha  = "guntha"
+	AreaUnitSqMeter = "sqm"
+)
+
+// AreaUnits maps each supported unit to acres
+var AreaUnits = map[string]float64{
+	AreaUnitAcre:    1,
+	AreaUnitHectare: 2.47105,
+	AreaUnitGuntha:  0.025,
+	AreaUnitSqMeter: 0.000247105,
+}
+
+// Acres returns the crop's structured area in acres; 0 when unknown
+func (c Crop) Acres() float64 {
+	if f, ok := AreaUnits[c.AreaUnit]; ok {
+		return c.AreaValue * f
+	}
+	return 0
+}
+
+// Normalize validates the structured fields, gives the crop an ID and fills the legacy
+// Area/Age strings from them so older clients keep showing something sensible.
+func (c *Crop) Normalize(now time.Time) error {
+	c.Name = strings.TrimSpace(c.Name)
+	c.Variety = strings.TrimSpace(c.Variety)
+	c.AreaUnit = strings.ToLower(strings.TrimSpace(c.AreaUnit))
+	if c.Name == "" {
+		return errors.New("crop name required")
+	}
+	if c.AreaValue < 0 {
+		return errors.New("area_value cannot be negative")
+	}
+	if c.AreaValue > 0 {
+		if c.AreaUnit == "" {
+			c.AreaUnit = AreaUnitAcre
+		}
+		if _, ok := AreaUnits[c.AreaUnit]; !ok {
+			return fmt.Errorf("area_unit must be one of acre, hectare, guntha, sqm")
+		}
+		c.Area = strconv.FormatFloat(c.AreaValue, 'f', -1, 64) + " " + c.AreaUnit
+	}
+	if c.SowingDate != nil {
+		if c.SowingDate.After(now.AddDate(1, 0, 0)) {
+			return errors.New("sowing_date too far in the future")
+		}
+		if days := int(now.Sub(*c.SowingDate).Hours() / 24); days >= 0 {
+			c.Age = strconv.Itoa(days) + " days"
+		}
+	}
+	if c.ID.IsZero() {
+		c.ID = primitive.NewObjectID()
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"Agromi/database"
+	"Agromi/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Roles a Firebase identity can sign in as
+const (
+	RoleUser       = "user"       // A users document: farmer, consumer or admin
+	RoleConsultant = "consultant" // A consultants document
+	RoleAdmin      = "admin"      // A users document with user_type admin; only for RequireRole
+)
+
+// Identity links one Firebase account to the profiles it may sign in as (collection: identities, _id = Firebase UID).
+// A person who farms and consults has both links and picks a role at login or switches later.
+type Identity struct {
+	UID          string              `bson:"_id" json:"uid"`
+	UserID       *primitive.ObjectID `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	ConsultantID *primitive.ObjectID `bson:"consultant_id,omitempty" json:"consultant_id,omitempty"`
+	Phone        string              `bson:"phone,omitempty" json:"phone,omitempty"`
+	Email        string              `bson:"email,omitempty" json:"email,omitempty"`
+	CreatedAt    time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time           `bson:"updated_at" json:"updated_at"`
+}
+
+// Roles lists the roles the identity can sign in as
+func (i Identity) Roles() []string {
+	roles := []string{}
+	if i.UserID != nil {
+		roles = append(roles, RoleUser)
+	}
+	if i.ConsultantID != nil {
+		roles = append(roles, RoleConsultant)
+	}
+	return roles
+}
+
+// ProfileID returns the profile for a role, or false when the identity has no such profile
+func (i Identity) ProfileID(role string) (primitive.ObjectID, bool) {
+	switch {
+	case role == RoleUser && i.UserID != nil:
+		return *i.UserID, true
+	case role == RoleConsultant && i.ConsultantID != nil:
+		return *i.ConsultantID, true
+	}
+	return primitive.NilObjectID, false
+}
+
+// FirebaseAccount is the verified content of a Firebase ID token
+type FirebaseAccount struct {
+	UID   string
+	Phone string
+	Email string
+}
+
+var ErrProfileLinked = errors.New("this account already has a profile for that role")
+
+// VerifyFirebaseToken checks a Firebase ID token from the client
+func VerifyFirebaseToken(ctx context.Context, idToken string) (FirebaseAccount, error) {
+	token, err := utils.AuthClient.VerifyIDToken(ctx, idToken)
+	if err != nil {
+		return FirebaseAccount{}, err
+	}
+	phone, _ := token.Claims["phone_number"].(string)
+	email, _ := token.Claims["email"].(string)
+	return FirebaseAccount{UID: token.UID, Phone: phone, Email: email}, nil
+}
+
+// LinkIdentity attaches a newly created profile to the Firebase account.
+// Fails with ErrProfileLinked if the account already has a profile for the role.
+func LinkIdentity(ctx context.Context, account FirebaseAccount, role string, profileID primitive.ObjectID) error {
+	field := "user_id"
+	if role == RoleConsultant {
+		field = "consultant_id"
+	}
+	now := time.Now()
+	set := bson.M{field: profileID, "updated_at": now}
+	if account.Phone != "" {
+		set["phone"] = account.Phone
+	}
+	if account.Email != "" {
+		set["email"] = account.Email
+	}
+	_, err := database.GetCollection("identities").UpdateOne(ctx,
+		bson.M{"_id": account.UID, field: bson.M{"$exists": false}},
+		bson.M{"$set": set, "$setOnInsert": bson.M{"created_at": now}},
+		options.Update().SetUpsert(true),
+	)
+	// The filter misses an existing link, so the upsert collides on _id
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrProfileLinked
+	}
+	return err
+}
+
+// UnlinkIdentity detaches a deleted profile so the Firebase account can create a new one
+func UnlinkIdentity(ctx context.Context, role string, profileID primitive.ObjectID) error {
+	field := "user_id"
+	if role == RoleConsultant {
+		field = "consultant_id"
+	}
+	_, err := database.GetCollection("identities").UpdateOne(ctx,
+		bson.M{field: profileID},
+		bson.M{"$unset": bson.M{field: ""}, "$set": bson.M{"updated_at": time.Now()}},
+	)
+	return err
+}
+
+var errNoProfile = errors.New("no profile for role")
+
+// signInProfile is what login needs from a user or consultant document
+type signInProfile struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	Name      string             `bson:"name"`
+	UserType  string             `bson:"user_type"`
+	IsBlocked bool               `bson:"is_blocked"`
+}
+
+// loadProfile fetches the profile the identity signs in as for role.
+// Consultants get user type "consultant" so sessions know which collection they belong to.
+func loadProfile(ctx context.Context, identity Identity, role string) (signInProfile, error) {
+	var profile signInProfile
+	id, ok := identity.ProfileID(role)
+	if !ok {
+		return profile, errNoProfile
+	}
+	collection := "users"
+	if role == RoleConsultant {
+		collection = "consultants"
+	}
+	opts := options.FindOne().SetProjection(bson.M{"name": 1, "user_type": 1, "is_blocked": 1})
+	err := database.GetCollection(collection).FindOne(ctx, bson.M{"_id": id}, opts).Decode(&profile)
+	if err == mongo.ErrNoDocuments {
+		return profile, errNoProfile
+	}
+	if role == RoleConsultant {
+		profile.UserType = "consultant"
+	}
+	return profile, err
+}
+
+// resolveIdentity loads the identity for a Firebase account, first linking profiles created
+// before identities existed: users carry the UID in auth_token_num, consultants are matched
+// on the verified phone number because they were given random tokens.
+func resolveIdentity(ctx context.Context, account FirebaseAccount) (Identity, error) {
+	coll := database.GetCollection("identities")
+	identity := Identity{UID: account.UID}
+	err := coll.FindOne(ctx, bson.M{"_id": account.UID}).Decode(&identity)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return identity, err
+	}
+
+	set := bson.M{}
+	if identity.UserID == nil {
+		var user struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if database.GetCollection("users").FindOne(ctx, bson.M{"auth_token_num": account.UID}).Decode(&user) == nil {
+			identity.UserID = &user.ID
+			set["user_id"] = user.ID
+		}
+	}
+	if identity.ConsultantID == nil {
+		if id, ok := legacyConsultant(ctx, account); ok {
+			identity.ConsultantID = &id
+			set["consultant_id"] = id
+		}
+	}
+	if len(set) == 0 {
+		return identity, nil
+	}
+
+	now := time.Now()
+	set["updated_at"] = now
+	if account.Phone != "" {
+		set["phone"] = account.Phone
+	}
+	if account.Email != "" {
+		set["email"] = account.Email
+	}
+	_, err = coll.UpdateOne(ctx, bson.M{"_id": account.UID},
+		bson.M{"$set": set, "$setOnInsert": bson.M{"created_at": now}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		// Someone else claimed the profile meanwhile; use whatever is stored now
+		identity = Identity{UID: account.UID}
+		if err := coll.FindOne(ctx, bson.M{"_id": account.UID}).Decode(&identity); err != nil && err != mongo.ErrNoDocuments {
+			return identity, err
+		}
+		return identity, nil
+	}
+	if identity.ConsultantID != nil {
+		database.GetCollection("consultants").UpdateOne(ctx, bson.M{"_id": *identity.ConsultantID}, bson.M{"$set": bson.M{"auth_token_num": account.UID}})
+	}
+	return identity, nil
+}
+
+// legacyConsultant finds an unlinked consultant profile for the account
+func legacyConsultant(ctx context.Context, account FirebaseAccount) (primitive.ObjectID, bool) {
+	filter := bson.M{"auth_token_num": account.UID}
+	if account.Phone != "" {
+		filter = bson.M{"$or": []bson.M{{"auth_token_num": account.UID}, {"phone": account.Phone}}}
+	}
+	var cons struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if database.GetCollection("consultants").FindOne(ctx, filter).Decode(&cons) != nil {
+		return primitive.NilObjectID, false
+	}
+	taken, err := database.GetCollection("identities").CountDocuments(ctx, bson.M{"consultant_id": cons.ID})
+	if err != nil || taken > 0 {
+		return primitive.NilObjectID, false
+	}
+	return cons.ID, true
+}
+
+// createIdentityIndexes keeps each profile linked to at most one Firebase account
+func createIdentityIndexes() {
+	if !database.WaitForClient(30 * time.Second) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, _ = database.GetCollection("identities").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"user_id": bson.M{"$exists": true}}),
+		},
+		{
+			Keys:    bson.D{{Key: "consultant_id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"consultant_id": bson.M{"$exists": true}}),
+		},
+	})
+}
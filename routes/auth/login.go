@@ -1,19 +1,18 @@
 package auth
 
 import (
-	"Agromi/utils"
 	"context"
+	"log"
 	"net/http"
+	"os"
 	"time"
 
 	"Agromi/core/router"
 	"Agromi/database"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // User & Session Models
@@ -22,15 +21,22 @@ type User struct {
 	Phone            string             `bson:"phone" json:"phone"` // Not required binding if Email provided
 	Email            string             `bson:"email" json:"email"`
 	Name             string             `bson:"name" json:"name" binding:"required"`
+	NameSearch       string             `bson:"name_search,omitempty" json:"-"` // SearchName(Name), backs prefix autocomplete
 	IsBlocked        bool               `bson:"is_blocked" json:"is_blocked"`
 	UserType         string             `bson:"user_type" json:"user_type" binding:"required,oneof=farmer consumer admin"`
 	ProfilePhotoURL  string             `bson:"profile_photo_url,omitempty" json:"profile_photo_url"`
-	AuthTokenNum     string             `bson:"auth_token_num,omitempty" json:"auth_token_num"`
+	AuthTokenNum     string             `bson:"auth_token_num,omitempty" json:"-"` // Firebase UID, never sent to clients
 	RegionalLanguage string             `bson:"regional_language,omitempty" json:"regional_language"`
 	Crops            []Crop             `bson:"crops,omitempty" json:"crops"`
 	Location         Location           `bson:"location,omitempty" json:"location"`
+	State            string             `bson:"state,omitempty" json:"state,omitempty"`
+	District         string             `bson:"district,omitempty" json:"district,omitempty"`
 	CreatedAt        time.Time          `bson:"created_at" json:"created_at"`
 	LastActiveAt     time.Time          `bson:"last_active_at,omitempty" json:"last_active_at"`
+	IsVerified       bool               `bson:"is_verified,omitempty" json:"is_verified"` // Set by admins
+	Privacy          PrivacySettings    `bson:"privacy" json:"privacy"`
+	// Set while a deletion request is in its grace period
+	DeletionScheduledAt *time.Time `bson:"deletion_scheduled_at,omitempty" json:"deletion_scheduled_at,omitempty"`
 	// GeoLocation for MongoDB 2dsphere index
 	GeoLocation *GeoJSON `bson:"geo_location,omitempty" json:"geo_location,omitempty"`
 }
@@ -41,7 +47,13 @@ type GeoJSON struct {
 }
 
 type Crop struct {
-	Name string `bson:"name" json:"name"`
+	ID         primitive.ObjectID `bson:"id,omitempty" json:"id,omitempty"`
+	Name       string             `bson:"name" json:"name"`
+	Variety    string             `bson:"variety,omitempty" json:"variety,omitempty"`
+	AreaValue  float64            `bson:"area_value,omitempty" json:"area_value,omitempty"`
+	AreaUnit   string             `bson:"area_unit,omitempty" json:"area_unit,omitempty"` // One of AreaUnits
+	SowingDate *time.Time         `bson:"sowing_date,omitempty" json:"sowing_date,omitempty"`
+	// Legacy free-text fields, still filled for older clients
 	Area string `bson:"area" json:"area"`
 	Age  string `bson:"age" json:"age"`
 }
@@ -51,25 +63,47 @@ type Location struct {
 	Longitude float64 `bson:"longitude" json:"longitude"`
 }
 
-type Session struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
-	Token     string             `bson:"token" json:"token"`
-	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
-}
+// jwtSecret signs access tokens; set by InitJWT from JWT_SECRET
+var jwtSecret []byte
+
+const minJWTSecretLen = 32
 
-var jwtSecret = []byte("YOUR_SUPER_SECRET_KEY") // In prod, use Env Var
+// InitJWT loads the token signing key. The server must not start without one:
+// anyone who knows the key can sign in as any user.
+func InitJWT() {
+	secret := os.Getenv("JWT_SECRET")
+	if len(secret) < minJWTSecretLen {
+		log.Fatalf("JWT_SECRET must be set to at least %d random characters", minJWTSecretLen)
+	}
+	jwtSecret = []byte(secret)
+}
 
 func init() {
 	router.Register(func(r *gin.Engine) {
 		r.POST("/api/auth/login", handleLogin)
+		r.POST("/api/auth/refresh", handleRefresh)
 		r.POST("/api/auth/logout", handleLogout)
+		r.POST("/api/auth/switch-role", RequireAuth(), switchRole)
+
+		sessions := r.Group("/api/auth/sessions", RequireAuth())
+		{
+			sessions.GET("", listSessions)
+			sessions.DELETE("", revokeAllSessions)
+			sessions.DELETE("/:id", revokeSession)
+		}
 	})
+
+	go createSessionIndexes()
+	go createIdentityIndexes()
 }
 
+// handleLogin signs a Firebase account in as one of its profiles.
+// role picks user or consultant for people who have both; by default the user profile is used.
 func handleLogin(c *gin.Context) {
 	var input struct {
 		AuthToken string `json:"auth_token" binding:"required"`
+		Role      string `json:"role" binding:"omitempty,oneof=user consultant"`
+		DeviceInfo
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -81,70 +115,53 @@ func handleLogin(c *gin.Context) {
 	defer cancel()
 
 	// 1. Verify Firebase Token
-	token, err := utils.AuthClient.VerifyIDToken(ctx, input.AuthToken)
+	account, err := VerifyFirebaseToken(ctx, input.AuthToken)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid Auth Token"})
 		return
 	}
 
-	uid := token.UID
-	// Optional: Get phone/email from token if needed for cross-check
-	// email := token.Claims["email"]
-	// phone := token.Claims["phone_number"]
-
-	var userID primitive.ObjectID
-	var userType string
-	var userName string
-	var isBlocked bool
-
-	// 2. Search in Users (Farmers/Consumers) by AuthTokenNum (UID) or Phone/Email
-	// Ideally, we store UID in AuthTokenNum or a separate field "firebase_uid"
-	// For now, let's assume we match by Phone OR Email if provided, OR we rely on UID in AuthTokenNum
-	// Refactor: We should prioritize UID lookup.
-
-	usersColl := database.GetCollection("users")
-	var user User
-
-	// Try finding by AuthTokenNum (UID) first
-	errFind := usersColl.FindOne(ctx, bson.M{"auth_token_num": uid}).Decode(&user)
-
-	if errFind == nil {
-		userID = user.ID
-		userType = user.UserType
-		userName = user.Name
-		isBlocked = user.IsBlocked
-	} else if errFind == mongo.ErrNoDocuments {
-		// Not found in Users... Check Consultants?
-		// NOTE: Consultants might not have Firebase UID attached yet if they migrated?
-		// For strict new flow, we expect UID to be present.
-
+	// 2. Find the profiles linked to this Firebase account
+	identity, err := resolveIdentity(ctx, account)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	roles := identity.Roles()
+	if len(roles) == 0 {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": "User not found",
-			"uid":   uid, // Send back UID so frontend can use it for registration
+			"uid":   account.UID, // Send back UID so frontend can use it for registration
 		})
 		return
-	} else {
+	}
+	role := input.Role
+	if role == "" {
+		role = roles[0]
+	}
+
+	// 3. Load the profile and check Blocked Status
+	profile, err := loadProfile(ctx, identity, role)
+	if err == errNoProfile {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No " + role + " profile for this account", "roles": roles})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 		return
 	}
-
-	// 3. Check Blocked Status
-	if isBlocked {
+	if profile.IsBlocked {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Your account has been blocked by Admin"})
 		return
 	}
+	userID, userType, userName := profile.ID, profile.UserType, profile.Name
 
-	// 4. Generate JWT Token
-	tokenString, _ := generateJWT(userID.Hex())
-
-	// 5. Create Session
-	session := Session{
-		ID:        primitive.NewObjectID(),
-		UserID:    userID,
-		Token:     tokenString,
-		CreatedAt: time.Now(),
+	// 4. Start a device session: short-lived access token plus rotating refresh token
+	tokens, err := startSession(ctx, c, userID, userType, identity.UID, input.DeviceInfo)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start session"})
+		return
 	}
-	_, _ = database.GetCollection("sessions").InsertOne(ctx, session)
 
 	// Update LastActiveAt (User or Consultant)
 	if userType == "consultant" {
@@ -153,39 +170,39 @@ func handleLogin(c *gin.Context) {
 		database.GetCollection("users").UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$set": bson.M{"last_active_at": time.Now()}})
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"token":     tokenString,
-		"user_id":   userID,
-		"user_type": userType,
-		"name":      userName,
-	})
+	tokens["user_id"] = userID
+	tokens["user_type"] = userType
+	tokens["name"] = userName
+	tokens["role"] = role
+	tokens["roles"] = roles
+	c.JSON(http.StatusOK, tokens)
 }
 
+// handleLogout ends the current session. The access token identifies it; when that has
+// already expired the client may send its refresh token instead.
 func handleLogout(c *gin.Context) {
-	token := c.GetHeader("Authorization")
-	if token == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Token required"})
+	var input struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	_ = c.ShouldBindJSON(&input)
+
+	filter := bson.M{"revoked_at": nil}
+	if tokenString := bearerToken(c); tokenString != "" && authenticate(c, tokenString) == nil {
+		filter["_id"] = CurrentSessionID(c)
+	} else if input.RefreshToken != "" {
+		filter["refresh_hash"] = hashToken(input.RefreshToken)
+	} else {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Valid access token or refresh_token required"})
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// Auto-delete session
-	_, err := database.GetCollection("sessions").DeleteOne(ctx, bson.M{"token": token})
+	_, err := database.GetCollection("sessions").UpdateOne(ctx, filter, bson.M{"$set": bson.M{"revoked_at": time.Now(), "revoke_reason": RevokeLogout}})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Logout failed"})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
 }
-
-// Helper: Generate JWT
-func generateJWT(userID string) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": userID,
-		"exp":     time.Now().Add(time.Hour * 72).Unix(),
-	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
-}
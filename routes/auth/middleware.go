@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Context keys set by RequireAuth
+const (
+	ContextUserID    = "auth_user_id"
+	ContextToken     = "auth_token"
+	ContextSessionID = "auth_session_id"
+	ContextUserType  = "auth_user_type"
+)
+
+// RequireAuth rejects requests without a valid access token from /api/auth/login or /api/auth/refresh.
+// The token is read from "Authorization: Bearer <token>" (a bare token is accepted too).
+// Its session must still be active, so revoked sessions lose access immediately.
+// The user id is stored under ContextUserID and the session under ContextSessionID.
+func RequireAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString := bearerToken(c)
+		if tokenString == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization token required"})
+			return
+		}
+
+		if err := authenticate(c, tokenString); err != nil {
+			if err == errInvalidToken || err == errSessionRevoked {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			} else {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Session check failed"})
+			}
+			return
+		}
+		c.Next()
+	}
+}
+
+// OptionalAuth identifies the viewer when a valid token is sent but lets anonymous
+// requests through, for public endpoints whose response depends on who is asking.
+// An invalid token is treated as anonymous rather than rejected.
+func OptionalAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if tokenString := bearerToken(c); tokenString != "" {
+			_ = authenticate(c, tokenString)
+		}
+		c.Next()
+	}
+}
+
+// RequireRole lets the request through only when the session was signed in as one of roles.
+// RoleUser matches any users document (farmer, consumer or admin), RoleConsultant a consultant
+// and RoleAdmin only admins. It must come after RequireAuth.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if CurrentUserID(c).IsZero() {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization token required"})
+			return
+		}
+		userType := CurrentUserType(c)
+		for _, role := range roles {
+			if hasRole(userType, role) {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Not allowed for this account type"})
+	}
+}
+
+func hasRole(userType, role string) bool {
+	switch role {
+	case RoleUser:
+		return userType != "" && userType != RoleConsultant
+	default:
+		return userType == role
+	}
+}
+
+// CurrentUserType returns the session's user_type: farmer, consumer, admin or consultant
+func CurrentUserType(c *gin.Context) string {
+	return c.GetString(ContextUserType)
+}
+
+// CurrentUserID returns the authenticated user's id; zero outside RequireAuth and for anonymous OptionalAuth requests
+func CurrentUserID(c *gin.Context) primitive.ObjectID {
+	id, _ := c.Get(ContextUserID)
+	userID, _ := id.(primitive.ObjectID)
+	return userID
+}
+
+func bearerToken(c *gin.Context) string {
+	return strings.TrimSpace(strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "))
+}
+
+// authenticate verifies the token and its session and fills the context keys
+func authenticate(c *gin.Context, tokenString string) error {
+	userID, sessionID, nonce, err := parseJWT(tokenString)
+	if err != nil {
+		return err
+	}
+	session, err := activeSession(userID, sessionID, nonce, tokenString, c.ClientIP())
+	if err != nil {
+		return err
+	}
+
+	c.Set(ContextUserID, userID)
+	c.Set(ContextToken, tokenString)
+	c.Set(ContextSessionID, session.ID)
+	c.Set(ContextUserType, session.UserType)
+	return nil
+}
+
+// errInvalidToken covers every failure to parse or verify a JWT
+var errInvalidToken = errors.New("invalid token")
+
+// parseJWT verifies the signature and expiry and returns the user_id, sid and sn (session nonce) claims.
+// sid and sn are empty for tokens issued before sessions had refresh tokens.
+func parseJWT(tokenString string) (userID, sessionID primitive.ObjectID, nonce string, err error) {
+	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
+		if len(jwtSecret) == 0 {
+			return nil, errNoSigningKey
+		}
+		return jwtSecret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return userID, sessionID, "", errInvalidToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return userID, sessionID, "", errInvalidToken
+	}
+	idStr, _ := claims["user_id"].(string)
+	if userID, err = primitive.ObjectIDFromHex(idStr); err != nil {
+		return userID, sessionID, "", errInvalidToken
+	}
+	if sid, ok := claims["sid"].(string); ok {
+		if sessionID, err = primitive.ObjectIDFromHex(sid); err != nil {
+			return userID, sessionID, "", errInvalidToken
+		}
+	}
+	nonce, _ = claims["sn"].(string)
+	return userID, sessionID, nonce, nil
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ProfileUpdate is the whitelist of fields a farmer may change on their own profile.
+// Nil fields are left untouched.
+type ProfileUpdate struct {
+	Name             *string          `json:"name"`
+	Email            *string          `json:"email"`
+	ProfilePhotoURL  *string          `json:"profile_photo_url"`
+	RegionalLanguage *string          `json:"regional_language"`
+	Location         *Location        `json:"location"`
+	State            *string          `json:"state"`
+	District         *string          `json:"district"`
+	Privacy          *PrivacySettings `json:"privacy"`
+}
+
+// SearchName is the normalized form of a name stored in name_search.
+// Lowercase so an anchored, case-sensitive prefix regex can use the index.
+func SearchName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// DecodeStrict decodes a JSON body into v, rejecting fields v does not declare
+// so callers learn when they try to change something they are not allowed to.
+func DecodeStrict(r io.Reader, v interface{}) error {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// Apply validates the update and returns the $set and $unset documents.
+// Changing Location always rewrites GeoLocation so geo queries stay in sync.
+func (u ProfileUpdate) Apply() (set, unset bson.M, err error) {
+	set, unset = bson.M{}, bson.M{}
+
+	if u.Name != nil {
+		name := strings.TrimSpace(*u.Name)
+		if name == "" {
+			return nil, nil, errors.New("name cannot be empty")
+		}
+		set["name"] = name
+		set["name_search"] = SearchName(name)
+	}
+	if u.Email != nil {
+		set["email"] = strings.TrimSpace(*u.Email)
+	}
+	if u.ProfilePhotoURL != nil {
+		set["profile_photo_url"] = strings.TrimSpace(*u.ProfilePhotoURL)
+	}
+	if u.RegionalLanguage != nil {
+		set["regional_language"] = strings.TrimSpace(*u.RegionalLanguage)
+	}
+	if u.State != nil {
+		set["state"] = strings.TrimSpace(*u.State)
+	}
+	if u.District != nil {
+		set["district"] = strings.TrimSpace(*u.District)
+	}
+
+	if u.Privacy != nil {
+		set["privacy"] = *u.Privacy
+	}
+
+	if loc := u.Location; loc != nil {
+		if loc.Latitude < -90 || loc.Latitude > 90 || loc.Longitude < -180 || loc.Longitude > 180 {
+			return nil, nil, errors.New("location out of range")
+		}
+		set["location"] = *loc
+		if loc.Latitude == 0 && loc.Longitude == 0 {
+			unset["geo_location"] = ""
+		} else {
+			set["geo_location"] = GeoJSON{Type: "Point", Coordinates: []float64{loc.Longitude, loc.Latitude}}
+		}
+	}
+	return set, unset, nil
+}
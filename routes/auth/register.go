@@ -7,7 +7,6 @@ import (
 
 	"Agromi/core/router"
 	"Agromi/database"
-	"Agromi/utils"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
@@ -34,6 +33,8 @@ func handleRegister(c *gin.Context) {
 		RegionalLanguage string   `json:"regional_language"`
 		Crops            []Crop   `json:"crops"`
 		Location         Location `json:"location"`
+		State            string   `json:"state"`
+		District         string   `json:"district"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -41,29 +42,33 @@ func handleRegister(c *gin.Context) {
 		return
 	}
 
+	for i := range input.Crops {
+		if err := input.Crops[i].Normalize(time.Now()); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	// 1. Verify Firebase Token
-	token, err := utils.AuthClient.VerifyIDToken(ctx, input.AuthToken)
+	account, err := VerifyFirebaseToken(ctx, input.AuthToken)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid Auth Token"})
 		return
 	}
-	uid := token.UID
-	// Extract phone/email from token if available
-	phone, _ := token.Claims["phone_number"].(string)
-	email, _ := token.Claims["email"].(string)
+	uid, phone, email := account.UID, account.Phone, account.Email
 
 	usersColl := database.GetCollection("users")
 
-	// 2. Check existence
-	count, err := usersColl.CountDocuments(ctx, bson.M{"auth_token_num": uid})
+	// 2. Check existence. The same Firebase account may already be a consultant; that is fine.
+	identity, err := resolveIdentity(ctx, account)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 		return
 	}
-	if count > 0 {
+	if _, ok := identity.ProfileID(RoleUser); ok {
 		c.JSON(http.StatusConflict, gin.H{"error": "User already exists"})
 		return
 	}
@@ -75,12 +80,15 @@ func handleRegister(c *gin.Context) {
 		Email:            email,
 		AuthTokenNum:     uid, // Store UID
 		Name:             input.Name,
+		NameSearch:       SearchName(input.Name),
 		UserType:         input.UserType,
 		IsBlocked:        false,
 		ProfilePhotoURL:  input.ProfilePhotoURL,
 		RegionalLanguage: input.RegionalLanguage,
 		Crops:            input.Crops,
 		Location:         input.Location,
+		State:            input.State,
+		District:         input.District,
 		CreatedAt:        time.Now(),
 	}
 
@@ -97,6 +105,16 @@ func handleRegister(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
 	}
+	if err := LinkIdentity(ctx, account, RoleUser, newUser.ID); err != nil {
+		usersColl.DeleteOne(ctx, bson.M{"_id": newUser.ID})
+		if err == ErrProfileLinked {
+			// A concurrent registration won
+			c.JSON(http.StatusConflict, gin.H{"error": "User already exists"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		}
+		return
+	}
 
 	c.JSON(http.StatusCreated, gin.H{"message": "User registered successfully", "id": newUser.ID})
 }
@@ -0,0 +1,483 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"Agromi/database"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 30 * 24 * time.Hour // Sliding: every refresh extends it
+
+	legacyTokenTTL    = 72 * time.Hour // Lifetime of JWTs issued before sessions had refresh tokens
+	maxRotatedHashes  = 20             // Old refresh hashes kept per session to detect reuse
+	lastSeenEvery     = time.Minute    // last_seen_at is written at most this often
+	maxDeviceFieldLen = 100
+)
+
+// Revoke reasons
+const (
+	RevokeLogout  = "logout"
+	RevokeUser    = "revoked_by_user"
+	RevokeAdmin   = "revoked_by_admin"
+	RevokeReuse   = "refresh_token_reuse"
+	RevokeBlocked = "account_blocked"
+	RevokeSwitch  = "role_switched"
+)
+
+var (
+	errSessionRevoked = errors.New("session revoked")
+	errTokenReused    = errors.New("refresh token reused")
+)
+
+// Session is one signed-in device (collection: sessions).
+// Access tokens carry the session ID, so revoking the session invalidates them immediately.
+type Session struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID        primitive.ObjectID `bson:"user_id" json:"user_id"`
+	UserType      string             `bson:"user_type,omitempty" json:"user_type,omitempty"`
+	IdentityUID   string             `bson:"identity_uid,omitempty" json:"-"` // Firebase account, used to switch roles
+	DeviceName    string             `bson:"device_name,omitempty" json:"device_name,omitempty"`
+	Platform      string             `bson:"platform,omitempty" json:"platform,omitempty"`
+	IP            string             `bson:"ip,omitempty" json:"ip,omitempty"`
+	UserAgent     string             `bson:"user_agent,omitempty" json:"user_agent,omitempty"`
+	RefreshHash   string             `bson:"refresh_hash,omitempty" json:"-"`    // SHA-256 of the current refresh token
+	NonceHash     string             `bson:"nonce_hash,omitempty" json:"-"`      // SHA-256 of the nonce in current access tokens
+	PrevNonceHash string             `bson:"prev_nonce_hash,omitempty" json:"-"` // Nonce hash before the last refresh, until those tokens expire
+	RotatedFrom   []string           `bson:"rotated_hashes,omitempty" json:"-"`  // Hashes of refresh tokens already exchanged
+	Token         string             `bson:"token,omitempty" json:"-"`           // Legacy: 72h JWT issued before refresh tokens
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	LastSeenAt    time.Time          `bson:"last_seen_at,omitempty" json:"last_seen_at"`
+	ExpiresAt     time.Time          `bson:"expires_at,omitempty" json:"expires_at"`
+	RevokedAt     *time.Time         `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+	RevokeReason  string             `bson:"revoke_reason,omitempty" json:"revoke_reason,omitempty"`
+	Current       bool               `bson:"-" json:"current,omitempty"` // Set when listing: the session making the request
+}
+
+// DeviceInfo is what the client tells us about itself at login
+type DeviceInfo struct {
+	DeviceName string `json:"device_name"`
+	Platform   string `json:"platform"` // android, ios, web
+}
+
+func clip(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) > maxDeviceFieldLen {
+		s = s[:maxDeviceFieldLen]
+	}
+	return s
+}
+
+// newRefreshToken returns an opaque random token and the hash stored for it
+func newRefreshToken() (string, string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// errNoSigningKey means InitJWT was not called
+var errNoSigningKey = errors.New("JWT signing key not configured")
+
+// generateAccessToken signs a short-lived JWT bound to a session.
+// The session ID is not secret, so the token also carries the session's random nonce,
+// of which only a hash is stored.
+func generateAccessToken(userID, sessionID primitive.ObjectID, nonce string) (string, error) {
+	if len(jwtSecret) == 0 {
+		return "", errNoSigningKey
+	}
+	claims := jwt.MapClaims{
+		"user_id": userID.Hex(),
+		"sid":     sessionID.Hex(),
+		"sn":      nonce,
+		"exp":     time.Now().Add(AccessTokenTTL).Unix(),
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(jwtSecret)
+}
+
+// tokenPair is the login/refresh response body
+func tokenPair(userID, sessionID primitive.ObjectID, nonce, refresh string) (gin.H, error) {
+	access, err := generateAccessToken(userID, sessionID, nonce)
+	if err != nil {
+		return nil, err
+	}
+	return gin.H{
+		"token":         access,
+		"refresh_token": refresh,
+		"expires_in":    int(AccessTokenTTL.Seconds()),
+		"session_id":    sessionID,
+	}, nil
+}
+
+// startSession records a new device session and issues its first token pair
+func startSession(ctx context.Context, c *gin.Context, userID primitive.ObjectID, userType, identityUID string, device DeviceInfo) (gin.H, error) {
+	refresh, hash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	nonce, nonceHash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	session := Session{
+		ID:          primitive.NewObjectID(),
+		UserID:      userID,
+		UserType:    userType,
+		IdentityUID: identityUID,
+		DeviceName:  clip(device.DeviceName),
+		Platform:    strings.ToLower(clip(device.Platform)),
+		IP:          c.ClientIP(),
+		UserAgent:   clip(c.Request.UserAgent()),
+		RefreshHash: hash,
+		NonceHash:   nonceHash,
+		CreatedAt:   now,
+		LastSeenAt:  now,
+		ExpiresAt:   now.Add(RefreshTokenTTL),
+	}
+	if _, err := database.GetCollection("sessions").InsertOne(ctx, session); err != nil {
+		return nil, err
+	}
+	return tokenPair(userID, session.ID, nonce, refresh)
+}
+
+// activeSession checks the session behind an access token and notes that it was used.
+// Tokens from before refresh tokens have no session ID and are matched by their raw value;
+// others must carry the session's current or previous nonce.
+func activeSession(userID, sessionID primitive.ObjectID, nonce, tokenString, ip string) (Session, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"user_id": userID, "revoked_at": nil}
+	if sessionID.IsZero() {
+		filter["token"] = tokenString
+	} else {
+		if nonce == "" {
+			return Session{}, errSessionRevoked
+		}
+		h := hashToken(nonce)
+		filter["_id"] = sessionID
+		filter["$or"] = []bson.M{{"nonce_hash": h}, {"prev_nonce_hash": h}}
+	}
+
+	coll := database.GetCollection("sessions")
+	var session Session
+	err := coll.FindOne(ctx, filter, options.FindOne().SetProjection(bson.M{"last_seen_at": 1, "user_type": 1})).Decode(&session)
+	if err == mongo.ErrNoDocuments {
+		return Session{}, errSessionRevoked
+	}
+	if err != nil {
+		return Session{}, err
+	}
+
+	// Legacy sessions did not record the account type and were only issued to users
+	if session.UserType == "" {
+		var user struct {
+			UserType string `bson:"user_type"`
+		}
+		if err := database.GetCollection("users").FindOne(ctx, bson.M{"_id": userID}, options.FindOne().SetProjection(bson.M{"user_type": 1})).Decode(&user); err != nil {
+			return Session{}, errSessionRevoked
+		}
+		session.UserType = user.UserType
+	}
+
+	if now := time.Now(); now.Sub(session.LastSeenAt) > lastSeenEvery {
+		coll.UpdateOne(ctx, bson.M{"_id": session.ID}, bson.M{"$set": bson.M{"last_seen_at": now, "ip": ip}})
+	}
+	return session, nil
+}
+
+// accountBlocked reports whether the account behind a session may no longer sign in
+func accountBlocked(ctx context.Context, userID primitive.ObjectID, userType string) bool {
+	collection := "users"
+	if userType == "consultant" {
+		collection = "consultants"
+	}
+	var account struct {
+		IsBlocked bool `bson:"is_blocked"`
+	}
+	err := database.GetCollection(collection).FindOne(ctx, bson.M{"_id": userID}, options.FindOne().SetProjection(bson.M{"is_blocked": 1})).Decode(&account)
+	return err != nil || account.IsBlocked
+}
+
+// rotateRefreshToken exchanges a refresh token for a new pair.
+// Presenting a token that was already exchanged means it leaked, so the whole session is revoked.
+func rotateRefreshToken(ctx context.Context, c *gin.Context, refresh string) (gin.H, error) {
+	hash := hashToken(refresh)
+	next, nextHash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	nonce, nonceHash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	coll := database.GetCollection("sessions")
+
+	// Matching on the current hash makes rotation atomic: of two concurrent refreshes only one wins.
+	// The nonce rotates too; access tokens with the previous one keep working until they expire.
+	var session Session
+	err = coll.FindOneAndUpdate(ctx,
+		bson.M{"refresh_hash": hash, "revoked_at": nil, "expires_at": bson.M{"$gt": now}},
+		bson.A{
+			bson.M{"$set": bson.M{
+				"prev_nonce_hash": "$nonce_hash",
+				"nonce_hash":      nonceHash,
+				"refresh_hash":    nextHash,
+				"last_seen_at":    now,
+				"ip":              c.ClientIP(),
+				"expires_at":      now.Add(RefreshTokenTTL),
+				"rotated_hashes": bson.M{"$slice": bson.A{
+					bson.M{"$concatArrays": bson.A{bson.M{"$ifNull": bson.A{"$rotated_hashes", bson.A{}}}, bson.A{hash}}},
+					-maxRotatedHashes,
+				}},
+			}},
+		},
+	).Decode(&session)
+	if err == mongo.ErrNoDocuments {
+		reused, _ := coll.UpdateOne(ctx,
+			bson.M{"rotated_hashes": hash, "revoked_at": nil},
+			bson.M{"$set": bson.M{"revoked_at": now, "revoke_reason": RevokeReuse}},
+		)
+		if reused != nil && reused.ModifiedCount > 0 {
+			return nil, errTokenReused
+		}
+		return nil, errSessionRevoked
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if accountBlocked(ctx, session.UserID, session.UserType) {
+		coll.UpdateOne(ctx, bson.M{"_id": session.ID}, bson.M{"$set": bson.M{"revoked_at": now, "revoke_reason": RevokeBlocked}})
+		return nil, errSessionRevoked
+	}
+	return tokenPair(session.UserID, session.ID, nonce, next)
+}
+
+// RevokeSessions signs a user out everywhere. Access tokens already issued stop working at once.
+func RevokeSessions(ctx context.Context, userID primitive.ObjectID, reason string) (int64, error) {
+	now := time.Now()
+	res, err := database.GetCollection("sessions").UpdateMany(ctx,
+		bson.M{"user_id": userID, "revoked_at": nil},
+		bson.M{"$set": bson.M{"revoked_at": now, "revoke_reason": reason}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// CurrentSessionID returns the session of the request's access token; zero outside RequireAuth
+func CurrentSessionID(c *gin.Context) primitive.ObjectID {
+	id, _ := c.Get(ContextSessionID)
+	sessionID, _ := id.(primitive.ObjectID)
+	return sessionID
+}
+
+func handleRefresh(c *gin.Context) {
+	var input struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tokens, err := rotateRefreshToken(ctx, c, input.RefreshToken)
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, tokens)
+	case errTokenReused:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token was already used; the session has been signed out"})
+	case errSessionRevoked:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+	}
+}
+
+// listSessions returns the caller's signed-in devices, most recently used first
+func listSessions(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	filter := bson.M{
+		"user_id":    CurrentUserID(c),
+		"revoked_at": nil,
+		"$or": []bson.M{
+			{"expires_at": bson.M{"$gt": now}},
+			{"expires_at": bson.M{"$exists": false}, "created_at": bson.M{"$gt": now.Add(-legacyTokenTTL)}},
+		},
+	}
+	cursor, err := database.GetCollection("sessions").Find(ctx, filter, options.Find().SetSort(bson.M{"last_seen_at": -1}))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	sessions := []Session{}
+	if err := cursor.All(ctx, &sessions); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error parsing sessions"})
+		return
+	}
+	current := CurrentSessionID(c)
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == current
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+// revokeSession signs one of the caller's devices out
+func revokeSession(c *gin.Context) {
+	sessionID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := database.GetCollection("sessions").UpdateOne(ctx,
+		bson.M{"_id": sessionID, "user_id": CurrentUserID(c), "revoked_at": nil},
+		bson.M{"$set": bson.M{"revoked_at": time.Now(), "revoke_reason": RevokeUser}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+// revokeAllSessions signs the caller out everywhere. Query: keep_current=true keeps this device signed in.
+func revokeAllSessions(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"user_id": CurrentUserID(c), "revoked_at": nil}
+	if c.Query("keep_current") == "true" {
+		filter["_id"] = bson.M{"$ne": CurrentSessionID(c)}
+	}
+	res, err := database.GetCollection("sessions").UpdateMany(ctx, filter,
+		bson.M{"$set": bson.M{"revoked_at": time.Now(), "revoke_reason": RevokeUser}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Sessions revoked", "revoked": res.ModifiedCount})
+}
+
+// switchRole ends the current session and starts one for the caller's other profile on the same device
+func switchRole(c *gin.Context) {
+	var input struct {
+		Role string `json:"role" binding:"required,oneof=user consultant"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	coll := database.GetCollection("sessions")
+	var current Session
+	if err := coll.FindOne(ctx, bson.M{"_id": CurrentSessionID(c)}).Decode(&current); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if current.IdentityUID == "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Sign in again to switch roles"})
+		return
+	}
+
+	var identity Identity
+	if err := database.GetCollection("identities").FindOne(ctx, bson.M{"_id": current.IdentityUID}).Decode(&identity); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	profile, err := loadProfile(ctx, identity, input.Role)
+	if err == errNoProfile {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No " + input.Role + " profile for this account", "roles": identity.Roles()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if profile.IsBlocked {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Your account has been blocked by Admin"})
+		return
+	}
+	if profile.ID == current.UserID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Already signed in as " + input.Role})
+		return
+	}
+
+	device := DeviceInfo{DeviceName: current.DeviceName, Platform: current.Platform}
+	tokens, err := startSession(ctx, c, profile.ID, profile.UserType, identity.UID, device)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start session"})
+		return
+	}
+	coll.UpdateOne(ctx, bson.M{"_id": current.ID}, bson.M{"$set": bson.M{"revoked_at": time.Now(), "revoke_reason": RevokeSwitch}})
+
+	tokens["user_id"] = profile.ID
+	tokens["user_type"] = profile.UserType
+	tokens["name"] = profile.Name
+	tokens["role"] = input.Role
+	tokens["roles"] = identity.Roles()
+	c.JSON(http.StatusOK, tokens)
+}
+
+// createSessionIndexes backs token lookups and drops sessions a week after they expire
+func createSessionIndexes() {
+	if !database.WaitForClient(30 * time.Second) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, _ = database.GetCollection("sessions").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "refresh_hash", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "rotated_hashes", Value: 1}}},
+		{Keys: bson.D{{Key: "token", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "last_seen_at", Value: -1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(7 * 24 * 3600)},
+	})
+}
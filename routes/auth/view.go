@@ -0,0 +1,216 @@
+package auth
+
+import (
+	"context"
+	"math"
+
+	"Agromi/database"
+	consultant_models "Agromi/routes/consultant/models"
+	market_models "Agromi/routes/market/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Relation is how a viewer is related to the profile they are looking at.
+// Each relation sees everything the ones before it see.
+type Relation int
+
+const (
+	RelationPublic  Relation = iota // Anyone, including anonymous viewers
+	RelationContact                 // Parties to an order the seller accepted or a consultation the consultant completed
+	RelationSelf                    // The subject themselves; admins read full records through the admin API instead
+)
+
+// villageGridDeg rounds coordinates to roughly 1 km, enough to show the village but not the house
+const villageGridDeg = 0.01
+
+// PrivacySettings are chosen by the user and apply to every other viewer of their profile
+type PrivacySettings struct {
+	HidePhone           bool `bson:"hide_phone" json:"hide_phone"`                       // Phone is left out even for contacts
+	VillageLocationOnly bool `bson:"village_location_only" json:"village_location_only"` // Contacts see the same rounded location as the public
+}
+
+// PublicCrop is what others see of a crop: no area or sowing date
+type PublicCrop struct {
+	Name    string `json:"name"`
+	Variety string `json:"variety,omitempty"`
+}
+
+// PublicProfile is safe to show to anyone
+type PublicProfile struct {
+	ID               primitive.ObjectID `json:"id"`
+	Name             string             `json:"name"`
+	UserType         string             `json:"user_type"`
+	ProfilePhotoURL  string             `json:"profile_photo_url,omitempty"`
+	RegionalLanguage string             `json:"regional_language,omitempty"`
+	State            string             `json:"state,omitempty"`
+	District         string             `json:"district,omitempty"`
+	Crops            []PublicCrop       `json:"crops"`
+	Location         *Location          `json:"location,omitempty"` // Village granularity, or exact for contacts who may see it
+	IsVerified       bool               `json:"is_verified"`
+}
+
+// ContactProfile adds the ways to reach the user
+type ContactProfile struct {
+	PublicProfile
+	Phone string `json:"phone,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
+// PublicView strips contact details, login identifiers and exact location
+func (u User) PublicView() PublicProfile {
+	p := PublicProfile{
+		ID:               u.ID,
+		Name:             u.Name,
+		UserType:         u.UserType,
+		ProfilePhotoURL:  u.ProfilePhotoURL,
+		RegionalLanguage: u.RegionalLanguage,
+		State:            u.State,
+		District:         u.District,
+		Crops:            make([]PublicCrop, 0, len(u.Crops)),
+		IsVerified:       u.IsVerified,
+	}
+	for _, crop := range u.Crops {
+		p.Crops = append(p.Crops, PublicCrop{Name: crop.Name, Variety: crop.Variety})
+	}
+	if u.Location.Latitude != 0 || u.Location.Longitude != 0 {
+		p.Location = &Location{
+			Latitude:  math.Round(u.Location.Latitude/villageGridDeg) * villageGridDeg,
+			Longitude: math.Round(u.Location.Longitude/villageGridDeg) * villageGridDeg,
+		}
+	}
+	return p
+}
+
+// ContactView is the public view plus phone, email and exact location, unless the user hid them
+func (u User) ContactView() ContactProfile {
+	p := ContactProfile{PublicProfile: u.PublicView(), Email: u.Email}
+	if !u.Privacy.HidePhone {
+		p.Phone = u.Phone
+	}
+	if p.Location != nil && !u.Privacy.VillageLocationOnly {
+		loc := u.Location
+		p.Location = &loc
+	}
+	return p
+}
+
+// View returns the projection of u the relation allows.
+// The full document never includes AuthTokenNum (json:"-").
+func (u User) View(rel Relation) interface{} {
+	switch rel {
+	case RelationSelf:
+		return u
+	case RelationContact:
+		return u.ContactView()
+	default:
+		return u.PublicView()
+	}
+}
+
+// Views projects a list of users for one viewer, see Relations
+func Views(ctx context.Context, viewerID primitive.ObjectID, users []User) []interface{} {
+	ids := make([]primitive.ObjectID, len(users))
+	for i := range users {
+		ids[i] = users[i].ID
+	}
+	rels := Relations(ctx, viewerID, ids)
+	out := make([]interface{}, len(users))
+	for i := range users {
+		out[i] = users[i].View(rels[users[i].ID])
+	}
+	return out
+}
+
+// ConsultantView projects a consultant profile: contacts also get phone and address
+func ConsultantView(cons consultant_models.Consultant, rel Relation) interface{} {
+	if rel >= RelationContact {
+		return cons
+	}
+	return cons.PublicView()
+}
+
+// RelationTo is Relations for a single subject
+func RelationTo(ctx context.Context, viewerID, subjectID primitive.ObjectID) Relation {
+	return Relations(ctx, viewerID, []primitive.ObjectID{subjectID})[subjectID]
+}
+
+// Relations works out how the viewer relates to each subject with one query per kind of link.
+// Subjects missing from the map are public. Anonymous viewers (zero ID) only get public views.
+func Relations(ctx context.Context, viewerID primitive.ObjectID, subjectIDs []primitive.ObjectID) map[primitive.ObjectID]Relation {
+	rels := make(map[primitive.ObjectID]Relation, len(subjectIDs))
+	if viewerID.IsZero() || len(subjectIDs) == 0 {
+		return rels
+	}
+
+	var others []primitive.ObjectID
+	for _, id := range subjectIDs {
+		if id == viewerID {
+			rels[id] = RelationSelf
+		} else {
+			others = append(others, id)
+		}
+	}
+	if len(others) == 0 {
+		return rels
+	}
+	// contact marks whichever side of a link is not the viewer
+	contact := func(a, b primitive.ObjectID) {
+		id := a
+		if id == viewerID {
+			id = b
+		}
+		if rels[id] < RelationContact {
+			rels[id] = RelationContact
+		}
+	}
+	in := bson.M{"$in": others}
+
+	// Only links written through a session count. Follows are not among them: the follow
+	// endpoint does not check who is following, so anyone could make a follow mutual.
+
+	// Buyer and seller once the seller has accepted in their session; placing an order alone reveals nothing
+	orderFilter := bson.M{
+		"status":      bson.M{"$in": []string{market_models.OrderStatusAccepted, market_models.OrderStatusCompleted}},
+		"accepted_by": bson.M{"$exists": true},
+		"$or": []bson.M{
+			{"buyer_id": viewerID, "seller_id": in},
+			{"seller_id": viewerID, "buyer_id": in},
+		},
+	}
+	if cursor, err := database.GetCollection("market_orders").Find(ctx, orderFilter, options.Find().SetProjection(bson.M{"buyer_id": 1, "seller_id": 1})); err == nil {
+		var rows []struct {
+			BuyerID  primitive.ObjectID `bson:"buyer_id"`
+			SellerID primitive.ObjectID `bson:"seller_id"`
+		}
+		if cursor.All(ctx, &rows) == nil {
+			for _, r := range rows {
+				contact(r.BuyerID, r.SellerID)
+			}
+		}
+	}
+
+	// Farmer and consultant once the consultant has completed the session; a booking alone reveals nothing
+	consultFilter := bson.M{
+		"status":       consultant_models.ConsultationCompleted,
+		"completed_by": bson.M{"$exists": true},
+		"$or": []bson.M{
+			{"farmer_id": viewerID, "consultant_id": in},
+			{"consultant_id": viewerID, "farmer_id": in},
+		},
+	}
+	if cursor, err := database.GetCollection("consultations").Find(ctx, consultFilter, options.Find().SetProjection(bson.M{"farmer_id": 1, "consultant_id": 1})); err == nil {
+		var rows []struct {
+			FarmerID     primitive.ObjectID `bson:"farmer_id"`
+			ConsultantID primitive.ObjectID `bson:"consultant_id"`
+		}
+		if cursor.All(ctx, &rows) == nil {
+			for _, r := range rows {
+				contact(r.FarmerID, r.ConsultantID)
+			}
+		}
+	}
+	return rels
+}
@@ -0,0 +1,193 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"Agromi/database"
+	calendar_models "Agromi/routes/calendar/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrNoTemplate is returned when no published template exists for a crop
+var ErrNoTemplate = errors.New("no crop calendar for this crop yet")
+
+// Source identifies what a generated schedule belongs to
+type Source struct {
+	CropID   primitive.ObjectID // auth.Crop on the farmer profile
+	PlotID   primitive.ObjectID // Or a season on a farm plot
+	SeasonID primitive.ObjectID
+}
+
+// filter matches the tasks of the source
+func (s Source) filter(ownerID primitive.ObjectID) bson.M {
+	f := bson.M{"owner_id": ownerID}
+	if !s.CropID.IsZero() {
+		f["crop_id"] = s.CropID
+	} else {
+		f["plot_id"] = s.PlotID
+		f["season_id"] = s.SeasonID
+	}
+	return f
+}
+
+// normalizeCrop makes crop and variety names comparable
+func normalizeCrop(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+// family is the template family key of a crop/variety
+func family(crop, variety string) string {
+	return normalizeCrop(crop) + "|" + normalizeCrop(variety)
+}
+
+// findTemplate returns the newest published template for the variety, falling back to the crop's generic one
+func findTemplate(ctx context.Context, crop, variety string) (calendar_models.Template, error) {
+	var t calendar_models.Template
+	coll := database.GetCollection("calendar_templates")
+	opts := options.FindOne().SetSort(bson.M{"version": -1})
+
+	families := []string{family(crop, "")}
+	if normalizeCrop(variety) != "" {
+		families = []string{family(crop, variety), family(crop, "")}
+	}
+	for _, fam := range families {
+		err := coll.FindOne(ctx, bson.M{"family": fam, "status": calendar_models.TemplatePublished}, opts).Decode(&t)
+		if err == nil {
+			return t, nil
+		}
+		if err != mongo.ErrNoDocuments {
+			return t, err
+		}
+	}
+	return t, ErrNoTemplate
+}
+
+// localize picks the text for lang, then English, then any translation
+func localize(texts map[string]string, lang string) string {
+	if s, ok := texts[strings.ToLower(lang)]; ok && s != "" {
+		return s
+	}
+	if s, ok := texts[calendar_models.DefaultLanguage]; ok && s != "" {
+		return s
+	}
+	for _, s := range texts {
+		if s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// userLanguage is the farmer's RegionalLanguage, or the default
+func userLanguage(ctx context.Context, ownerID primitive.ObjectID) string {
+	var u struct {
+		RegionalLanguage string `bson:"regional_language"`
+	}
+	database.GetCollection("users").FindOne(ctx, bson.M{"_id": ownerID}, options.FindOne().SetProjection(bson.M{"regional_language": 1})).Decode(&u)
+	if u.RegionalLanguage == "" {
+		return calendar_models.DefaultLanguage
+	}
+	return strings.ToLower(u.RegionalLanguage)
+}
+
+// Generate (re)builds the task schedule of a crop from its sowing date.
+// Pending tasks are replaced; tasks the farmer already marked done or skipped are kept.
+// Returns the number of tasks created.
+func Generate(ctx context.Context, ownerID primitive.ObjectID, src Source, crop, variety string, sowing time.Time) (int, error) {
+	tmpl, err := findTemplate(ctx, crop, variety)
+	if err != nil {
+		return 0, err
+	}
+	lang := userLanguage(ctx, ownerID)
+
+	coll := database.GetCollection("crop_tasks")
+	base := src.filter(ownerID)
+
+	pending := bson.M{"status": calendar_models.TaskPending}
+	for k, v := range base {
+		pending[k] = v
+	}
+	if _, err := coll.DeleteMany(ctx, pending); err != nil {
+		return 0, err
+	}
+
+	// Keys the farmer already acted on are not scheduled again
+	handled := map[string]bool{}
+	if cursor, err := coll.Find(ctx, base, options.Find().SetProjection(bson.M{"key": 1})); err == nil {
+		var rows []calendar_models.Task
+		if cursor.All(ctx, &rows) == nil {
+			for _, r := range rows {
+				handled[r.Key] = true
+			}
+		}
+	}
+
+	now := time.Now()
+	day := time.Date(sowing.Year(), sowing.Month(), sowing.Day(), 0, 0, 0, 0, sowing.Location())
+	var docs []interface{}
+	for _, tt := range tmpl.Tasks {
+		if handled[tt.Key] {
+			continue
+		}
+		due := day.AddDate(0, 0, tt.DayOffset)
+		docs = append(docs, calendar_models.Task{
+			ID:              primitive.NewObjectID(),
+			OwnerID:         ownerID,
+			CropID:          src.CropID,
+			PlotID:          src.PlotID,
+			SeasonID:        src.SeasonID,
+			CropName:        crop,
+			TemplateID:      tmpl.ID,
+			TemplateVersion: tmpl.Version,
+			Key:             tt.Key,
+			Type:            tt.Type,
+			Title:           localize(tt.Title, lang),
+			Description:     localize(tt.Description, lang),
+			Language:        lang,
+			DueDate:         due,
+			WindowEnd:       due.AddDate(0, 0, max(tt.WindowDays, 1)),
+			Status:          calendar_models.TaskPending,
+			CreatedAt:       now,
+		})
+	}
+	if len(docs) == 0 {
+		return 0, nil
+	}
+	if _, err := coll.InsertMany(ctx, docs); err != nil {
+		return 0, err
+	}
+	return len(docs), nil
+}
+
+// RemoveSchedule deletes the pending tasks of a source, e.g. when the crop is removed
+func RemoveSchedule(ctx context.Context, ownerID primitive.ObjectID, src Source) {
+	f := src.filter(ownerID)
+	f["status"] = calendar_models.TaskPending
+	database.GetCollection("crop_tasks").DeleteMany(ctx, f)
+}
+
+// RemovePlotSchedules deletes the pending tasks of every season on a plot
+func RemovePlotSchedules(ctx context.Context, ownerID, plotID primitive.ObjectID) {
+	database.GetCollection("crop_tasks").DeleteMany(ctx, bson.M{
+		"owner_id": ownerID,
+		"plot_id":  plotID,
+		"status":   calendar_models.TaskPending,
+	})
+}
+
+// Sync keeps a source's schedule in step after the farmer edits it; failures are ignored
+// because the farmer can always regenerate from the calendar endpoint.
+func Sync(ctx context.Context, ownerID primitive.ObjectID, src Source, crop, variety string, sowing *time.Time) {
+	if sowing == nil {
+		RemoveSchedule(ctx, ownerID, src)
+		return
+	}
+	_, _ = Generate(ctx, ownerID, src, crop, variety, *sowing)
+}
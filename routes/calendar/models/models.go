@@ -0,0 +1,103 @@
+package calendar_models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Task types
+const (
+	TaskIrrigation = "irrigation"
+	TaskFertilizer = "fertilizer"
+	TaskSpray      = "spray"
+	TaskWeeding    = "weeding"
+	TaskHarvest    = "harvest"
+	TaskOther      = "other"
+)
+
+// TaskTypes accepted in templates
+var TaskTypes = []string{TaskIrrigation, TaskFertilizer, TaskSpray, TaskWeeding, TaskHarvest, TaskOther}
+
+// Template statuses
+const (
+	TemplateDraft     = "draft"
+	TemplatePublished = "published"
+	TemplateRetired   = "retired"
+)
+
+// Task statuses
+const (
+	TaskPending = "pending"
+	TaskDone    = "done"
+	TaskSkipped = "skipped"
+)
+
+// DefaultLanguage is used when a text has no translation for the farmer's RegionalLanguage
+const DefaultLanguage = "en"
+
+// Limits
+const (
+	MaxTemplateTasks = 100
+	MinDayOffset     = -60 // Land preparation before sowing
+	MaxDayOffset     = 400
+	MaxWindowDays    = 60
+)
+
+// Template is one version of a crop calendar (collection: calendar_templates).
+// All versions for a crop/variety share a Family; the highest published version is used.
+type Template struct {
+	ID      primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Family  string             `bson:"family" json:"family"` // "<crop>|<variety>"
+	Crop    string             `bson:"crop" json:"crop"`     // Normalized crop name
+	Variety string             `bson:"variety" json:"variety"`
+	Version int                `bson:"version" json:"version"`
+	Status  string             `bson:"status" json:"status"`
+
+	Tasks []TemplateTask `bson:"tasks" json:"tasks"`
+
+	AuthorID   primitive.ObjectID `bson:"author_id" json:"author_id"`
+	AuthorType string             `bson:"author_type" json:"author_type"` // "admin" or "consultant"
+	Notes      string             `bson:"notes,omitempty" json:"notes,omitempty"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// TemplateTask is a task scheduled relative to the sowing date
+type TemplateTask struct {
+	Key         string            `bson:"key" json:"key"` // Stable within a family, e.g. "urea_split_2"
+	Type        string            `bson:"type" json:"type"`
+	DayOffset   int               `bson:"day_offset" json:"day_offset"`   // Days after sowing (negative = before)
+	WindowDays  int               `bson:"window_days" json:"window_days"` // How long the task stays actionable
+	Title       map[string]string `bson:"title" json:"title"`             // Language code -> text
+	Description map[string]string `bson:"description,omitempty" json:"description,omitempty"`
+}
+
+// Task is a dated task generated for one farmer crop (collection: crop_tasks)
+type Task struct {
+	ID      primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OwnerID primitive.ObjectID `bson:"owner_id" json:"owner_id"`
+
+	// Source: a profile crop (CropID) or a plot season (PlotID + SeasonID)
+	CropID   primitive.ObjectID `bson:"crop_id,omitempty" json:"crop_id,omitempty"`
+	PlotID   primitive.ObjectID `bson:"plot_id,omitempty" json:"plot_id,omitempty"`
+	SeasonID primitive.ObjectID `bson:"season_id,omitempty" json:"season_id,omitempty"`
+	CropName string             `bson:"crop_name" json:"crop_name"`
+
+	TemplateID      primitive.ObjectID `bson:"template_id" json:"template_id"`
+	TemplateVersion int                `bson:"template_version" json:"template_version"`
+	Key             string             `bson:"key" json:"key"`
+	Type            string             `bson:"type" json:"type"`
+	Title           string             `bson:"title" json:"title"` // Localized at generation time
+	Description     string             `bson:"description,omitempty" json:"description,omitempty"`
+	Language        string             `bson:"language" json:"language"`
+
+	DueDate   time.Time `bson:"due_date" json:"due_date"`
+	WindowEnd time.Time `bson:"window_end" json:"window_end"`
+
+	Status         string     `bson:"status" json:"status"`
+	Note           string     `bson:"note,omitempty" json:"note,omitempty"`
+	CompletedAt    *time.Time `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	ReminderSentAt *time.Time `bson:"reminder_sent_at,omitempty" json:"reminder_sent_at,omitempty"`
+	CreatedAt      time.Time  `bson:"created_at" json:"created_at"`
+}
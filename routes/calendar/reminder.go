@@ -0,0 +1,78 @@
+package calendar
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"Agromi/database"
+	calendar_models "Agromi/routes/calendar/models"
+	"Agromi/routes/social"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Reminders go out this long before a task is due
+const reminderLead = 24 * time.Hour
+
+// runReminderJob notifies farmers about tasks coming due, once per task
+func runReminderJob() {
+	if !database.WaitForClient(30 * time.Second) {
+		log.Println("Crop calendar: database not ready, reminder job not started")
+		return
+	}
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		sendReminders()
+		<-ticker.C
+	}
+}
+
+func sendReminders() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	now := time.Now()
+	coll := database.GetCollection("crop_tasks")
+	filter := bson.M{
+		"status":           calendar_models.TaskPending,
+		"due_date":         bson.M{"$lte": now.Add(reminderLead)},
+		"window_end":       bson.M{"$gt": now}, // Tasks whose window passed are not worth a reminder
+		"reminder_sent_at": bson.M{"$exists": false},
+	}
+	cursor, err := coll.Find(ctx, filter, options.Find().SetLimit(5000))
+	if err != nil {
+		log.Println("Crop calendar: reminder query failed:", err)
+		return
+	}
+
+	var tasks []calendar_models.Task
+	if err := cursor.All(ctx, &tasks); err != nil {
+		log.Println("Crop calendar: reminder decode failed:", err)
+		return
+	}
+
+	sent := 0
+	for _, t := range tasks {
+		// Claim first so overlapping runs never notify twice
+		res, err := coll.UpdateOne(ctx,
+			bson.M{"_id": t.ID, "reminder_sent_at": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"reminder_sent_at": now}},
+		)
+		if err != nil || res.ModifiedCount == 0 {
+			continue
+		}
+		msg := t.Title
+		if t.CropName != "" {
+			msg = t.CropName + ": " + t.Title
+		}
+		social.CreateNotification(ctx, t.OwnerID, "crop_task", msg, t.ID)
+		sent++
+	}
+	if sent > 0 {
+		log.Printf("Crop calendar: sent %d task reminders", sent)
+	}
+}
@@ -0,0 +1,66 @@
+package calendar
+
+import (
+	"context"
+	"time"
+
+	"Agromi/core/router"
+	"Agromi/database"
+	admin_audit "Agromi/routes/admin/audit"
+	"Agromi/routes/auth"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	router.Register(func(r *gin.Engine) {
+		farmer := r.Group("/api/calendar", auth.RequireAuth(), auth.RequireRole(auth.RoleUser))
+		{
+			farmer.POST("/generate", GenerateSchedule)
+			farmer.GET("/tasks", ListTasks)
+			farmer.PUT("/tasks/:id", UpdateTask)
+		}
+
+		admin := r.Group("/api/admin/calendar", auth.RequireAuth(), auth.RequireRole(auth.RoleAdmin))
+		{
+			admin.POST("/templates", AdminCreateTemplate)
+			admin.GET("/templates", AdminListTemplates)
+			admin.GET("/templates/:id", GetTemplate)
+			admin.PUT("/templates/:id/status", admin_audit.RequireReason(), AdminSetTemplateStatus)
+		}
+
+		consultant := r.Group("/api/consultant/calendar", auth.RequireAuth(), auth.RequireRole(auth.RoleConsultant))
+		{
+			consultant.POST("/templates", ConsultantCreateTemplate)
+			consultant.GET("/templates", ConsultantListTemplates)
+			consultant.GET("/templates/:id", GetTemplate)
+		}
+	})
+
+	go createCalendarIndexes()
+	go runReminderJob()
+}
+
+// createCalendarIndexes backs template lookup, task listing and the reminder scan
+func createCalendarIndexes() {
+	if !database.WaitForClient(30 * time.Second) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, _ = database.GetCollection("calendar_templates").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "family", Value: 1}, {Key: "version", Value: -1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "author_id", Value: 1}}},
+	})
+	_, _ = database.GetCollection("crop_tasks").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "due_date", Value: 1}}},
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "crop_id", Value: 1}}},
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "plot_id", Value: 1}, {Key: "season_id", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "due_date", Value: 1}}},
+	})
+}
@@ -0,0 +1,207 @@
+package calendar
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"Agromi/database"
+	"Agromi/routes/auth"
+	calendar_models "Agromi/routes/calendar/models"
+	"Agromi/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GenerateSchedule (re)builds the calendar of one profile crop (crop_id) or plot season (plot_id + season_id)
+func GenerateSchedule(c *gin.Context) {
+	var body struct {
+		CropID   string `json:"crop_id"`
+		PlotID   string `json:"plot_id"`
+		SeasonID string `json:"season_id"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ownerID := auth.CurrentUserID(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var src Source
+	var cropName, variety string
+	var sowing *time.Time
+
+	if body.CropID != "" {
+		cropID, err := primitive.ObjectIDFromHex(body.CropID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid crop_id"})
+			return
+		}
+		var user struct {
+			Crops []auth.Crop `bson:"crops"`
+		}
+		opts := options.FindOne().SetProjection(bson.M{"crops": bson.M{"$elemMatch": bson.M{"id": cropID}}})
+		if err := database.GetCollection("users").FindOne(ctx, bson.M{"_id": ownerID}, opts).Decode(&user); err != nil || len(user.Crops) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Crop not found"})
+			return
+		}
+		src = Source{CropID: cropID}
+		cropName, variety, sowing = user.Crops[0].Name, user.Crops[0].Variety, user.Crops[0].SowingDate
+	} else {
+		plotID, err1 := primitive.ObjectIDFromHex(body.PlotID)
+		seasonID, err2 := primitive.ObjectIDFromHex(body.SeasonID)
+		if err1 != nil || err2 != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "crop_id, or plot_id and season_id, required"})
+			return
+		}
+		var plot struct {
+			Seasons []struct {
+				CropName   string     `bson:"crop_name"`
+				Variety    string     `bson:"variety"`
+				SowingDate *time.Time `bson:"sowing_date"`
+			} `bson:"seasons"`
+		}
+		opts := options.FindOne().SetProjection(bson.M{"seasons": bson.M{"$elemMatch": bson.M{"id": seasonID}}})
+		if err := database.GetCollection("farm_plots").FindOne(ctx, bson.M{"_id": plotID, "owner_id": ownerID}, opts).Decode(&plot); err != nil || len(plot.Seasons) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Season not found"})
+			return
+		}
+		src = Source{PlotID: plotID, SeasonID: seasonID}
+		cropName, variety, sowing = plot.Seasons[0].CropName, plot.Seasons[0].Variety, plot.Seasons[0].SowingDate
+	}
+
+	if sowing == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Set a sowing date first"})
+		return
+	}
+
+	n, err := Generate(ctx, ownerID, src, cropName, variety, *sowing)
+	if err == ErrNoTemplate {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate calendar"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Calendar generated", "tasks_created": n})
+}
+
+// ListTasks returns the farmer's tasks by due date.
+// Filters: status, crop_id, plot_id, from/to (YYYY-MM-DD). Paged with cursor.
+func ListTasks(c *gin.Context) {
+	filter := bson.M{"owner_id": auth.CurrentUserID(c)}
+	if status := c.Query("status"); status != "" {
+		filter["status"] = status
+	}
+	if id, err := primitive.ObjectIDFromHex(c.Query("crop_id")); err == nil {
+		filter["crop_id"] = id
+	}
+	if id, err := primitive.ObjectIDFromHex(c.Query("plot_id")); err == nil {
+		filter["plot_id"] = id
+	}
+
+	due := bson.M{}
+	if from, err := time.Parse("2006-01-02", c.Query("from")); err == nil {
+		due["$gte"] = from
+	}
+	if to, err := time.Parse("2006-01-02", c.Query("to")); err == nil {
+		due["$lt"] = to.AddDate(0, 0, 1)
+	}
+	if len(due) > 0 {
+		filter["due_date"] = due
+	}
+
+	if raw := c.Query("cursor"); raw != "" {
+		cur, err := utils.DecodeCursor(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		after := time.UnixMilli(int64(cur.Key))
+		filter["$or"] = []bson.M{
+			{"due_date": bson.M{"$gt": after}},
+			{"due_date": after, "_id": bson.M{"$gt": cur.ID}},
+		}
+	}
+
+	limit := utils.ParseLimit(c.Query("limit"), 50, 200)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "due_date", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(limit + 1)
+	cursor, err := database.GetCollection("crop_tasks").Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	tasks := []calendar_models.Task{}
+	if err := cursor.All(ctx, &tasks); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error parsing tasks"})
+		return
+	}
+
+	nextCursor := ""
+	if int64(len(tasks)) > limit {
+		tasks = tasks[:limit]
+		last := tasks[len(tasks)-1]
+		nextCursor = utils.EncodeCursor(float64(last.DueDate.UnixMilli()), last.ID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tasks": tasks, "next_cursor": nextCursor})
+}
+
+// UpdateTask marks a task done or skipped, or reopens it
+func UpdateTask(c *gin.Context) {
+	taskID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var body struct {
+		Status string `json:"status" binding:"required,oneof=pending done skipped"`
+		Note   string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	update := bson.M{"$set": bson.M{"status": body.Status, "note": body.Note}}
+	if body.Status == calendar_models.TaskPending {
+		update["$unset"] = bson.M{"completed_at": ""}
+	} else {
+		update["$set"].(bson.M)["completed_at"] = time.Now()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var task calendar_models.Task
+	err = database.GetCollection("crop_tasks").FindOneAndUpdate(ctx,
+		bson.M{"_id": taskID, "owner_id": auth.CurrentUserID(c)},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&task)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task"})
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
@@ -0,0 +1,288 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"Agromi/database"
+	admin_audit "Agromi/routes/admin/audit"
+	"Agromi/routes/auth"
+	calendar_models "Agromi/routes/calendar/models"
+	consultant_models "Agromi/routes/consultant/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type templateInput struct {
+	Crop    string                         `json:"crop" binding:"required"`
+	Variety string                         `json:"variety"` // Empty = applies to every variety of the crop
+	Notes   string                         `json:"notes"`
+	Tasks   []calendar_models.TemplateTask `json:"tasks" binding:"required"`
+}
+
+// validateTasks normalizes template tasks and sorts them by day offset
+func validateTasks(tasks []calendar_models.TemplateTask) ([]calendar_models.TemplateTask, error) {
+	if len(tasks) == 0 || len(tasks) > calendar_models.MaxTemplateTasks {
+		return nil, fmt.Errorf("a template needs 1 to %d tasks", calendar_models.MaxTemplateTasks)
+	}
+	seen := map[string]bool{}
+	out := make([]calendar_models.TemplateTask, 0, len(tasks))
+	for i, t := range tasks {
+		t.Key = strings.ToLower(strings.TrimSpace(t.Key))
+		t.Type = strings.ToLower(strings.TrimSpace(t.Type))
+		if t.Key == "" {
+			t.Key = fmt.Sprintf("task_%d", i+1)
+		}
+		if seen[t.Key] {
+			return nil, fmt.Errorf("task %d: duplicate key %q", i, t.Key)
+		}
+		seen[t.Key] = true
+		if !slices.Contains(calendar_models.TaskTypes, t.Type) {
+			return nil, fmt.Errorf("task %d: type must be one of %s", i, strings.Join(calendar_models.TaskTypes, ", "))
+		}
+		if t.DayOffset < calendar_models.MinDayOffset || t.DayOffset > calendar_models.MaxDayOffset {
+			return nil, fmt.Errorf("task %d: day_offset must be between %d and %d", i, calendar_models.MinDayOffset, calendar_models.MaxDayOffset)
+		}
+		if t.WindowDays < 0 || t.WindowDays > calendar_models.MaxWindowDays {
+			return nil, fmt.Errorf("task %d: window_days must be between 0 and %d", i, calendar_models.MaxWindowDays)
+		}
+		t.Title = cleanTexts(t.Title)
+		t.Description = cleanTexts(t.Description)
+		if len(t.Title) == 0 {
+			return nil, fmt.Errorf("task %d: title needs at least one language", i)
+		}
+		out = append(out, t)
+	}
+	slices.SortStableFunc(out, func(a, b calendar_models.TemplateTask) int { return a.DayOffset - b.DayOffset })
+	return out, nil
+}
+
+// cleanTexts lowercases language codes and drops empty translations
+func cleanTexts(in map[string]string) map[string]string {
+	out := map[string]string{}
+	for lang, s := range in {
+		lang, s = strings.ToLower(strings.TrimSpace(lang)), strings.TrimSpace(s)
+		if lang != "" && s != "" {
+			out[lang] = s
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// createTemplate stores a new draft version in the crop/variety family.
+// Errors are written to the response; on success the caller responds.
+func createTemplate(ctx context.Context, c *gin.Context, in templateInput, authorID primitive.ObjectID, authorType string) (calendar_models.Template, bool) {
+	tasks, err := validateTasks(in.Tasks)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return calendar_models.Template{}, false
+	}
+	crop, variety := normalizeCrop(in.Crop), normalizeCrop(in.Variety)
+	if crop == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "crop required"})
+		return calendar_models.Template{}, false
+	}
+
+	coll := database.GetCollection("calendar_templates")
+	now := time.Now()
+	tmpl := calendar_models.Template{
+		Family:     family(crop, variety),
+		Crop:       crop,
+		Variety:    variety,
+		Status:     calendar_models.TemplateDraft,
+		Tasks:      tasks,
+		AuthorID:   authorID,
+		AuthorType: authorType,
+		Notes:      strings.TrimSpace(in.Notes),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	// The unique (family, version) index makes a concurrent author retry with the next number
+	for attempt := 0; attempt < 3; attempt++ {
+		var latest calendar_models.Template
+		err := coll.FindOne(ctx, bson.M{"family": tmpl.Family}, options.FindOne().SetSort(bson.M{"version": -1}).SetProjection(bson.M{"version": 1})).Decode(&latest)
+		if err != nil && err != mongo.ErrNoDocuments {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return tmpl, false
+		}
+		tmpl.ID = primitive.NewObjectID()
+		tmpl.Version = latest.Version + 1
+		_, err = coll.InsertOne(ctx, tmpl)
+		if err == nil {
+			return tmpl, true
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			break
+		}
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create template"})
+	return tmpl, false
+}
+
+// listTemplates returns template versions, newest first, filtered by crop, status and author
+func listTemplates(c *gin.Context, filter bson.M) {
+	if crop := normalizeCrop(c.Query("crop")); crop != "" {
+		filter["crop"] = crop
+	}
+	if status := c.Query("status"); status != "" {
+		filter["status"] = status
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "family", Value: 1}, {Key: "version", Value: -1}}).
+		SetLimit(200)
+	cursor, err := database.GetCollection("calendar_templates").Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	templates := []calendar_models.Template{}
+	if err := cursor.All(ctx, &templates); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error parsing templates"})
+		return
+	}
+	c.JSON(http.StatusOK, templates)
+}
+
+// AdminCreateTemplate adds a draft version authored by the signed-in admin
+func AdminCreateTemplate(c *gin.Context) {
+	var body templateInput
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tmpl, ok := createTemplate(ctx, c, body, auth.CurrentUserID(c), "admin")
+	if !ok {
+		return
+	}
+	admin_audit.Record(ctx, c, admin_audit.Event{Action: "calendar_template.create", Collection: "calendar_templates", TargetID: tmpl.ID, Created: true})
+
+	c.JSON(http.StatusCreated, tmpl)
+}
+
+// AdminListTemplates lists every template version
+func AdminListTemplates(c *gin.Context) {
+	listTemplates(c, bson.M{})
+}
+
+// GetTemplate returns one template version
+func GetTemplate(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var tmpl calendar_models.Template
+	if err := database.GetCollection("calendar_templates").FindOne(ctx, bson.M{"_id": id}).Decode(&tmpl); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+	c.JSON(http.StatusOK, tmpl)
+}
+
+// AdminSetTemplateStatus publishes or retires a version; the route requires an audit reason.
+// Publishing retires the family's previously published version, so farmers get one calendar per crop/variety.
+func AdminSetTemplateStatus(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var body struct {
+		Status string `json:"status" binding:"required,oneof=draft published retired"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	coll := database.GetCollection("calendar_templates")
+	before := admin_audit.Snapshot(ctx, "calendar_templates", id)
+	var tmpl calendar_models.Template
+	err = coll.FindOneAndUpdate(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"status": body.Status, "updated_at": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&tmpl)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	ev := admin_audit.Event{Action: "calendar_template." + body.Status, Collection: "calendar_templates", TargetID: tmpl.ID, Before: before}
+	if body.Status == calendar_models.TemplatePublished {
+		res, err := coll.UpdateMany(ctx,
+			bson.M{"family": tmpl.Family, "_id": bson.M{"$ne": tmpl.ID}, "status": calendar_models.TemplatePublished},
+			bson.M{"$set": bson.M{"status": calendar_models.TemplateRetired, "updated_at": time.Now()}},
+		)
+		if err == nil {
+			ev.Details = bson.M{"retired_versions": res.ModifiedCount}
+		}
+	}
+	admin_audit.Record(ctx, c, ev)
+
+	c.JSON(http.StatusOK, tmpl)
+}
+
+// verifiedConsultant checks that the consultant may author templates
+func verifiedConsultant(ctx context.Context, id primitive.ObjectID) (primitive.ObjectID, bool) {
+	n, _ := database.GetCollection("consultants").CountDocuments(ctx, bson.M{
+		"_id":                 id,
+		"verification_status": consultant_models.StatusVerified,
+		"is_blocked":          bson.M{"$ne": true},
+	})
+	return id, n > 0
+}
+
+// ConsultantCreateTemplate lets a verified consultant propose a draft; an admin publishes it
+func ConsultantCreateTemplate(c *gin.Context) {
+	var body templateInput
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	consultantID, ok := verifiedConsultant(ctx, auth.CurrentUserID(c))
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only verified consultants can author crop calendars"})
+		return
+	}
+	if tmpl, ok := createTemplate(ctx, c, body, consultantID, "consultant"); ok {
+		c.JSON(http.StatusCreated, tmpl)
+	}
+}
+
+// ConsultantListTemplates lists the templates the signed-in consultant authored
+func ConsultantListTemplates(c *gin.Context) {
+	listTemplates(c, bson.M{"author_id": auth.CurrentUserID(c), "author_type": "consultant"})
+}
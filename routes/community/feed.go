@@ -3,111 +3,326 @@ package community
 import (
 	"context"
 	"net/http"
-	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"Agromi/database"
+	"Agromi/routes/auth"
 	community_models "Agromi/routes/community/models"
-	"Agromi/utils" // Assuming Haversine is here
+	"Agromi/routes/social"
+	social_models "Agromi/routes/social/models"
+	"Agromi/utils"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// GetFeed returns scored community posts
+func init() {
+	go createPostIndexes()
+}
+
+// Constants for Weighting
+const (
+	W_Rel   = 1.0 // Relevance
+	W_Dist  = 0.3 // Distance
+	W_Rate  = 0.3 // Rating
+	W_Fresh = 0.4 // Freshness
+)
+
+const (
+	distanceHorizonKm = 100.0  // Distance score fades to 0 at this range
+	earthRadiusKm     = 6378.1 // For $centerSphere radians
+	defaultFeedLimit  = 20
+	maxFeedLimit      = 50
+)
+
+// feedQuery is the parsed set of feed filters
+type feedQuery struct {
+	Lat, Lon float64
+	HasGeo   bool
+	RadiusKm float64 // 0 = no hard distance filter
+	Tags     []string
+	Text     string
+	AsOf     time.Time            // Freshness reference; fixed across pages
+	Exclude  []primitive.ObjectID // Posts never to return (e.g. already seen)
+}
+
+// feedFeature is one weighted term of the feed score. Its value is kept on the
+// post under rank_features so ranking can be logged and tuned later.
+type feedFeature struct {
+	Name   string
+	Weight float64
+	Expr   interface{}
+}
+
+// GetFeed returns one page of scored community posts as a bare array, the shape
+// existing clients read. Paging clients use GetFeedPage.
 func GetFeed(c *gin.Context) {
-	latStr := c.Query("lat")
-	lonStr := c.Query("lon")
-	query := c.Query("query")
+	if posts, _, ok := loadFeedPage(c); ok {
+		c.JSON(http.StatusOK, posts)
+	}
+}
+
+// GetFeedPage returns scored community posts, one page at a time, with the cursor for the next.
+// Query: lat, lon, radius (km), tag (comma separated), query (text search),
+// limit, cursor (next_cursor from the previous page), mode (default|personal).
+// The viewer is the signed-in user; personal mode needs one.
+// Posts pinned for the viewer's region come first on the first page.
+func GetFeedPage(c *gin.Context) {
+	if posts, nextCursor, ok := loadFeedPage(c); ok {
+		c.JSON(http.StatusOK, gin.H{"posts": posts, "next_cursor": nextCursor})
+	}
+}
+
+// loadFeedPage reads the feed query and loads one page; ok is false once an error was written
+func loadFeedPage(c *gin.Context) ([]community_models.Post, string, bool) {
+	q := feedQuery{
+		Text: strings.TrimSpace(c.Query("query")),
+		Tags: splitTags(c.Query("tag")),
+		AsOf: time.Now(),
+	}
+
+	latStr, lonStr := c.Query("lat"), c.Query("lon")
+	if latStr != "" && lonStr != "" {
+		lat, errLat := strconv.ParseFloat(latStr, 64)
+		lon, errLon := strconv.ParseFloat(lonStr, 64)
+		if errLat != nil || errLon != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid lat/lon"})
+			return nil, "", false
+		}
+		q.Lat, q.Lon, q.HasGeo = lat, lon, true
+	}
+	if radiusStr := c.Query("radius"); radiusStr != "" {
+		radius, err := strconv.ParseFloat(radiusStr, 64)
+		if err != nil || radius <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "radius must be a positive number of km"})
+			return nil, "", false
+		}
+		if !q.HasGeo {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "radius requires lat and lon"})
+			return nil, "", false
+		}
+		q.RadiusKm = radius
+	}
+
+	var after *utils.Cursor
+	if cursorStr := c.Query("cursor"); cursorStr != "" {
+		cur, err := utils.DecodeCursor(cursorStr)
+		if err != nil || cur.AsOf == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return nil, "", false
+		}
+		after = &cur
+		q.AsOf = time.UnixMilli(cur.AsOf)
+	}
 
-	userLat, _ := strconv.ParseFloat(latStr, 64)
-	userLon, _ := strconv.ParseFloat(lonStr, 64)
+	limit := utils.ParseLimit(c.Query("limit"), defaultFeedLimit, maxFeedLimit)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
+	viewerID := auth.CurrentUserID(c)
 
-	coll := database.GetCollection("community_posts")
+	// Personalized mode: boost followed authors, the viewer's crops and language; hide seen posts
+	mode := c.DefaultQuery("mode", feedModeDefault)
+	var extra []feedFeature
+	switch mode {
+	case feedModeDefault:
+	case feedModePersonal:
+		if viewerID.IsZero() {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Sign in for the personal feed"})
+			return nil, "", false
+		}
+		p, err := loadPersonalization(ctx, viewerID, q.AsOf)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Viewer not found"})
+			return nil, "", false
+		}
+		extra = p.Features
+		q.Exclude = p.Seen
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be default or personal"})
+		return nil, "", false
+	}
 
-	// Basic filter (can extend to text search if query provided)
-	filter := bson.M{}
-	cursor, err := coll.Find(ctx, filter)
+	// Pinned posts head the first page and are left out of the ranked pages
+	pinned := loadPinnedPosts(ctx, q)
+	for _, p := range pinned {
+		q.Exclude = append(q.Exclude, p.ID)
+	}
+
+	pipeline := buildFeedPipeline(q, extra, after, limit+1)
+
+	coll := database.GetCollection("community_posts")
+	cursor, err := coll.Aggregate(ctx, pipeline)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
-		return
+		return nil, "", false
 	}
 
-	var posts []community_models.Post
+	posts := []community_models.Post{}
 	if err = cursor.All(ctx, &posts); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error parsing posts"})
-		return
+		return nil, "", false
 	}
 
-	// Constants for Weighting
-	const (
-		W_Rel   = 1.0 // Relevance
-		W_Dist  = 0.3 // Distance
-		W_Rate  = 0.3 // Rating
-		W_Fresh = 0.4 // Freshness
-	)
+	nextCursor := ""
+	if int64(len(posts)) > limit {
+		posts = posts[:limit]
+		last := posts[len(posts)-1]
+		nextCursor = utils.EncodeScoreCursor(last.Score, last.ID, q.AsOf)
+	}
 
-	for i := range posts {
-		p := &posts[i]
-
-		// 1. Relevance
-		relevanceScore := 1.0
-		if query != "" {
-			// Simple check: if query in content, boost score
-			// In prod: Use MongoDB Text Search Score
-			// Here: Manual placeholder
-			if contains(p.Content, query) {
-				relevanceScore = 2.0
-			} else {
-				relevanceScore = 0.1
-			}
-		}
+	if !viewerID.IsZero() {
+		logRanking(ctx, viewerID, mode, q.AsOf, after == nil, posts)
+	}
+	if after == nil {
+		posts = append(pinned, posts...)
+	}
+	attachViewerState(ctx, posts, viewerID)
 
-		// 2. Distance
-		distScore := 0.0
-		if p.Location != nil && len(p.Location.Coordinates) == 2 {
-			dist := utils.Haversine(userLat, userLon, p.Location.Coordinates[1], p.Location.Coordinates[0])
-			// Normalize: Closer is better. 100km max?
-			if dist < 100 {
-				distScore = (100.0 - dist) / 100.0
-			}
-		}
+	return posts, nextCursor, true
+}
 
-		// 3. Rating
-		// Normalized 0-5 -> 0-1
-		ratingScore := p.SenderRating / 5.0
+// buildFeedPipeline filters posts, scores them inside MongoDB and returns one page.
+// extra features are added to the base score (used by the personalized feed).
+// Ties on score are broken by _id so pages never overlap or skip posts.
+func buildFeedPipeline(q feedQuery, extra []feedFeature, after *utils.Cursor, limit int64) []bson.M {
+	// Only posts that existed when the first page was served, so later pages stay stable
+	match := bson.M{"created_at": bson.M{"$lte": q.AsOf}, "is_deleted": bson.M{"$ne": true}}
+	if q.Text != "" {
+		match["$text"] = bson.M{"$search": q.Text}
+	}
+	if len(q.Tags) > 0 {
+		match["tags"] = bson.M{"$in": q.Tags}
+	}
+	if q.RadiusKm > 0 {
+		match["location"] = bson.M{"$geoWithin": bson.M{
+			"$centerSphere": []interface{}{[]float64{q.Lon, q.Lat}, q.RadiusKm / earthRadiusKm},
+		}}
+	}
+	if len(q.Exclude) > 0 {
+		match["_id"] = bson.M{"$nin": q.Exclude}
+	}
 
-		// 4. Freshness
-		// Hours since posted. Decay.
-		hours := time.Since(p.CreatedAt).Hours()
-		freshnessScore := 1.0 / (1.0 + hours/24.0) // Drops over days
+	// 1. Relevance: text score squashed into [1, 2); 1.0 when not searching
+	var relevance interface{} = 1.0
+	if q.Text != "" {
+		ts := bson.M{"$meta": "textScore"}
+		relevance = bson.M{"$add": []interface{}{1, bson.M{"$divide": []interface{}{ts, bson.M{"$add": []interface{}{ts, 1}}}}}}
+	}
 
-		// Total Score
-		p.Score = (W_Rel * relevanceScore) + (W_Dist * distScore) + (W_Rate * ratingScore) + (W_Fresh * freshnessScore)
+	// 2. Distance: linear fade to 0 at distanceHorizonKm
+	var distanceScore interface{} = 0.0
+	if q.HasGeo {
+		distanceScore = bson.M{"$max": []interface{}{0, bson.M{"$divide": []interface{}{
+			bson.M{"$subtract": []interface{}{distanceHorizonKm, bson.M{"$ifNull": []interface{}{"$distance_km", distanceHorizonKm}}}},
+			distanceHorizonKm,
+		}}}}
 	}
 
-	// Sort Descending by Score
-	sort.Slice(posts, func(i, j int) bool {
-		return posts[i].Score > posts[j].Score
-	})
+	// 3. Rating: normalized 0-5 -> 0-1
+	rating := bson.M{"$divide": []interface{}{bson.M{"$ifNull": []interface{}{"$sender_rating", 0}}, 5}}
 
-	c.JSON(http.StatusOK, posts)
+	// 4. Freshness: hours since posted, relative to AsOf. Drops over days.
+	hours := bson.M{"$divide": []interface{}{bson.M{"$subtract": []interface{}{q.AsOf, "$created_at"}}, 3600000.0}}
+	freshness := bson.M{"$divide": []interface{}{1, bson.M{"$add": []interface{}{1, bson.M{"$divide": []interface{}{hours, 24}}}}}}
+
+	features := append([]feedFeature{
+		{Name: "relevance", Weight: W_Rel, Expr: relevance},
+		{Name: "distance", Weight: W_Dist, Expr: distanceScore},
+		{Name: "rating", Weight: W_Rate, Expr: rating},
+		{Name: "freshness", Weight: W_Fresh, Expr: freshness},
+	}, extra...)
+
+	values := bson.M{}
+	var scoreTerms []interface{}
+	for _, f := range features {
+		values["rank_features."+f.Name] = f.Expr
+		scoreTerms = append(scoreTerms, bson.M{"$multiply": []interface{}{f.Weight, "$rank_features." + f.Name}})
+	}
+
+	pipeline := []bson.M{{"$match": match}}
+	if q.HasGeo {
+		pipeline = append(pipeline, bson.M{"$addFields": bson.M{"distance_km": utils.HaversineExpr("location", q.Lat, q.Lon)}})
+	}
+	pipeline = append(pipeline,
+		bson.M{"$addFields": values},
+		bson.M{"$addFields": bson.M{"score": bson.M{"$add": scoreTerms}}},
+	)
+
+	if after != nil {
+		pipeline = append(pipeline, bson.M{"$match": bson.M{"$or": []bson.M{
+			{"score": bson.M{"$lt": after.Key}},
+			{"score": after.Key, "_id": bson.M{"$lt": after.ID}},
+		}}})
+	}
+
+	return append(pipeline,
+		bson.M{"$sort": bson.D{{Key: "score", Value: -1}, {Key: "_id", Value: -1}}},
+		bson.M{"$limit": limit},
+	)
 }
 
-func contains(text, sub string) bool {
-	return strings.Contains(strings.ToLower(text), strings.ToLower(sub))
+// attachViewerState fills reaction counters, the viewer's own reaction and poll visibility
+func attachViewerState(ctx context.Context, posts []community_models.Post, viewerID primitive.ObjectID) {
+	ids := make([]primitive.ObjectID, len(posts))
+	for i := range posts {
+		ids[i] = posts[i].ID
+	}
+	counts := social.ReactionCounts(ctx, ids)
+	mine := social.ViewerReactions(ctx, viewerID, ids)
+	for i := range posts {
+		posts[i].ReactionCounts = counts[posts[i].ID]
+		posts[i].LikesCount = counts[posts[i].ID][social_models.ReactionLike]
+		posts[i].MyReaction = mine[posts[i].ID]
+	}
+	applyPollVisibility(ctx, posts, viewerID)
+}
+
+// splitTags parses a comma separated tag list into normalized tags
+func splitTags(raw string) []string {
+	var tags []string
+	for _, t := range strings.Split(raw, ",") {
+		if t = NormalizeTag(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// createPostIndexes backs the geo, text and tag filters of the feed.
+// One revision per post version makes concurrent edits collide on the revision insert.
+func createPostIndexes() {
+	if !database.WaitForClient(30 * time.Second) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	coll := database.GetCollection("community_posts")
+	_, _ = coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "location", Value: "2dsphere"}}},
+		{Keys: bson.D{{Key: "content", Value: "text"}, {Key: "tags", Value: "text"}}},
+		{Keys: bson.D{{Key: "tags", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+	})
+	_, _ = database.GetCollection("community_post_revisions").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "post_id", Value: 1}, {Key: "version", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
 }
 
 func RegisterRoutes(router *gin.RouterGroup) {
 	commGroup := router.Group("/api/community")
 	{
 		commGroup.POST("/create", CreatePost)
-		commGroup.GET("/feed", GetFeed)
-		commGroup.DELETE("/delete/:id", DeletePost)
+		commGroup.GET("/feed", auth.OptionalAuth(), GetFeed)
+		commGroup.GET("/v2/feed", auth.OptionalAuth(), GetFeedPage)
+		commGroup.DELETE("/delete/:id", auth.RequireAuth(), DeletePost)
 	}
 }
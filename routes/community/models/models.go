@@ -6,21 +6,49 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Post Types
+const (
+	PostTypePost     = "post" // Default; older posts have no post_type
+	PostTypeQuestion = "question"
+	PostTypePoll     = "poll"
+)
+
 // Post Structure
 type Post struct {
 	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	PostType     string             `bson:"post_type,omitempty" json:"post_type,omitempty"`
 	SenderID     primitive.ObjectID `bson:"sender_id" json:"sender_id"`
 	SenderName   string             `bson:"sender_name" json:"sender_name"`
 	SenderRating float64            `bson:"sender_rating" json:"sender_rating"` // Cached rating of sender
 
-	Content  string   `bson:"content" json:"content"`
-	MediaURL string   `bson:"media_url,omitempty" json:"media_url,omitempty"`
-	Tags     []string `bson:"tags,omitempty" json:"tags,omitempty"`
+	Content     string       `bson:"content" json:"content"`
+	MediaURL    string       `bson:"media_url,omitempty" json:"media_url,omitempty"` // Legacy single attachment
+	Attachments []Attachment `bson:"attachments,omitempty" json:"attachments,omitempty"`
+	Tags        []string     `bson:"tags,omitempty" json:"tags,omitempty"`
+	TopicTags   []string     `bson:"topic_tags" json:"-"`                          // The author's explicit tags; Tags adds the content's #hashtags
+	Language    string       `bson:"language,omitempty" json:"language,omitempty"` // Sender's regional language at posting time
 
 	Location *GeoJSON `bson:"location,omitempty" json:"location,omitempty"`
 
-	LikesCount int     `bson:"likes_count" json:"likes_count"`
-	Score      float64 `bson:"score,omitempty" json:"score,omitempty"` // Computed score for feed
+	Question *QuestionInfo `bson:"question,omitempty" json:"question,omitempty"` // Set when PostType is question
+	Poll     *PollInfo     `bson:"poll,omitempty" json:"poll,omitempty"`         // Set when PostType is poll
+
+	// Editing & moderation
+	EditCount int                `bson:"edit_count,omitempty" json:"edit_count,omitempty"`
+	EditedAt  *time.Time         `bson:"edited_at,omitempty" json:"edited_at,omitempty"`
+	IsDeleted bool               `bson:"is_deleted,omitempty" json:"-"` // Soft delete; admins can restore
+	DeletedAt *time.Time         `bson:"deleted_at,omitempty" json:"-"`
+	DeletedBy primitive.ObjectID `bson:"deleted_by,omitempty" json:"-"`
+	Pin       *PinInfo           `bson:"pin,omitempty" json:"pin,omitempty"`
+
+	LikesCount int     `bson:"-" json:"likes_count"`                               // Filled per request from reaction_counts
+	Score      float64 `bson:"score,omitempty" json:"score,omitempty"`             // Computed score for feed
+	DistanceKm float64 `bson:"distance_km,omitempty" json:"distance_km,omitempty"` // Computed distance from the viewer
+
+	// Filled per request, never stored
+	ReactionCounts map[string]int     `bson:"-" json:"reaction_counts,omitempty"`
+	MyReaction     string             `bson:"-" json:"my_reaction,omitempty"`
+	RankFeatures   map[string]float64 `bson:"rank_features,omitempty" json:"-"` // Feed score inputs, logged for tuning
 
 	CreatedAt time.Time `bson:"created_at" json:"created_at"`
 	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
@@ -31,3 +59,180 @@ type GeoJSON struct {
 	Type        string    `bson:"type" json:"type"`
 	Coordinates []float64 `bson:"coordinates" json:"coordinates"` // [lon, lat]
 }
+
+// Attachment types
+const (
+	AttachmentImage = "image"
+	AttachmentVideo = "video"
+	AttachmentAudio = "audio" // Voice notes
+)
+
+// Attachment limits
+const (
+	MaxAttachments   = 10
+	MaxCaptionLength = 280
+	DefaultPinHours  = 72
+	MaxPinHours      = 24 * 30
+	MaxPinnedPerFeed = 3
+)
+
+// Attachment is one media item of a post's gallery
+type Attachment struct {
+	Type    string `bson:"type" json:"type"` // image, video or audio
+	URL     string `bson:"url" json:"url"`
+	Caption string `bson:"caption,omitempty" json:"caption,omitempty"`
+}
+
+// IsValidAttachmentType reports whether t is a supported attachment type
+func IsValidAttachmentType(t string) bool {
+	return t == AttachmentImage || t == AttachmentVideo || t == AttachmentAudio
+}
+
+// PinInfo pins a post to the top of the feed for viewers inside a region.
+// A zero RadiusKm pins the post for everyone.
+type PinInfo struct {
+	Region    string             `bson:"region,omitempty" json:"region,omitempty"` // Display label, e.g. "Nashik district"
+	Center    *GeoJSON           `bson:"center,omitempty" json:"center,omitempty"`
+	RadiusKm  float64            `bson:"radius_km,omitempty" json:"radius_km,omitempty"`
+	PinnedBy  primitive.ObjectID `bson:"pinned_by" json:"-"`
+	PinnedAt  time.Time          `bson:"pinned_at" json:"pinned_at"`
+	ExpiresAt time.Time          `bson:"expires_at" json:"expires_at"`
+}
+
+// PostRevision is a snapshot of a post before an edit (collection: community_post_revisions)
+type PostRevision struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	PostID      primitive.ObjectID `bson:"post_id" json:"post_id"`
+	Version     int                `bson:"version" json:"version"`     // 0 = original post
+	EditorID    primitive.ObjectID `bson:"editor_id" json:"editor_id"` // Who replaced this version
+	Content     string             `bson:"content" json:"content"`
+	MediaURL    string             `bson:"media_url,omitempty" json:"media_url,omitempty"`
+	Attachments []Attachment       `bson:"attachments,omitempty" json:"attachments,omitempty"`
+	Tags        []string           `bson:"tags,omitempty" json:"tags,omitempty"`
+	ReplacedAt  time.Time          `bson:"replaced_at" json:"replaced_at"`
+}
+
+// FeedImpression records that a viewer has seen a post (collection: feed_impressions)
+type FeedImpression struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ViewerID primitive.ObjectID `bson:"viewer_id" json:"viewer_id"`
+	PostID   primitive.ObjectID `bson:"post_id" json:"post_id"`
+	SeenAt   time.Time          `bson:"seen_at" json:"seen_at"`
+}
+
+// FeedRankLog captures why a post was ranked where it was (collection: feed_rank_logs)
+type FeedRankLog struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ViewerID  primitive.ObjectID `bson:"viewer_id" json:"viewer_id"`
+	PostID    primitive.ObjectID `bson:"post_id" json:"post_id"`
+	Mode      string             `bson:"mode" json:"mode"`
+	Position  int                `bson:"position" json:"position"` // Rank within the page
+	FirstPage bool               `bson:"first_page" json:"first_page"`
+	Features  map[string]float64 `bson:"features" json:"features"`
+	Score     float64            `bson:"score" json:"score"`
+	AsOf      time.Time          `bson:"as_of" json:"as_of"`
+	ServedAt  time.Time          `bson:"served_at" json:"served_at"`
+}
+
+// QuestionInfo holds the Q&A state of a question post
+type QuestionInfo struct {
+	ExpertType       string             `bson:"expert_type,omitempty" json:"expert_type,omitempty"` // Consultant type best suited to answer (e.g. "Doctor")
+	AnswerCount      int                `bson:"answer_count" json:"answer_count"`
+	AcceptedAnswerID primitive.ObjectID `bson:"accepted_answer_id,omitempty" json:"accepted_answer_id,omitempty"`
+	AcceptedAt       *time.Time         `bson:"accepted_at,omitempty" json:"accepted_at,omitempty"`
+}
+
+// Answer to a question post (collection: community_answers)
+type Answer struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	QuestionID     primitive.ObjectID `bson:"question_id" json:"question_id"`
+	AuthorID       primitive.ObjectID `bson:"author_id" json:"author_id"`
+	AuthorName     string             `bson:"author_name" json:"author_name"`
+	AuthorType     string             `bson:"author_type" json:"author_type"`                             // "user" or "consultant"
+	ConsultantType string             `bson:"consultant_type,omitempty" json:"consultant_type,omitempty"` // e.g. "Doctor"
+	IsExpert       bool               `bson:"is_expert" json:"is_expert"`                                 // Verified consultant at answer time
+
+	Content  string `bson:"content" json:"content"`
+	MediaURL string `bson:"media_url,omitempty" json:"media_url,omitempty"`
+
+	IsAccepted bool      `bson:"is_accepted" json:"is_accepted"`
+	CreatedAt  time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// Answer author types
+const (
+	AuthorTypeUser       = "user"
+	AuthorTypeConsultant = "consultant"
+)
+
+// Reputation points a consultant earns when the asker accepts their answer
+const ReputationPerAcceptedAnswer = 10
+
+// Poll limits
+const (
+	MinPollOptions      = 2
+	MaxPollOptions      = 10
+	MaxPollOptionLength = 100
+	MaxPollHours        = 24 * 90
+)
+
+// PollInfo holds the options and running tallies of a poll post
+type PollInfo struct {
+	Options          []PollOption `bson:"options" json:"options"`
+	MultipleChoice   bool         `bson:"multiple_choice" json:"multiple_choice"`
+	ResultsAfterVote bool         `bson:"results_after_vote" json:"results_after_vote"` // Hide tallies until the viewer votes
+	ExpiresAt        *time.Time   `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+	VoterCount       int          `bson:"voter_count" json:"voter_count"`
+
+	// Filled per request, never stored
+	MyVotes       []int `bson:"-" json:"my_votes,omitempty"`
+	ResultsHidden bool  `bson:"-" json:"results_hidden,omitempty"`
+}
+
+// PollOption is one choice; ID is its index in Options
+type PollOption struct {
+	ID    int    `bson:"id" json:"id"`
+	Text  string `bson:"text" json:"text"`
+	Votes int    `bson:"votes" json:"votes"`
+}
+
+// IsClosed reports whether the poll stopped accepting votes at t
+func (p *PollInfo) IsClosed(t time.Time) bool {
+	return p.ExpiresAt != nil && !t.Before(*p.ExpiresAt)
+}
+
+// PollVote is one user's ballot (collection: community_poll_votes)
+type PollVote struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	PollID    primitive.ObjectID `bson:"poll_id" json:"poll_id"`
+	VoterID   primitive.ObjectID `bson:"voter_id" json:"voter_id"`
+	OptionIDs []int              `bson:"option_ids" json:"option_ids"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// TagFollow subscribes a user to a topic (collection: tag_follows)
+type TagFollow struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Tag       string             `bson:"tag" json:"tag"` // Normalized
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// TrendingSnapshot is the ranked topic list for one window and region (collection: trending_topics)
+type TrendingSnapshot struct {
+	ID         string          `bson:"_id" json:"-"` // "<window>|<region>"
+	Window     string          `bson:"window" json:"window"`
+	Region     string          `bson:"region" json:"region"` // "global" or a grid cell key
+	Topics     []TrendingTopic `bson:"topics" json:"topics"`
+	ComputedAt time.Time       `bson:"computed_at" json:"computed_at"`
+}
+
+// TrendingTopic is one tag's activity within a trending window
+type TrendingTopic struct {
+	Tag       string  `bson:"tag" json:"tag"`
+	Score     float64 `bson:"score" json:"score"`
+	Posts     int     `bson:"posts" json:"posts"`
+	Reactions int     `bson:"reactions" json:"reactions"`
+	Growth    float64 `bson:"growth" json:"growth"` // Change vs the previous window of the same length
+}
@@ -0,0 +1,206 @@
+package community
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"Agromi/database"
+	"Agromi/routes/auth"
+	community_models "Agromi/routes/community/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	go createPersonalIndexes()
+}
+
+// Feed modes
+const (
+	feedModeDefault  = "default"
+	feedModePersonal = "personal"
+)
+
+// Personalization weights (added on top of the base feed weights)
+const (
+	W_Follow = 0.8 // Author is followed by the viewer
+	W_Crop   = 0.5 // Post tags include one of the viewer's crops
+	W_Lang   = 0.3 // Post is in the viewer's regional language
+	W_Tag    = 0.6 // Post carries a topic the viewer follows
+)
+
+const (
+	seenWindow      = 30 * 24 * time.Hour // Impressions older than this no longer suppress posts
+	maxSeenExcluded = 2000
+	maxFollowees    = 1000
+	maxSeenPerCall  = 100
+)
+
+// personalization holds the viewer-specific parts of the personalized feed
+type personalization struct {
+	Features []feedFeature
+	Seen     []primitive.ObjectID
+}
+
+// loadPersonalization builds the boosts (followed authors, crops, topics, language) and seen-post exclusions for a viewer
+func loadPersonalization(ctx context.Context, viewerID primitive.ObjectID, asOf time.Time) (personalization, error) {
+	var p personalization
+
+	var viewer auth.User
+	if err := database.GetCollection("users").FindOne(ctx, bson.M{"_id": viewerID}).Decode(&viewer); err != nil {
+		return p, err
+	}
+
+	// Followed authors
+	followees := []primitive.ObjectID{}
+	followOpts := options.Find().SetProjection(bson.M{"followee_id": 1}).SetLimit(maxFollowees)
+	if cursor, err := database.GetCollection("follows").Find(ctx, bson.M{"follower_id": viewerID}, followOpts); err == nil {
+		var follows []struct {
+			FolloweeID primitive.ObjectID `bson:"followee_id"`
+		}
+		if cursor.All(ctx, &follows) == nil {
+			for _, f := range follows {
+				followees = append(followees, f.FolloweeID)
+			}
+		}
+	}
+
+	// Crop names compared against lower-cased post tags (normalized too, for newer posts)
+	crops := []string{}
+	for _, crop := range viewer.Crops {
+		if name := strings.ToLower(strings.TrimSpace(crop.Name)); name != "" {
+			crops = append(crops, name)
+			if tag := NormalizeTag(name); tag != name {
+				crops = append(crops, tag)
+			}
+		}
+	}
+	tags := followedTags(ctx, viewerID)
+	lowerTags := bson.M{"$map": bson.M{"input": bson.M{"$ifNull": []interface{}{"$tags", []string{}}}, "in": bson.M{"$toLower": "$$this"}}}
+
+	flag := func(cond interface{}) bson.M { return bson.M{"$cond": []interface{}{cond, 1, 0}} }
+
+	p.Features = []feedFeature{
+		{Name: "followed_author", Weight: W_Follow, Expr: flag(bson.M{"$in": []interface{}{"$sender_id", followees}})},
+		{Name: "crop_match", Weight: W_Crop, Expr: flag(bson.M{"$gt": []interface{}{bson.M{"$size": bson.M{"$setIntersection": []interface{}{lowerTags, crops}}}, 0}})},
+		{Name: "followed_tag", Weight: W_Tag, Expr: flag(bson.M{"$gt": []interface{}{bson.M{"$size": bson.M{"$setIntersection": []interface{}{lowerTags, tags}}}, 0}})},
+		{Name: "language_match", Weight: W_Lang, Expr: flag(bson.M{"$and": []interface{}{
+			viewer.RegionalLanguage != "",
+			bson.M{"$eq": []interface{}{"$language", viewer.RegionalLanguage}},
+		}})},
+	}
+
+	// Seen posts. Only impressions recorded before the first page count, so pages stay stable.
+	seenFilter := bson.M{"viewer_id": viewerID, "seen_at": bson.M{"$gte": asOf.Add(-seenWindow), "$lte": asOf}}
+	seenOpts := options.Find().SetProjection(bson.M{"post_id": 1}).SetSort(bson.M{"seen_at": -1}).SetLimit(maxSeenExcluded)
+	if cursor, err := database.GetCollection("feed_impressions").Find(ctx, seenFilter, seenOpts); err == nil {
+		var seen []community_models.FeedImpression
+		if cursor.All(ctx, &seen) == nil {
+			for _, s := range seen {
+				p.Seen = append(p.Seen, s.PostID)
+			}
+		}
+	}
+
+	return p, nil
+}
+
+// logRanking stores the score inputs of every served post so weights can be tuned offline
+func logRanking(ctx context.Context, viewerID primitive.ObjectID, mode string, asOf time.Time, firstPage bool, posts []community_models.Post) {
+	if len(posts) == 0 {
+		return
+	}
+
+	now := time.Now()
+	docs := make([]interface{}, 0, len(posts))
+	for i, p := range posts {
+		docs = append(docs, community_models.FeedRankLog{
+			ID:        primitive.NewObjectID(),
+			ViewerID:  viewerID,
+			PostID:    p.ID,
+			Mode:      mode,
+			Position:  i,
+			FirstPage: firstPage,
+			Features:  p.RankFeatures,
+			Score:     p.Score,
+			AsOf:      asOf,
+			ServedAt:  now,
+		})
+	}
+
+	// Best effort: logging must never fail the feed
+	_, _ = database.GetCollection("feed_rank_logs").InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+}
+
+// MarkSeen records that the signed-in viewer has seen posts so the personalized feed stops showing them
+func MarkSeen(c *gin.Context) {
+	var body struct {
+		PostIDs []string `json:"post_ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(body.PostIDs) > maxSeenPerCall {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Too many post_ids"})
+		return
+	}
+
+	viewerID := auth.CurrentUserID(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	var models []mongo.WriteModel
+	for _, idStr := range body.PostIDs {
+		postID, err := primitive.ObjectIDFromHex(idStr)
+		if err != nil {
+			continue
+		}
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"viewer_id": viewerID, "post_id": postID}).
+			SetUpdate(bson.M{"$set": bson.M{"seen_at": now}, "$setOnInsert": bson.M{"_id": primitive.NewObjectID()}}).
+			SetUpsert(true))
+	}
+	if len(models) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No valid post_ids"})
+		return
+	}
+
+	if _, err := database.GetCollection("feed_impressions").BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record impressions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Marked as seen", "count": len(models)})
+}
+
+// createPersonalIndexes keeps impression lookups fast and expires old impressions and logs
+func createPersonalIndexes() {
+	if !database.WaitForClient(30 * time.Second) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	impressions := database.GetCollection("feed_impressions")
+	_, _ = impressions.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "viewer_id", Value: 1}, {Key: "post_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "viewer_id", Value: 1}, {Key: "seen_at", Value: -1}}},
+		{Keys: bson.D{{Key: "seen_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(int32(seenWindow.Seconds()))},
+	})
+
+	logs := database.GetCollection("feed_rank_logs")
+	_, _ = logs.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "viewer_id", Value: 1}, {Key: "served_at", Value: -1}}},
+		{Keys: bson.D{{Key: "served_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(90 * 24 * 3600)},
+	})
+}
@@ -0,0 +1,71 @@
+package community
+
+import (
+	"context"
+	"time"
+
+	"Agromi/database"
+	community_models "Agromi/routes/community/models"
+	"Agromi/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	go createPinIndexes()
+}
+
+// loadPinnedPosts returns the active pins that apply to the viewer's location, newest pin first.
+// Regional pins need lat/lon; pins without a radius apply everywhere.
+func loadPinnedPosts(ctx context.Context, q feedQuery) []community_models.Post {
+	filter := bson.M{
+		"pin.expires_at": bson.M{"$gt": time.Now()},
+		"is_deleted":     bson.M{"$ne": true},
+		"created_at":     bson.M{"$lte": q.AsOf},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "pin.pinned_at", Value: -1}}).SetLimit(100)
+
+	cursor, err := database.GetCollection("community_posts").Find(ctx, filter, opts)
+	if err != nil {
+		return nil
+	}
+	var candidates []community_models.Post
+	if err := cursor.All(ctx, &candidates); err != nil {
+		return nil
+	}
+
+	var pinned []community_models.Post
+	for _, p := range candidates {
+		if p.Pin.RadiusKm > 0 {
+			if !q.HasGeo || p.Pin.Center == nil || len(p.Pin.Center.Coordinates) != 2 {
+				continue
+			}
+			d := utils.Haversine(q.Lat, q.Lon, p.Pin.Center.Coordinates[1], p.Pin.Center.Coordinates[0])
+			if d > p.Pin.RadiusKm {
+				continue
+			}
+		}
+		pinned = append(pinned, p)
+		if len(pinned) == community_models.MaxPinnedPerFeed {
+			break
+		}
+	}
+	return pinned
+}
+
+// createPinIndexes backs the active pin lookup
+func createPinIndexes() {
+	if !database.WaitForClient(30 * time.Second) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, _ = database.GetCollection("community_posts").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "pin.expires_at", Value: 1}},
+		Options: options.Index().SetSparse(true),
+	})
+}
@@ -0,0 +1,237 @@
+package community
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"Agromi/database"
+	"Agromi/routes/auth"
+	community_models "Agromi/routes/community/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	go createPollIndexes()
+}
+
+// pollInput is the poll part of a CreatePost request
+type pollInput struct {
+	Options          []string `json:"options"`
+	MultipleChoice   bool     `json:"multiple_choice"`
+	ResultsAfterVote bool     `json:"results_after_vote"`
+	ExpiresInHours   int      `json:"expires_in_hours"` // 0 = never expires
+}
+
+// build validates the input and returns the poll to store
+func (in *pollInput) build(now time.Time) (*community_models.PollInfo, error) {
+	if in == nil {
+		return nil, errors.New("poll required for post_type poll")
+	}
+	if len(in.Options) < community_models.MinPollOptions || len(in.Options) > community_models.MaxPollOptions {
+		return nil, fmt.Errorf("poll needs %d to %d options", community_models.MinPollOptions, community_models.MaxPollOptions)
+	}
+
+	poll := &community_models.PollInfo{
+		MultipleChoice:   in.MultipleChoice,
+		ResultsAfterVote: in.ResultsAfterVote,
+	}
+	seen := map[string]bool{}
+	for i, text := range in.Options {
+		text = strings.TrimSpace(text)
+		if text == "" || len([]rune(text)) > community_models.MaxPollOptionLength {
+			return nil, fmt.Errorf("option %d must be 1-%d characters", i, community_models.MaxPollOptionLength)
+		}
+		if seen[strings.ToLower(text)] {
+			return nil, fmt.Errorf("duplicate option %q", text)
+		}
+		seen[strings.ToLower(text)] = true
+		poll.Options = append(poll.Options, community_models.PollOption{ID: i, Text: text})
+	}
+
+	if in.ExpiresInHours < 0 || in.ExpiresInHours > community_models.MaxPollHours {
+		return nil, fmt.Errorf("expires_in_hours must be between 0 and %d", community_models.MaxPollHours)
+	}
+	if in.ExpiresInHours > 0 {
+		expires := now.Add(time.Duration(in.ExpiresInHours) * time.Hour)
+		poll.ExpiresAt = &expires
+	}
+	return poll, nil
+}
+
+// VotePoll records the signed-in user's ballot. Each user votes once; tallies update atomically.
+func VotePoll(c *gin.Context) {
+	var body struct {
+		PollID    string `json:"poll_id" binding:"required"`
+		OptionIDs []int  `json:"option_ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pollID, err := primitive.ObjectIDFromHex(body.PollID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid poll_id"})
+		return
+	}
+	voterID := auth.CurrentUserID(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	posts := database.GetCollection("community_posts")
+	var post community_models.Post
+	err = posts.FindOne(ctx, bson.M{"_id": pollID, "post_type": community_models.PostTypePoll, "is_deleted": bson.M{"$ne": true}}).Decode(&post)
+	if err != nil || post.Poll == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Poll not found"})
+		return
+	}
+	now := time.Now()
+	if post.Poll.IsClosed(now) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Poll has closed"})
+		return
+	}
+
+	// Dedupe and validate the chosen options
+	chosen := map[int]bool{}
+	for _, id := range body.OptionIDs {
+		if id < 0 || id >= len(post.Poll.Options) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Unknown option %d", id)})
+			return
+		}
+		chosen[id] = true
+	}
+	if len(chosen) == 0 || (!post.Poll.MultipleChoice && len(chosen) > 1) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Choose exactly one option"})
+		return
+	}
+	optionIDs := make([]int, 0, len(chosen))
+	for id := range chosen {
+		optionIDs = append(optionIDs, id)
+	}
+	sort.Ints(optionIDs)
+
+	// The unique (poll_id, voter_id) index enforces one ballot per user
+	vote := community_models.PollVote{
+		ID:        primitive.NewObjectID(),
+		PollID:    pollID,
+		VoterID:   voterID,
+		OptionIDs: optionIDs,
+		CreatedAt: now,
+	}
+	if _, err := database.GetCollection("community_poll_votes").InsertOne(ctx, vote); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "You have already voted"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record vote"})
+		return
+	}
+
+	inc := bson.M{"poll.voter_count": 1}
+	for _, id := range optionIDs {
+		inc[fmt.Sprintf("poll.options.%d.votes", id)] = 1
+	}
+	if err := posts.FindOneAndUpdate(ctx, bson.M{"_id": pollID}, bson.M{"$inc": inc},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&post); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tallies"})
+		return
+	}
+
+	post.Poll.MyVotes = optionIDs
+	c.JSON(http.StatusOK, gin.H{"message": "Vote recorded", "poll": post.Poll})
+}
+
+// GetPoll returns a poll with its live tallies.
+// Tallies are hidden from viewers who have not voted when the poll asks for it, until it closes;
+// the viewer is the signed-in user, anonymous viewers have not voted.
+func GetPoll(c *gin.Context) {
+	pollID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	viewerID := auth.CurrentUserID(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var post community_models.Post
+	err = database.GetCollection("community_posts").FindOne(ctx, bson.M{"_id": pollID, "post_type": community_models.PostTypePoll, "is_deleted": bson.M{"$ne": true}}).Decode(&post)
+	if err != nil || post.Poll == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Poll not found"})
+		return
+	}
+
+	posts := []community_models.Post{post}
+	applyPollVisibility(ctx, posts, viewerID)
+	c.JSON(http.StatusOK, posts[0])
+}
+
+// applyPollVisibility fills the viewer's ballot on poll posts and blanks tallies the viewer may not see yet
+func applyPollVisibility(ctx context.Context, posts []community_models.Post, viewerID primitive.ObjectID) {
+	var pollIDs []primitive.ObjectID
+	for i := range posts {
+		if posts[i].Poll != nil {
+			pollIDs = append(pollIDs, posts[i].ID)
+		}
+	}
+	if len(pollIDs) == 0 {
+		return
+	}
+
+	myVotes := map[primitive.ObjectID][]int{}
+	if !viewerID.IsZero() {
+		cursor, err := database.GetCollection("community_poll_votes").Find(ctx, bson.M{"poll_id": bson.M{"$in": pollIDs}, "voter_id": viewerID})
+		if err == nil {
+			var votes []community_models.PollVote
+			if cursor.All(ctx, &votes) == nil {
+				for _, v := range votes {
+					myVotes[v.PollID] = v.OptionIDs
+				}
+			}
+		}
+	}
+
+	now := time.Now()
+	for i := range posts {
+		poll := posts[i].Poll
+		if poll == nil {
+			continue
+		}
+		voted, ok := myVotes[posts[i].ID]
+		poll.MyVotes = voted
+		if poll.ResultsAfterVote && !ok && !poll.IsClosed(now) {
+			poll.ResultsHidden = true
+			poll.VoterCount = 0
+			for j := range poll.Options {
+				poll.Options[j].Votes = 0
+			}
+		}
+	}
+}
+
+// createPollIndexes enforces one ballot per user and backs the viewer lookup
+func createPollIndexes() {
+	if !database.WaitForClient(30 * time.Second) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, _ = database.GetCollection("community_poll_votes").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "poll_id", Value: 1}, {Key: "voter_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "voter_id", Value: 1}}},
+	})
+}
@@ -2,28 +2,38 @@ package community
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"Agromi/database"
+	"Agromi/routes/auth"
 	community_models "Agromi/routes/community/models"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // CreatePost
 func CreatePost(c *gin.Context) {
 	var body struct {
-		SenderID     string   `json:"sender_id" binding:"required"`
-		SenderName   string   `json:"sender_name" binding:"required"`
-		Content      string   `json:"content" binding:"required"`
-		MediaURL     string   `json:"media_url"`
-		Tags         []string `json:"tags"`
-		Lat          float64  `json:"lat" binding:"required"`
-		Lon          float64  `json:"lon" binding:"required"`
-		SenderRating float64  `json:"sender_rating"` // Optional: Client can send or we fetch
+		SenderID     string                        `json:"sender_id" binding:"required"`
+		SenderName   string                        `json:"sender_name" binding:"required"`
+		Content      string                        `json:"content" binding:"required"`
+		MediaURL     string                        `json:"media_url"`
+		Attachments  []community_models.Attachment `json:"attachments"`
+		Tags         []string                      `json:"tags"`
+		Lat          float64                       `json:"lat" binding:"required"`
+		Lon          float64                       `json:"lon" binding:"required"`
+		SenderRating float64                       `json:"sender_rating"` // Optional: Client can send or we fetch
+		PostType     string                        `json:"post_type"`     // "post" (default), "question" or "poll"
+		ExpertType   string                        `json:"expert_type"`   // Questions: consultant type to route to
+		Poll         *pollInput                    `json:"poll"`          // Polls: options and settings
 	}
 
 	if err := c.ShouldBindJSON(&body); err != nil {
@@ -33,9 +43,37 @@ func CreatePost(c *gin.Context) {
 
 	senderObjID, _ := primitive.ObjectIDFromHex(body.SenderID)
 
+	attachments, err := cleanAttachments(body.Attachments)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if body.PostType == "" {
+		body.PostType = community_models.PostTypePost
+	}
+	if body.PostType != community_models.PostTypePost && body.PostType != community_models.PostTypeQuestion && body.PostType != community_models.PostTypePoll {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "post_type must be post, question or poll"})
+		return
+	}
+
+	var poll *community_models.PollInfo
+	if body.PostType == community_models.PostTypePoll {
+		if poll, err = body.Poll.build(time.Now()); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	// Language drives the personalized feed's language boost
+	var sender struct {
+		RegionalLanguage string `bson:"regional_language"`
+	}
+	_ = database.GetCollection("users").FindOne(ctx, bson.M{"_id": senderObjID}, options.FindOne().SetProjection(bson.M{"regional_language": 1})).Decode(&sender)
+
 	post := community_models.Post{
 		ID:           primitive.NewObjectID(),
 		SenderID:     senderObjID,
@@ -43,18 +81,25 @@ func CreatePost(c *gin.Context) {
 		SenderRating: body.SenderRating,
 		Content:      body.Content,
 		MediaURL:     body.MediaURL,
-		Tags:         body.Tags,
+		Attachments:  attachments,
+		Tags:         postTags(body.Tags, body.Content),
+		TopicTags:    postTags(body.Tags, ""),
+		Language:     sender.RegionalLanguage,
 		Location: &community_models.GeoJSON{
 			Type:        "Point",
 			Coordinates: []float64{body.Lon, body.Lat},
 		},
-		LikesCount: 0,
-		CreatedAt:  time.Now(),
-		UpdatedAt:  time.Now(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	post.PostType = body.PostType
+	if post.PostType == community_models.PostTypeQuestion {
+		post.Question = &community_models.QuestionInfo{ExpertType: body.ExpertType}
 	}
+	post.Poll = poll
 
 	coll := database.GetCollection("community_posts")
-	_, err := coll.InsertOne(ctx, post)
+	_, err = coll.InsertOne(ctx, post)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create post"})
 		return
@@ -63,23 +108,211 @@ func CreatePost(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"message": "Post created", "id": post.ID})
 }
 
-// DeletePost
+// EditPost lets the signed-in author change content, tags and attachments.
+// The previous version is kept in community_post_revisions.
+func EditPost(c *gin.Context) {
+	postID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var body struct {
+		Content     *string                        `json:"content"`
+		Tags        *[]string                      `json:"tags"`
+		MediaURL    *string                        `json:"media_url"`
+		Attachments *[]community_models.Attachment `json:"attachments"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	senderID := auth.CurrentUserID(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	coll := database.GetCollection("community_posts")
+	var post community_models.Post
+	if err := coll.FindOne(ctx, bson.M{"_id": postID, "is_deleted": bson.M{"$ne": true}}).Decode(&post); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+	if post.SenderID != senderID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the author can edit this post"})
+		return
+	}
+
+	update := bson.M{}
+	if body.Content != nil {
+		if strings.TrimSpace(*body.Content) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "content cannot be empty"})
+			return
+		}
+		update["content"] = *body.Content
+	}
+	// Hashtags in the content are part of the tags, so retag whenever either changes.
+	// Hashtags removed from the content must drop out, so start from the explicit tags only.
+	if body.Tags != nil || body.Content != nil {
+		explicit, content := topicTags(post), post.Content
+		if body.Tags != nil {
+			explicit = postTags(*body.Tags, "")
+		}
+		if body.Content != nil {
+			content = *body.Content
+		}
+		update["topic_tags"] = explicit
+		update["tags"] = postTags(explicit, content)
+	}
+	if body.MediaURL != nil {
+		update["media_url"] = *body.MediaURL
+	}
+	if body.Attachments != nil {
+		attachments, err := cleanAttachments(*body.Attachments)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		update["attachments"] = attachments
+	}
+	if len(update) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to update"})
+		return
+	}
+
+	now := time.Now()
+	update["edited_at"] = now
+	update["updated_at"] = now
+
+	// The revision is saved first so an edit can never lose the previous version
+	revisions := database.GetCollection("community_post_revisions")
+	revision := community_models.PostRevision{
+		ID:          primitive.NewObjectID(),
+		PostID:      postID,
+		Version:     post.EditCount,
+		EditorID:    senderID,
+		Content:     post.Content,
+		MediaURL:    post.MediaURL,
+		Attachments: post.Attachments,
+		Tags:        post.Tags,
+		ReplacedAt:  now,
+	}
+	if _, err := revisions.InsertOne(ctx, revision); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Post was edited concurrently, reload and retry"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save revision"})
+		return
+	}
+
+	// edit_count in the filter makes concurrent edits fail instead of overwriting each other
+	res, err := coll.UpdateOne(ctx,
+		bson.M{"_id": postID, "edit_count": editCountFilter(post.EditCount)},
+		bson.M{"$set": update, "$inc": bson.M{"edit_count": 1}},
+	)
+	if err != nil || res.MatchedCount == 0 {
+		revisions.DeleteOne(ctx, bson.M{"_id": revision.ID})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update post"})
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": "Post was edited concurrently, reload and retry"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Post updated", "edit_count": post.EditCount + 1})
+}
+
+// editCountFilter matches the current edit_count; posts never edited have no field
+func editCountFilter(n int) interface{} {
+	if n == 0 {
+		return bson.M{"$in": []interface{}{0, nil}}
+	}
+	return n
+}
+
+// ListRevisions returns the edit history of a post, newest first
+func ListRevisions(c *gin.Context) {
+	postID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if n, _ := database.GetCollection("community_posts").CountDocuments(ctx, bson.M{"_id": postID, "is_deleted": bson.M{"$ne": true}}); n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "version", Value: -1}})
+	cursor, err := database.GetCollection("community_post_revisions").Find(ctx, bson.M{"post_id": postID}, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	revisions := []community_models.PostRevision{}
+	if err := cursor.All(ctx, &revisions); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error parsing revisions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// cleanAttachments validates attachment types, URLs and caption lengths
+func cleanAttachments(in []community_models.Attachment) ([]community_models.Attachment, error) {
+	if len(in) > community_models.MaxAttachments {
+		return nil, fmt.Errorf("at most %d attachments allowed", community_models.MaxAttachments)
+	}
+	out := make([]community_models.Attachment, 0, len(in))
+	for i, a := range in {
+		a.Type = strings.ToLower(strings.TrimSpace(a.Type))
+		a.URL = strings.TrimSpace(a.URL)
+		a.Caption = strings.TrimSpace(a.Caption)
+		if !community_models.IsValidAttachmentType(a.Type) {
+			return nil, fmt.Errorf("attachment %d: type must be image, video or audio", i)
+		}
+		if a.URL == "" {
+			return nil, fmt.Errorf("attachment %d: url required", i)
+		}
+		if utf8.RuneCountInString(a.Caption) > community_models.MaxCaptionLength {
+			return nil, fmt.Errorf("attachment %d: caption longer than %d characters", i, community_models.MaxCaptionLength)
+		}
+		out = append(out, a)
+	}
+	return out, nil
+}
+
+// DeletePost soft-deletes a post. Only the signed-in author may delete; admins can restore it.
 func DeletePost(c *gin.Context) {
-	id := c.Param("id")
-	postID, _ := primitive.ObjectIDFromHex(id)
+	postID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	senderID := auth.CurrentUserID(c)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	now := time.Now()
 	coll := database.GetCollection("community_posts")
-	res, err := coll.DeleteOne(ctx, bson.M{"_id": postID})
+	res, err := coll.UpdateOne(ctx,
+		bson.M{"_id": postID, "sender_id": senderID, "is_deleted": bson.M{"$ne": true}},
+		bson.M{"$set": bson.M{"is_deleted": true, "deleted_at": now, "deleted_by": senderID, "updated_at": now}},
+	)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete"})
 		return
 	}
-	if res.DeletedCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found or unauthorized"})
 		return
 	}
 
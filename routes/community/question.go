@@ -0,0 +1,331 @@
+package community
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"Agromi/database"
+	"Agromi/routes/auth"
+	community_models "Agromi/routes/community/models"
+	consultant_models "Agromi/routes/consultant/models"
+	"Agromi/routes/social"
+	"Agromi/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	go createQuestionIndexes()
+}
+
+const defaultQuestionRadiusKm = 50.0
+
+// AnswerQuestion adds the signed-in user's answer to a question post.
+// Answers from verified consultants are marked as expert answers.
+func AnswerQuestion(c *gin.Context) {
+	var body struct {
+		QuestionID string `json:"question_id" binding:"required"`
+		AuthorName string `json:"author_name" binding:"required"`
+		Content    string `json:"content" binding:"required"`
+		MediaURL   string `json:"media_url"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	questionID, err := primitive.ObjectIDFromHex(body.QuestionID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid question_id"})
+		return
+	}
+	authorID := auth.CurrentUserID(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	posts := database.GetCollection("community_posts")
+	var question community_models.Post
+	if err := posts.FindOne(ctx, bson.M{"_id": questionID, "post_type": community_models.PostTypeQuestion, "is_deleted": bson.M{"$ne": true}}).Decode(&question); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Question not found"})
+		return
+	}
+
+	answer := community_models.Answer{
+		ID:         primitive.NewObjectID(),
+		QuestionID: questionID,
+		AuthorID:   authorID,
+		AuthorName: body.AuthorName,
+		AuthorType: community_models.AuthorTypeUser,
+		Content:    body.Content,
+		MediaURL:   body.MediaURL,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+
+	// Consultant sessions: expert badge only while verified and not blocked
+	if auth.CurrentUserType(c) == auth.RoleConsultant {
+		var consultant consultant_models.Consultant
+		if err := database.GetCollection("consultants").FindOne(ctx, bson.M{"_id": authorID}).Decode(&consultant); err == nil {
+			answer.AuthorType = community_models.AuthorTypeConsultant
+			answer.ConsultantType = consultant.Type
+			answer.IsExpert = consultant.VerificationStatus == consultant_models.StatusVerified && !consultant.IsBlocked
+		}
+	}
+
+	if _, err := database.GetCollection("community_answers").InsertOne(ctx, answer); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post answer"})
+		return
+	}
+
+	posts.UpdateOne(ctx, bson.M{"_id": questionID}, bson.M{"$inc": bson.M{"question.answer_count": 1}})
+
+	if question.SenderID != authorID {
+		msg := body.AuthorName + " answered your question."
+		if answer.IsExpert {
+			msg = "An expert (" + answer.ConsultantType + ") answered your question."
+		}
+		social.CreateNotification(ctx, question.SenderID, "answer", msg, answer.ID)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Answer posted", "id": answer.ID, "is_expert": answer.IsExpert})
+}
+
+// ListAnswers returns the answers of a question: accepted first, then expert answers, then oldest first
+func ListAnswers(c *gin.Context) {
+	questionID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "is_accepted", Value: -1}, {Key: "is_expert", Value: -1}, {Key: "created_at", Value: 1}}).
+		SetLimit(100)
+	cursor, err := database.GetCollection("community_answers").Find(ctx, bson.M{"question_id": questionID}, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	answers := []community_models.Answer{}
+	if err := cursor.All(ctx, &answers); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error parsing answers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, answers)
+}
+
+// AcceptAnswer lets the signed-in asker mark one answer as accepted. Accepting a consultant's answer
+// adds to their reputation; switching the accepted answer moves the points.
+func AcceptAnswer(c *gin.Context) {
+	var body struct {
+		QuestionID string `json:"question_id" binding:"required"`
+		AnswerID   string `json:"answer_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	questionID, err := primitive.ObjectIDFromHex(body.QuestionID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid question_id"})
+		return
+	}
+	answerID, err := primitive.ObjectIDFromHex(body.AnswerID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid answer_id"})
+		return
+	}
+	askerID := auth.CurrentUserID(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	posts := database.GetCollection("community_posts")
+	answers := database.GetCollection("community_answers")
+
+	var question community_models.Post
+	err = posts.FindOne(ctx, bson.M{"_id": questionID, "post_type": community_models.PostTypeQuestion, "sender_id": askerID, "is_deleted": bson.M{"$ne": true}}).Decode(&question)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Question not found or you are not the asker"})
+		return
+	}
+
+	var answer community_models.Answer
+	if err := answers.FindOne(ctx, bson.M{"_id": answerID, "question_id": questionID}).Decode(&answer); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Answer not found for this question"})
+		return
+	}
+	if answer.IsAccepted {
+		c.JSON(http.StatusOK, gin.H{"message": "Answer already accepted"})
+		return
+	}
+
+	// Swap the accepted answer atomically on the question so concurrent accepts can't both win
+	prevAccepted := primitive.NilObjectID
+	if question.Question != nil {
+		prevAccepted = question.Question.AcceptedAnswerID
+	}
+	questionFilter := bson.M{"_id": questionID}
+	if prevAccepted.IsZero() {
+		questionFilter["question.accepted_answer_id"] = bson.M{"$exists": false}
+	} else {
+		questionFilter["question.accepted_answer_id"] = prevAccepted
+	}
+	now := time.Now()
+	res, err := posts.UpdateOne(ctx, questionFilter, bson.M{"$set": bson.M{"question.accepted_answer_id": answerID, "question.accepted_at": now}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept answer"})
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Accepted answer changed concurrently, retry"})
+		return
+	}
+
+	if !prevAccepted.IsZero() {
+		var prev community_models.Answer
+		if err := answers.FindOneAndUpdate(ctx, bson.M{"_id": prevAccepted}, bson.M{"$set": bson.M{"is_accepted": false, "updated_at": now}}).Decode(&prev); err == nil {
+			adjustReputation(ctx, prev, askerID, -1)
+		}
+	}
+
+	answers.UpdateOne(ctx, bson.M{"_id": answerID}, bson.M{"$set": bson.M{"is_accepted": true, "updated_at": now}})
+	adjustReputation(ctx, answer, askerID, 1)
+
+	if answer.AuthorID != askerID {
+		social.CreateNotification(ctx, answer.AuthorID, "answer_accepted", "Your answer was accepted.", answer.ID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Answer accepted"})
+}
+
+// adjustReputation credits (sign 1) or debits (sign -1) a consultant for an accepted answer.
+// Accepting your own answer earns nothing, so it is never debited either.
+func adjustReputation(ctx context.Context, answer community_models.Answer, askerID primitive.ObjectID, sign int) {
+	if answer.AuthorType != community_models.AuthorTypeConsultant || answer.AuthorID == askerID {
+		return
+	}
+	database.GetCollection("consultants").UpdateOne(ctx, bson.M{"_id": answer.AuthorID}, bson.M{"$inc": bson.M{
+		"reputation":       sign * community_models.ReputationPerAcceptedAnswer,
+		"accepted_answers": sign,
+	}})
+}
+
+// UnansweredQuestions is the consultant work queue: questions with no answers yet,
+// routed to the consultant's type and near the given location.
+// The consultant is the signed-in one. Query: lat, lon, radius (km, default 50), include_unaccepted, limit, cursor.
+func UnansweredQuestions(c *gin.Context) {
+	consultantID := auth.CurrentUserID(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var consultant consultant_models.Consultant
+	if err := database.GetCollection("consultants").FindOne(ctx, bson.M{"_id": consultantID, "is_blocked": false}).Decode(&consultant); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Consultant not found"})
+		return
+	}
+
+	filter := bson.M{
+		"post_type":  community_models.PostTypeQuestion,
+		"is_deleted": bson.M{"$ne": true},
+		// Untargeted questions are open to every consultant type
+		"$or": []bson.M{
+			{"question.expert_type": consultant.Type},
+			{"question.expert_type": bson.M{"$exists": false}},
+			{"question.expert_type": ""},
+		},
+	}
+	if c.Query("include_unaccepted") == "true" {
+		filter["question.accepted_answer_id"] = bson.M{"$exists": false}
+	} else {
+		filter["question.answer_count"] = 0
+	}
+
+	if latStr, lonStr := c.Query("lat"), c.Query("lon"); latStr != "" && lonStr != "" {
+		lat, errLat := strconv.ParseFloat(latStr, 64)
+		lon, errLon := strconv.ParseFloat(lonStr, 64)
+		if errLat != nil || errLon != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid lat/lon"})
+			return
+		}
+		radius := defaultQuestionRadiusKm
+		if r, err := strconv.ParseFloat(c.Query("radius"), 64); err == nil && r > 0 {
+			radius = r
+		}
+		filter["location"] = bson.M{"$geoWithin": bson.M{
+			"$centerSphere": []interface{}{[]float64{lon, lat}, radius / earthRadiusKm},
+		}}
+	}
+
+	if cursorStr := c.Query("cursor"); cursorStr != "" {
+		cur, err := utils.DecodeCursor(cursorStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		t := time.UnixMilli(int64(cur.Key))
+		filter["$and"] = []bson.M{{"$or": []bson.M{
+			{"created_at": bson.M{"$lt": t}},
+			{"created_at": t, "_id": bson.M{"$lt": cur.ID}},
+		}}}
+	}
+
+	limit := utils.ParseLimit(c.Query("limit"), defaultFeedLimit, maxFeedLimit)
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).SetLimit(limit + 1)
+
+	cursor, err := database.GetCollection("community_posts").Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	questions := []community_models.Post{}
+	if err := cursor.All(ctx, &questions); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error parsing questions"})
+		return
+	}
+
+	nextCursor := ""
+	if int64(len(questions)) > limit {
+		questions = questions[:limit]
+		last := questions[len(questions)-1]
+		nextCursor = utils.EncodeCursor(float64(last.CreatedAt.UnixMilli()), last.ID)
+	}
+	attachViewerState(ctx, questions, consultantID)
+
+	c.JSON(http.StatusOK, gin.H{"questions": questions, "next_cursor": nextCursor})
+}
+
+// createQuestionIndexes backs answer listing and the unanswered queue
+func createQuestionIndexes() {
+	if !database.WaitForClient(30 * time.Second) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	answers := database.GetCollection("community_answers")
+	_, _ = answers.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "question_id", Value: 1}, {Key: "is_accepted", Value: -1}, {Key: "is_expert", Value: -1}, {Key: "created_at", Value: 1}},
+	})
+
+	posts := database.GetCollection("community_posts")
+	_, _ = posts.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "post_type", Value: 1}, {Key: "question.answer_count", Value: 1}, {Key: "created_at", Value: -1}},
+	})
+}
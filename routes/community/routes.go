@@ -2,6 +2,7 @@ package community
 
 import (
 	"Agromi/core/router"
+	"Agromi/routes/auth"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,8 +12,25 @@ func init() {
 		group := r.Group("/api/community")
 		{
 			group.POST("/create", CreatePost)
-			group.GET("/feed", GetFeed)
-			group.DELETE("/delete/:id", DeletePost)
+			group.GET("/feed", auth.OptionalAuth(), GetFeed)
+			group.GET("/v2/feed", auth.OptionalAuth(), GetFeedPage)
+			group.PUT("/edit/:id", auth.RequireAuth(), EditPost)
+			group.GET("/post/:id/revisions", ListRevisions)
+			group.POST("/seen", auth.RequireAuth(), MarkSeen)
+
+			group.POST("/question/answer", auth.RequireAuth(), AnswerQuestion)
+			group.GET("/question/:id/answers", ListAnswers)
+			group.PUT("/question/accept", auth.RequireAuth(), AcceptAnswer)
+			group.GET("/question/unanswered", auth.RequireAuth(), auth.RequireRole(auth.RoleConsultant), UnansweredQuestions)
+
+			group.GET("/topic/:tag", auth.OptionalAuth(), GetTopic)
+			group.POST("/topic/follow", auth.RequireAuth(), FollowTag)
+			group.GET("/topics/following", ListFollowedTags)
+			group.GET("/trending", GetTrending)
+
+			group.POST("/poll/vote", auth.RequireAuth(), VotePoll)
+			group.GET("/poll/:id", auth.OptionalAuth(), GetPoll)
+			group.DELETE("/delete/:id", auth.RequireAuth(), DeletePost)
 		}
 	})
 }
@@ -0,0 +1,288 @@
+package community
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"Agromi/database"
+	"Agromi/routes/auth"
+	community_models "Agromi/routes/community/models"
+	"Agromi/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	go createTopicIndexes()
+}
+
+const (
+	maxTagLength   = 50
+	maxPostTags    = 20
+	maxFollowedTag = 500
+)
+
+// hashtagPattern finds #hashtags in post content
+var hashtagPattern = regexp.MustCompile(`#([\p{L}\p{N}_]+)`)
+
+// NormalizeTag lower-cases a tag, drops a leading '#', joins words with '_'
+// and removes anything that is not a letter, digit or underscore. Returns "" if nothing is left.
+func NormalizeTag(raw string) string {
+	raw = strings.TrimPrefix(strings.TrimSpace(raw), "#")
+	var b strings.Builder
+	for _, r := range strings.ToLower(raw) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), unicode.Is(unicode.Mn, r), unicode.Is(unicode.Mc, r), r == '_':
+			b.WriteRune(r)
+		case unicode.IsSpace(r), r == '-':
+			b.WriteRune('_')
+		}
+	}
+	tag := strings.Trim(b.String(), "_")
+	if len([]rune(tag)) > maxTagLength {
+		tag = string([]rune(tag)[:maxTagLength])
+	}
+	return tag
+}
+
+// postTags merges explicit tags with #hashtags from the content, normalized and de-duplicated
+func postTags(explicit []string, content string) []string {
+	seen := map[string]bool{}
+	tags := []string{}
+	add := func(raw string) {
+		if t := NormalizeTag(raw); t != "" && !seen[t] && len(tags) < maxPostTags {
+			seen[t] = true
+			tags = append(tags, t)
+		}
+	}
+	for _, t := range explicit {
+		add(t)
+	}
+	for _, m := range hashtagPattern.FindAllStringSubmatch(content, -1) {
+		add(m[1])
+	}
+	return tags
+}
+
+// topicTags returns the author's explicit tags. Posts from before they were stored separately
+// get them back by removing the content's hashtags from the merged tags.
+func topicTags(post community_models.Post) []string {
+	if post.TopicTags != nil {
+		return post.TopicTags
+	}
+	hashtags := map[string]bool{}
+	for _, t := range postTags(nil, post.Content) {
+		hashtags[t] = true
+	}
+	explicit := []string{}
+	for _, t := range postTags(post.Tags, "") {
+		if !hashtags[t] {
+			explicit = append(explicit, t)
+		}
+	}
+	return explicit
+}
+
+// GetTopic lists the posts of a topic, newest first. Query: limit, cursor.
+func GetTopic(c *gin.Context) {
+	tag := NormalizeTag(c.Param("tag"))
+	if tag == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag"})
+		return
+	}
+	viewerID := auth.CurrentUserID(c)
+
+	filter := bson.M{"tags": tag, "is_deleted": bson.M{"$ne": true}}
+	if cursorStr := c.Query("cursor"); cursorStr != "" {
+		cur, err := utils.DecodeCursor(cursorStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		t := time.UnixMilli(int64(cur.Key))
+		filter["$or"] = []bson.M{
+			{"created_at": bson.M{"$lt": t}},
+			{"created_at": t, "_id": bson.M{"$lt": cur.ID}},
+		}
+	}
+	limit := utils.ParseLimit(c.Query("limit"), defaultFeedLimit, maxFeedLimit)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).SetLimit(limit + 1)
+	cursor, err := database.GetCollection("community_posts").Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	posts := []community_models.Post{}
+	if err := cursor.All(ctx, &posts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error parsing posts"})
+		return
+	}
+
+	nextCursor := ""
+	if int64(len(posts)) > limit {
+		posts = posts[:limit]
+		last := posts[len(posts)-1]
+		nextCursor = utils.EncodeCursor(float64(last.CreatedAt.UnixMilli()), last.ID)
+	}
+	attachViewerState(ctx, posts, viewerID)
+
+	follows := database.GetCollection("tag_follows")
+	followerCount, _ := follows.CountDocuments(ctx, bson.M{"tag": tag})
+	following := false
+	if !viewerID.IsZero() {
+		n, _ := follows.CountDocuments(ctx, bson.M{"tag": tag, "user_id": viewerID})
+		following = n > 0
+	}
+
+	resp := gin.H{
+		"tag":            tag,
+		"follower_count": followerCount,
+		"following":      following,
+		"posts":          posts,
+		"next_cursor":    nextCursor,
+	}
+	// Totals only on the first page; later pages just continue the list
+	if c.Query("cursor") == "" {
+		postCount, _ := database.GetCollection("community_posts").CountDocuments(ctx, bson.M{"tags": tag, "is_deleted": bson.M{"$ne": true}})
+		resp["post_count"] = postCount
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// FollowTag toggles the signed-in user's subscription to a topic
+func FollowTag(c *gin.Context) {
+	var body struct {
+		Tag string `json:"tag" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID := auth.CurrentUserID(c)
+	tag := NormalizeTag(body.Tag)
+	if tag == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	coll := database.GetCollection("tag_follows")
+
+	res, err := coll.DeleteOne(ctx, bson.M{"user_id": userID, "tag": tag})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if res.DeletedCount > 0 {
+		c.JSON(http.StatusOK, gin.H{"message": "Unfollowed", "tag": tag})
+		return
+	}
+
+	if n, _ := coll.CountDocuments(ctx, bson.M{"user_id": userID}); n >= maxFollowedTag {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Too many followed topics"})
+		return
+	}
+
+	follow := community_models.TagFollow{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		Tag:       tag,
+		CreatedAt: time.Now(),
+	}
+	if _, err := coll.InsertOne(ctx, follow); err != nil && !mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to follow"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Followed", "tag": tag})
+}
+
+// ListFollowedTags returns the topics a user follows. Query: user_id.
+func ListFollowedTags(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Query("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Valid user_id required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c.JSON(http.StatusOK, gin.H{"tags": followedTags(ctx, userID)})
+}
+
+// followedTags returns the normalized tags a user follows, most recent first
+func followedTags(ctx context.Context, userID primitive.ObjectID) []string {
+	tags := []string{}
+	opts := options.Find().SetSort(bson.M{"created_at": -1}).SetLimit(maxFollowedTag)
+	cursor, err := database.GetCollection("tag_follows").Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return tags
+	}
+	var follows []community_models.TagFollow
+	if cursor.All(ctx, &follows) == nil {
+		for _, f := range follows {
+			tags = append(tags, f.Tag)
+		}
+	}
+	return tags
+}
+
+// createTopicIndexes makes tag follows unique and fills topic_tags on posts written before they existed
+func createTopicIndexes() {
+	if !database.WaitForClient(30 * time.Second) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	_, _ = database.GetCollection("tag_follows").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "tag", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "tag", Value: 1}}},
+	})
+
+	coll := database.GetCollection("community_posts")
+	cursor, err := coll.Find(ctx,
+		bson.M{"topic_tags": bson.M{"$exists": false}, "is_deleted": bson.M{"$ne": true}},
+		options.Find().SetProjection(bson.M{"tags": 1, "content": 1}),
+	)
+	if err != nil {
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var writes []mongo.WriteModel
+	for cursor.Next(ctx) {
+		var post community_models.Post
+		if cursor.Decode(&post) != nil {
+			continue
+		}
+		explicit := topicTags(post)
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": post.ID}).
+			SetUpdate(bson.M{"$set": bson.M{"topic_tags": explicit, "tags": postTags(explicit, post.Content)}}))
+		if len(writes) == 500 {
+			_, _ = coll.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+			writes = writes[:0]
+		}
+	}
+	if len(writes) > 0 {
+		_, _ = coll.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	}
+}
@@ -0,0 +1,280 @@
+package community
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"Agromi/database"
+	community_models "Agromi/routes/community/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	go runTrendingJob()
+}
+
+// Trending settings
+const (
+	trendingInterval     = 10 * time.Minute
+	trendingCellDegrees  = 1.0 // Regions are lat/lon grid cells of this size (~110 km)
+	trendingTopN         = 20
+	trendingRegionGlobal = "global"
+	trendingPostWeight   = 1.0 // A new post on a topic counts more than a reaction to one
+	trendingReactWeight  = 0.3
+)
+
+// trendingWindows are the sliding windows trending is computed over
+var trendingWindows = map[string]time.Duration{
+	"1h":  time.Hour,
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+}
+
+// trendingCell maps a location to its region key
+func trendingCell(lat, lon float64) string {
+	return fmt.Sprintf("%d:%d", int(math.Floor(lat/trendingCellDegrees)), int(math.Floor(lon/trendingCellDegrees)))
+}
+
+// GetTrending returns the trending topics for a window, for the viewer's region when lat/lon are given.
+// Query: window (1h|24h|7d, default 24h), lat, lon.
+func GetTrending(c *gin.Context) {
+	window := c.DefaultQuery("window", "24h")
+	if _, ok := trendingWindows[window]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "window must be 1h, 24h or 7d"})
+		return
+	}
+
+	region := trendingRegionGlobal
+	if latStr, lonStr := c.Query("lat"), c.Query("lon"); latStr != "" && lonStr != "" {
+		lat, errLat := strconv.ParseFloat(latStr, 64)
+		lon, errLon := strconv.ParseFloat(lonStr, 64)
+		if errLat != nil || errLon != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid lat/lon"})
+			return
+		}
+		region = trendingCell(lat, lon)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	coll := database.GetCollection("trending_topics")
+	var snap community_models.TrendingSnapshot
+	err := coll.FindOne(ctx, bson.M{"_id": window + "|" + region}).Decode(&snap)
+	if err == mongo.ErrNoDocuments && region != trendingRegionGlobal {
+		// Quiet region: fall back to the global list
+		err = coll.FindOne(ctx, bson.M{"_id": window + "|" + trendingRegionGlobal}).Decode(&snap)
+	}
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusOK, community_models.TrendingSnapshot{Window: window, Region: region, Topics: []community_models.TrendingTopic{}})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, snap)
+}
+
+// runTrendingJob recomputes trending topics on a fixed interval
+func runTrendingJob() {
+	if !database.WaitForClient(30 * time.Second) {
+		return
+	}
+
+	ticker := time.NewTicker(trendingInterval)
+	defer ticker.Stop()
+	for {
+		if err := computeTrending(time.Now()); err != nil {
+			log.Println("trending:", err)
+		}
+		<-ticker.C
+	}
+}
+
+// trendingKey identifies one tag's counters in one region
+type trendingKey struct {
+	Region, Tag string
+}
+
+// trendingCounts are the activity counts of the current window and the one before it
+type trendingCounts struct {
+	Posts, Reactions         int
+	PrevPosts, PrevReactions int
+}
+
+// computeTrending scores tags by post and reaction velocity for every window and region.
+// Velocity is weighted activity per hour; growth compares it with the previous window of
+// the same length, so topics that are picking up rank above steadily busy ones.
+func computeTrending(now time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	coll := database.GetCollection("trending_topics")
+	for window, length := range trendingWindows {
+		counts, err := trendingActivity(ctx, now, length)
+		if err != nil {
+			return err
+		}
+
+		byRegion := map[string][]community_models.TrendingTopic{}
+		hours := length.Hours()
+		for k, n := range counts {
+			cur := (float64(n.Posts)*trendingPostWeight + float64(n.Reactions)*trendingReactWeight) / hours
+			prev := (float64(n.PrevPosts)*trendingPostWeight + float64(n.PrevReactions)*trendingReactWeight) / hours
+			if cur == 0 {
+				continue
+			}
+			growth := (cur - prev) / (prev + 1/hours)
+			byRegion[k.Region] = append(byRegion[k.Region], community_models.TrendingTopic{
+				Tag:       k.Tag,
+				Score:     cur * (1 + math.Max(0, math.Min(growth, 10))),
+				Posts:     n.Posts,
+				Reactions: n.Reactions,
+				Growth:    growth,
+			})
+		}
+
+		for region, topics := range byRegion {
+			sort.Slice(topics, func(i, j int) bool {
+				if topics[i].Score != topics[j].Score {
+					return topics[i].Score > topics[j].Score
+				}
+				return topics[i].Tag < topics[j].Tag
+			})
+			if len(topics) > trendingTopN {
+				topics = topics[:trendingTopN]
+			}
+			snap := community_models.TrendingSnapshot{
+				ID:         window + "|" + region,
+				Window:     window,
+				Region:     region,
+				Topics:     topics,
+				ComputedAt: now,
+			}
+			if _, err := coll.ReplaceOne(ctx, bson.M{"_id": snap.ID}, snap, options.Replace().SetUpsert(true)); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Regions that went quiet since the last run
+	_, err := coll.DeleteMany(ctx, bson.M{"computed_at": bson.M{"$lt": now}})
+	return err
+}
+
+// trendingActivity counts posts and post reactions per (region, tag) over the last two windows.
+// Every region's counts are also rolled up into the global region.
+func trendingActivity(ctx context.Context, now time.Time, length time.Duration) (map[trendingKey]*trendingCounts, error) {
+	start, split := now.Add(-2*length), now.Add(-length)
+	cell := func(field string) bson.M {
+		coord := func(i int) bson.M {
+			return bson.M{"$floor": bson.M{"$divide": []interface{}{bson.M{"$arrayElemAt": []interface{}{field + ".coordinates", i}}, trendingCellDegrees}}}
+		}
+		return bson.M{"lat": coord(1), "lon": coord(0)}
+	}
+
+	type row struct {
+		ID struct {
+			Tag     string                      `bson:"tag"`
+			Cell    *struct{ Lat, Lon float64 } `bson:"cell"`
+			Current bool                        `bson:"current"`
+		} `bson:"_id"`
+		Count int `bson:"count"`
+	}
+
+	counts := map[trendingKey]*trendingCounts{}
+	add := func(rows []row, reactions bool) {
+		for _, r := range rows {
+			regions := []string{trendingRegionGlobal}
+			if r.ID.Cell != nil {
+				regions = append(regions, fmt.Sprintf("%d:%d", int(r.ID.Cell.Lat), int(r.ID.Cell.Lon)))
+			}
+			for _, region := range regions {
+				k := trendingKey{Region: region, Tag: r.ID.Tag}
+				n := counts[k]
+				if n == nil {
+					n = &trendingCounts{}
+					counts[k] = n
+				}
+				switch {
+				case reactions && r.ID.Current:
+					n.Reactions += r.Count
+				case reactions:
+					n.PrevReactions += r.Count
+				case r.ID.Current:
+					n.Posts += r.Count
+				default:
+					n.PrevPosts += r.Count
+				}
+			}
+		}
+	}
+
+	// Posts created in the windows
+	postPipeline := []bson.M{
+		{"$match": bson.M{"created_at": bson.M{"$gte": start, "$lte": now}, "is_deleted": bson.M{"$ne": true}, "tags.0": bson.M{"$exists": true}}},
+		{"$unwind": "$tags"},
+		{"$group": bson.M{
+			"_id": bson.M{
+				"tag":     "$tags",
+				"cell":    bson.M{"$cond": []interface{}{bson.M{"$ifNull": []interface{}{"$location", false}}, cell("$location"), nil}},
+				"current": bson.M{"$gte": []interface{}{"$created_at", split}},
+			},
+			"count": bson.M{"$sum": 1},
+		}},
+	}
+	cursor, err := database.GetCollection("community_posts").Aggregate(ctx, postPipeline)
+	if err != nil {
+		return nil, err
+	}
+	var postRows []row
+	if err := cursor.All(ctx, &postRows); err != nil {
+		return nil, err
+	}
+	add(postRows, false)
+
+	// Reactions on posts in the windows, attributed to the post's tags and region
+	reactPipeline := []bson.M{
+		{"$match": bson.M{"target_type": "post", "created_at": bson.M{"$gte": start, "$lte": now}}},
+		{"$lookup": bson.M{
+			"from":         "community_posts",
+			"localField":   "target_id",
+			"foreignField": "_id",
+			"as":           "post",
+		}},
+		{"$unwind": "$post"},
+		{"$match": bson.M{"post.is_deleted": bson.M{"$ne": true}}},
+		{"$unwind": "$post.tags"},
+		{"$group": bson.M{
+			"_id": bson.M{
+				"tag":     "$post.tags",
+				"cell":    bson.M{"$cond": []interface{}{bson.M{"$ifNull": []interface{}{"$post.location", false}}, cell("$post.location"), nil}},
+				"current": bson.M{"$gte": []interface{}{"$created_at", split}},
+			},
+			"count": bson.M{"$sum": 1},
+		}},
+	}
+	cursor, err = database.GetCollection("likes").Aggregate(ctx, reactPipeline)
+	if err != nil {
+		return nil, err
+	}
+	var reactRows []row
+	if err := cursor.All(ctx, &reactRows); err != nil {
+		return nil, err
+	}
+	add(reactRows, true)
+
+	return counts, nil
+}
@@ -0,0 +1,151 @@
+package consultant
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"Agromi/database"
+	"Agromi/routes/auth"
+	"Agromi/routes/consultant/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BookConsultation lets the signed-in farmer request a session with a consultant
+func BookConsultation(c *gin.Context) {
+	var body struct {
+		ConsultantID string     `json:"consultant_id" binding:"required"`
+		Mode         string     `json:"mode" binding:"required,oneof=chat voice video"`
+		Topic        string     `json:"topic"`
+		ScheduledAt  *time.Time `json:"scheduled_at"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	consultantID, err := primitive.ObjectIDFromHex(body.ConsultantID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Consultant ID"})
+		return
+	}
+	farmerID := auth.CurrentUserID(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var consultant models.Consultant
+	err = database.GetCollection("consultants").FindOne(ctx, bson.M{"_id": consultantID, "is_blocked": false}).Decode(&consultant)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Consultant not found"})
+		return
+	}
+
+	now := time.Now()
+	session := models.Consultation{
+		ID:           primitive.NewObjectID(),
+		ConsultantID: consultantID,
+		FarmerID:     farmerID,
+		Mode:         body.Mode,
+		Topic:        body.Topic,
+		ScheduledAt:  body.ScheduledAt,
+		Status:       models.ConsultationRequested,
+		Fee:          consultant.ConsultationFee,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	if _, err := database.GetCollection("consultations").InsertOne(ctx, session); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to book consultation"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Consultation requested", "id": session.ID})
+}
+
+// CloseConsultation marks a session finished (consultant) or cancelled (either party),
+// acting as the signed-in user
+func CloseConsultation(c *gin.Context) {
+	sessionID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var body struct {
+		Status string `json:"status" binding:"required,oneof=Completed Cancelled"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	actorID := auth.CurrentUserID(c)
+
+	filter := bson.M{"_id": sessionID, "status": models.ConsultationRequested}
+	now := time.Now()
+	set := bson.M{"status": body.Status, "updated_at": now}
+	if body.Status == models.ConsultationCompleted {
+		// Only the consultant can confirm the session took place
+		if auth.CurrentUserType(c) != auth.RoleConsultant {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only the consultant can complete a consultation"})
+			return
+		}
+		filter["consultant_id"] = actorID
+		set["completed_at"] = now
+		set["completed_by"] = actorID
+	} else {
+		filter["$or"] = []bson.M{{"consultant_id": actorID}, {"farmer_id": actorID}}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := database.GetCollection("consultations").UpdateOne(ctx, filter, bson.M{"$set": set})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Update failed"})
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Consultation not found, not yours, or already closed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Consultation " + body.Status})
+}
+
+// ListConsultations returns the signed-in farmer's or consultant's sessions
+func ListConsultations(c *gin.Context) {
+	filter := bson.M{"farmer_id": auth.CurrentUserID(c)}
+	if auth.CurrentUserType(c) == auth.RoleConsultant {
+		filter = bson.M{"consultant_id": auth.CurrentUserID(c)}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.M{"created_at": -1}).SetLimit(100)
+	cursor, err := database.GetCollection("consultations").Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	sessions := []models.Consultation{}
+	if err = cursor.All(ctx, &sessions); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error parsing data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+func RegisterConsultationRoutes(router *gin.RouterGroup) {
+	router.POST("/consultation/book", auth.RequireAuth(), auth.RequireRole(auth.RoleUser), BookConsultation)
+	router.PUT("/consultation/close/:id", auth.RequireAuth(), CloseConsultation)
+	router.GET("/consultation/list", auth.RequireAuth(), ListConsultations)
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"Agromi/database"
+	"Agromi/routes/auth"
 	"Agromi/routes/consultant/models"
 
 	"github.com/gin-gonic/gin"
@@ -15,8 +16,8 @@ import (
 )
 
 type ScoredConsultant struct {
-	models.Consultant `json:",inline"`
-	Score             float64 `json:"score"`
+	models.PublicConsultant `json:",inline"`
+	Score                   float64 `json:"score"`
 }
 
 // ListConsultants returns a filtered and scored list
@@ -84,8 +85,8 @@ func ListConsultants(c *gin.Context) {
 		// 4. Distance (Skipped - Model update needed)
 
 		scoredList = append(scoredList, ScoredConsultant{
-			Consultant: cons,
-			Score:      score,
+			PublicConsultant: cons.PublicView(),
+			Score:            score,
 		})
 	}
 
@@ -97,7 +98,8 @@ func ListConsultants(c *gin.Context) {
 	c.JSON(http.StatusOK, scoredList)
 }
 
-// GetConsultant returns a single consultant by ID
+// GetConsultant returns a single consultant by ID.
+// Phone and address are only included for farmers with a consultation booked.
 func GetConsultant(c *gin.Context) {
 	id := c.Param("id")
 	objID, err := primitive.ObjectIDFromHex(id)
@@ -117,10 +119,10 @@ func GetConsultant(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, consultant)
+	c.JSON(http.StatusOK, auth.ConsultantView(consultant, auth.RelationTo(ctx, auth.CurrentUserID(c), consultant.ID)))
 }
 
 func RegisterListRoutes(router *gin.RouterGroup) {
 	router.GET("/list", ListConsultants)
-	router.GET("/profile/:id", GetConsultant)
+	router.GET("/profile/:id", auth.OptionalAuth(), GetConsultant)
 }
@@ -26,7 +26,7 @@ const (
 // Consultant Struct
 type Consultant struct {
 	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	AuthTokenNum string             `json:"auth_token_num" bson:"auth_token_num"` // For consistent auth handling if needed
+	AuthTokenNum string             `json:"-" bson:"auth_token_num"` // Login identifier, never sent to clients
 
 	// Personal Info
 	Name    string `json:"name" bson:"name"`
@@ -59,8 +59,10 @@ type Consultant struct {
 	VideoURLs        []string `json:"video_urls" bson:"video_urls"`
 
 	// Stats
-	Rating      float64 `json:"rating" bson:"rating"`
-	ReviewCount int     `json:"review_count" bson:"review_count"`
+	Rating          float64 `json:"rating" bson:"rating"`
+	ReviewCount     int     `json:"review_count" bson:"review_count"`
+	Reputation      int     `json:"reputation" bson:"reputation"`             // Earned from accepted community answers
+	AcceptedAnswers int     `json:"accepted_answers" bson:"accepted_answers"` // Community answers accepted by the asker
 
 	// System
 	IsBlocked           bool       `json:"is_blocked" bson:"is_blocked"`
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Consultation Status
+const (
+	ConsultationRequested = "Requested"
+	ConsultationCompleted = "Completed"
+	ConsultationCancelled = "Cancelled"
+)
+
+// Consultation Modes
+const (
+	ModeChat  = "chat"
+	ModeVoice = "voice"
+	ModeVideo = "video"
+)
+
+// Consultation is a booked session between a farmer and a consultant (collection: consultations)
+type Consultation struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ConsultantID primitive.ObjectID `json:"consultant_id" bson:"consultant_id"`
+	FarmerID     primitive.ObjectID `json:"farmer_id" bson:"farmer_id"`
+	Mode         string             `json:"mode" bson:"mode"`
+	Topic        string             `json:"topic" bson:"topic"`
+
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty" bson:"scheduled_at,omitempty"`
+	Status      string     `json:"status" bson:"status"`
+	Fee         float64    `json:"fee" bson:"fee"` // Snapshot of the consultant's fee at booking
+
+	CreatedAt   time.Time  `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" bson:"updated_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" bson:"completed_at,omitempty"`
+
+	// Consultant who completed the session while signed in; older completions lack it
+	CompletedBy primitive.ObjectID `json:"-" bson:"completed_by,omitempty"`
+}
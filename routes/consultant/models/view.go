@@ -0,0 +1,54 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// PublicConsultant is the profile shown to farmers browsing consultants.
+// Phone and address are only shared once a consultation is booked.
+type PublicConsultant struct {
+	ID                 primitive.ObjectID `json:"id"`
+	Name               string             `json:"name"`
+	Type               string             `json:"type"`
+	Qualification      []string           `json:"qualification"`
+	Experience         int                `json:"experience"`
+	Achievements       []string           `json:"achievements"`
+	Position           string             `json:"position"`
+	VerificationStatus string             `json:"verification_status"`
+	ConsultationFee    float64            `json:"consultation_fee"`
+	VoiceCallRate      float64            `json:"voice_call_rate"`
+	VideoCallRate      float64            `json:"video_call_rate"`
+	ChatRate           float64            `json:"chat_rate"`
+	Timing             string             `json:"timing"`
+	ProfilePhotoURL    string             `json:"profile_photo_url"`
+	GalleryPhotoURLs   []string           `json:"gallery_photo_urls"`
+	VideoURLs          []string           `json:"video_urls"`
+	Rating             float64            `json:"rating"`
+	ReviewCount        int                `json:"review_count"`
+	Reputation         int                `json:"reputation"`
+	AcceptedAnswers    int                `json:"accepted_answers"`
+}
+
+// PublicView strips contact details, age and account state
+func (c Consultant) PublicView() PublicConsultant {
+	return PublicConsultant{
+		ID:                 c.ID,
+		Name:               c.Name,
+		Type:               c.Type,
+		Qualification:      c.Qualification,
+		Experience:         c.Experience,
+		Achievements:       c.Achievements,
+		Position:           c.Position,
+		VerificationStatus: c.VerificationStatus,
+		ConsultationFee:    c.ConsultationFee,
+		VoiceCallRate:      c.VoiceCallRate,
+		VideoCallRate:      c.VideoCallRate,
+		ChatRate:           c.ChatRate,
+		Timing:             c.Timing,
+		ProfilePhotoURL:    c.ProfilePhotoURL,
+		GalleryPhotoURLs:   c.GalleryPhotoURLs,
+		VideoURLs:          c.VideoURLs,
+		Rating:             c.Rating,
+		ReviewCount:        c.ReviewCount,
+		Reputation:         c.Reputation,
+		AcceptedAnswers:    c.AcceptedAnswers,
+	}
+}
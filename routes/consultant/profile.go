@@ -2,12 +2,11 @@ package consultant
 
 import (
 	"context"
-	"fmt"
-	"math/rand"
 	"net/http"
 	"time"
 
 	"Agromi/database"
+	"Agromi/routes/auth"
 	"Agromi/routes/consultant/models"
 
 	"github.com/gin-gonic/gin"
@@ -15,58 +14,100 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-// RegisterConsultant creates a new consultant profile
+// RegisterConsultant creates a new consultant profile for a Firebase account.
+// The same account can also hold a farmer profile; /api/auth/login picks one by role.
 func RegisterConsultant(c *gin.Context) {
-	var body models.Consultant
+	var body struct {
+		models.Consultant
+		AuthToken string `json:"auth_token" binding:"required"` // Firebase ID token
+	}
 	if err := c.ShouldBindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	account, err := auth.VerifyFirebaseToken(ctx, body.AuthToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid Auth Token"})
+		return
+	}
+	consultant := body.Consultant
+	if account.Phone != "" {
+		consultant.Phone = account.Phone // Verified by Firebase
+	}
+
 	// Basic Validation
-	if body.Phone == "" || body.Name == "" || body.Type == "" {
+	if consultant.Phone == "" || consultant.Name == "" || consultant.Type == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Name, Phone, and Type are required"})
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
 	coll := database.GetCollection("consultants")
 
 	// Check existing phone
-	count, _ := coll.CountDocuments(ctx, bson.M{"phone": body.Phone})
+	count, _ := coll.CountDocuments(ctx, bson.M{"phone": consultant.Phone})
 	if count > 0 {
-		c.JSON(http.StatusConflict, gin.H{"error": "Consultant with this phone already exists"})
+		c.JSON(http.StatusConflict, gin.H{"error": "Consultant with this phone already exists; sign in to use it"})
 		return
 	}
 
 	// Set Defaults
-	body.ID = primitive.NewObjectID()
-	body.CreatedAt = time.Now()
-	body.UpdatedAt = time.Now()
-	body.VerificationStatus = models.StatusPending // Default pending
-	body.IsBlocked = false
-	body.Rating = 0
-	body.ReviewCount = 0
-
-	// Generate Auth Token
-	rand.Seed(time.Now().UnixNano())
-	body.AuthTokenNum = fmt.Sprintf("CONS-%d-%d", time.Now().Unix(), rand.Intn(10000))
-
-	_, err := coll.InsertOne(ctx, body)
-	if err != nil {
+	consultant.ID = primitive.NewObjectID()
+	consultant.AuthTokenNum = account.UID
+	consultant.CreatedAt = time.Now()
+	consultant.UpdatedAt = time.Now()
+	consultant.VerificationStatus = models.StatusPending // Default pending
+	consultant.IsBlocked = false
+	consultant.Rating = 0
+	consultant.ReviewCount = 0
+	consultant.Reputation = 0
+	consultant.AcceptedAnswers = 0
+	consultant.DeletionScheduledAt = nil
+
+	if _, err := coll.InsertOne(ctx, consultant); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register consultant"})
 		return
 	}
+	if err := auth.LinkIdentity(ctx, account, auth.RoleConsultant, consultant.ID); err != nil {
+		coll.DeleteOne(ctx, bson.M{"_id": consultant.ID})
+		if err == auth.ErrProfileLinked {
+			c.JSON(http.StatusConflict, gin.H{"error": "This account already has a consultant profile"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register consultant"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Consultant registered successfully; sign in with role consultant", "id": consultant.ID})
+}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "Consultant registered successfully", "id": body.ID, "auth_token": body.AuthTokenNum})
+// profileFields are the consultant profile fields the consultant may change themselves.
+// Verification, ratings, reputation and contact details go through other flows.
+var profileFields = map[string]bool{
+	"name":               true,
+	"age":                true,
+	"address":            true,
+	"type":               true,
+	"qualification":      true,
+	"experience":         true,
+	"achievements":       true,
+	"position":           true,
+	"consultation_fee":   true,
+	"voice_call_rate":    true,
+	"video_call_rate":    true,
+	"chat_rate":          true,
+	"timing":             true,
+	"profile_photo_url":  true,
+	"gallery_photo_urls": true,
+	"video_urls":         true,
 }
 
-// UpdateProfile updates consultant details
+// UpdateProfile updates the signed-in consultant's details
 func UpdateProfile(c *gin.Context) {
-	// For MVP, passing ID in body or query. In prod, extract from JWT context.
 	var body struct {
-		ID      string                 `json:"id" binding:"required"`
 		Updates map[string]interface{} `json:"updates" binding:"required"`
 	}
 
@@ -75,27 +116,21 @@ func UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	objID, err := primitive.ObjectIDFromHex(body.ID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Consultant ID"})
-		return
+	updates := bson.M{}
+	for k, v := range body.Updates {
+		if !profileFields[k] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Field cannot be updated: " + k})
+			return
+		}
+		updates[k] = v
 	}
+	updates["updated_at"] = time.Now()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	coll := database.GetCollection("consultants")
 
-	// Filter allowed fields to update
-	allowedUpdates := bson.M{}
-	for k, v := range body.Updates {
-		// Prevent updating critical fields like ID, Phone (without verification), Ratings
-		if k != "id" && k != "phone" && k != "rating" && k != "review_count" && k != "is_blocked" && k != "verification_status" {
-			allowedUpdates[k] = v
-		}
-	}
-	allowedUpdates["updated_at"] = time.Now()
-
-	result, err := coll.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": allowedUpdates})
+	result, err := coll.UpdateOne(ctx, bson.M{"_id": auth.CurrentUserID(c)}, bson.M{"$set": updates})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
 		return
@@ -109,30 +144,16 @@ func UpdateProfile(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Profile updated successfully"})
 }
 
-// DELETE /request-delete
-// Schedule deletion after 30 days
+// POST /delete-request
+// Schedule deletion of the signed-in consultant after 30 days
 func RequestDeletion(c *gin.Context) {
-	var body struct {
-		ID string `json:"id" binding:"required"`
-	}
-	if err := c.ShouldBindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	objID, err := primitive.ObjectIDFromHex(body.ID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
-		return
-	}
-
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	coll := database.GetCollection("consultants")
 
 	scheduledTime := time.Now().Add(30 * 24 * time.Hour) // 30 Days
 
-	_, err = coll.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": bson.M{
+	_, err := coll.UpdateOne(ctx, bson.M{"_id": auth.CurrentUserID(c)}, bson.M{"$set": bson.M{
 		"deletion_scheduled_at": scheduledTime,
 		"updated_at":            time.Now(),
 	}})
@@ -146,8 +167,8 @@ func RequestDeletion(c *gin.Context) {
 }
 
 func RegisterProfileRoutes(router *gin.RouterGroup) {
-	// Public or Auth protected (assuming public for registration, auth for update/delete in real app)
+	// Registration is public; changes act on the signed-in consultant
 	router.POST("/create", RegisterConsultant)
-	router.PUT("/update", UpdateProfile)
-	router.POST("/delete-request", RequestDeletion)
+	router.PUT("/update", auth.RequireAuth(), auth.RequireRole(auth.RoleConsultant), UpdateProfile)
+	router.POST("/delete-request", auth.RequireAuth(), auth.RequireRole(auth.RoleConsultant), RequestDeletion)
 }
@@ -12,6 +12,7 @@ func init() {
 		{
 			RegisterProfileRoutes(consultantGroup)
 			RegisterListRoutes(consultantGroup)
+			RegisterConsultationRoutes(consultantGroup)
 		}
 	})
 }
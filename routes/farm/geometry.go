@@ -0,0 +1,105 @@
+package farm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	farm_models "Agromi/routes/farm/models"
+)
+
+const (
+	wgs84RadiusM = 6378137.0
+	sqmPerAcre   = 4046.8564224
+	sqmPerHa     = 10000.0
+)
+
+// validatePolygon checks that p is a GeoJSON polygon MongoDB will index:
+// closed rings of at least 4 positions with coordinates in range.
+func validatePolygon(p farm_models.Polygon) error {
+	if p.Type != "Polygon" {
+		return errors.New(`boundary.type must be "Polygon"`)
+	}
+	if len(p.Coordinates) == 0 {
+		return errors.New("boundary needs an outer ring")
+	}
+	total := 0
+	for r, ring := range p.Coordinates {
+		if len(ring) < 4 {
+			return fmt.Errorf("ring %d needs at least 4 positions", r)
+		}
+		for i, pos := range ring {
+			if len(pos) != 2 {
+				return fmt.Errorf("ring %d position %d must be [lon, lat]", r, i)
+			}
+			if pos[0] < -180 || pos[0] > 180 || pos[1] < -90 || pos[1] > 90 {
+				return fmt.Errorf("ring %d position %d out of range", r, i)
+			}
+		}
+		first, last := ring[0], ring[len(ring)-1]
+		if first[0] != last[0] || first[1] != last[1] {
+			return fmt.Errorf("ring %d is not closed", r)
+		}
+		total += len(ring)
+	}
+	if total > farm_models.MaxPolygonPoints {
+		return fmt.Errorf("boundary has more than %d positions", farm_models.MaxPolygonPoints)
+	}
+	return nil
+}
+
+// ringArea is the area of a ring on the sphere in square metres (sign gives the winding).
+// Same formula as the GeoJSON area used by mapping libraries.
+func ringArea(ring [][]float64) float64 {
+	rad := func(d float64) float64 { return d * math.Pi / 180 }
+	var sum float64
+	n := len(ring) - 1 // Last position repeats the first
+	for i := 0; i < n; i++ {
+		lo := ring[i]
+		mid := ring[(i+1)%n]
+		hi := ring[(i+2)%n]
+		sum += (rad(hi[0]) - rad(lo[0])) * math.Sin(rad(mid[1]))
+	}
+	return sum * wgs84RadiusM * wgs84RadiusM / 2
+}
+
+// polygonArea is the outer ring's area minus its holes, in square metres
+func polygonArea(p farm_models.Polygon) float64 {
+	area := math.Abs(ringArea(p.Coordinates[0]))
+	for _, hole := range p.Coordinates[1:] {
+		area -= math.Abs(ringArea(hole))
+	}
+	return math.Max(area, 0)
+}
+
+// centroid is the vertex average of the outer ring, good enough for plots a few km across
+func centroid(p farm_models.Polygon) farm_models.Point {
+	ring := p.Coordinates[0]
+	n := len(ring) - 1
+	var lon, lat float64
+	for _, pos := range ring[:n] {
+		lon += pos[0]
+		lat += pos[1]
+	}
+	return farm_models.Point{Type: "Point", Coordinates: []float64{lon / float64(n), lat / float64(n)}}
+}
+
+// applyBoundary sets the boundary and everything derived from it
+func applyBoundary(plot *farm_models.Plot, boundary farm_models.Polygon) error {
+	if err := validatePolygon(boundary); err != nil {
+		return err
+	}
+	sqm := polygonArea(boundary)
+	if sqm == 0 {
+		return errors.New("boundary has no area")
+	}
+	if sqm/sqmPerAcre > farm_models.MaxPlotAcres {
+		return errors.New("boundary is too large for a plot")
+	}
+	plot.Boundary = boundary
+	plot.Centroid = centroid(boundary)
+	plot.AreaSqM = math.Round(sqm*100) / 100
+	plot.AreaAcres = math.Round(sqm/sqmPerAcre*10000) / 10000
+	plot.AreaHectares = math.Round(sqm/sqmPerHa*10000) / 10000
+	return nil
+}
@@ -0,0 +1,88 @@
+package farm
+
+import (
+	"math"
+	"testing"
+
+	farm_models "Agromi/routes/farm/models"
+)
+
+// cellArea is the exact spherical area of a lon/lat rectangle, in square metres
+func cellArea(lon1, lat1, lon2, lat2 float64) float64 {
+	rad := func(d float64) float64 { return d * math.Pi / 180 }
+	return wgs84RadiusM * wgs84RadiusM * math.Abs(rad(lon2)-rad(lon1)) * math.Abs(math.Sin(rad(lat2))-math.Sin(rad(lat1)))
+}
+
+// box is a closed counter-clockwise ring around a lon/lat rectangle
+func box(lon1, lat1, lon2, lat2 float64) [][]float64 {
+	return [][]float64{{lon1, lat1}, {lon2, lat1}, {lon2, lat2}, {lon1, lat2}, {lon1, lat1}}
+}
+
+func reversed(ring [][]float64) [][]float64 {
+	out := make([][]float64, len(ring))
+	for i := range ring {
+		out[len(ring)-1-i] = ring[i]
+	}
+	return out
+}
+
+func closeTo(got, want float64) bool {
+	if want == 0 {
+		return math.Abs(got) < 1e-6
+	}
+	return math.Abs(got-want)/math.Abs(want) < 1e-9
+}
+
+func TestRingArea(t *testing.T) {
+	tests := []struct {
+		name string
+		ring [][]float64
+		want float64
+	}{
+		{"one degree at the equator", box(0, 0, 1, 1), cellArea(0, 0, 1, 1)},
+		{"small plot in Maharashtra", box(73.8500, 18.5200, 73.8510, 18.5210), cellArea(73.8500, 18.5200, 73.8510, 18.5210)},
+		{"southern hemisphere", box(-47.1, -23.2, -47.0, -23.1), cellArea(-47.1, -23.2, -47.0, -23.1)},
+		{"degenerate line", [][]float64{{0, 0}, {1, 1}, {0, 0}}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ringArea(tt.ring)
+			if !closeTo(math.Abs(got), tt.want) {
+				t.Errorf("|ringArea| = %f, want %f", math.Abs(got), tt.want)
+			}
+			if back := ringArea(reversed(tt.ring)); !closeTo(back, -got) {
+				t.Errorf("reversed ring area = %f, want %f", back, -got)
+			}
+		})
+	}
+}
+
+func TestPolygonArea(t *testing.T) {
+	tests := []struct {
+		name  string
+		rings [][][]float64
+		want  float64
+	}{
+		{"no holes", [][][]float64{box(0, 0, 2, 2)}, cellArea(0, 0, 2, 2)},
+		{"clockwise outer ring", [][][]float64{reversed(box(0, 0, 2, 2))}, cellArea(0, 0, 2, 2)},
+		{
+			"one hole",
+			[][][]float64{box(0, 0, 2, 2), reversed(box(0.5, 0.5, 1.5, 1.5))},
+			cellArea(0, 0, 2, 2) - cellArea(0.5, 0.5, 1.5, 1.5),
+		},
+		{
+			"two holes",
+			[][][]float64{box(0, 0, 3, 1), box(0.2, 0.2, 0.8, 0.8), box(2.2, 0.2, 2.8, 0.8)},
+			cellArea(0, 0, 3, 1) - 2*cellArea(0.2, 0.2, 0.8, 0.8),
+		},
+		{"hole larger than outer ring", [][][]float64{box(0, 0, 1, 1), box(-1, -1, 2, 2)}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := polygonArea(farm_models.Polygon{Type: "Polygon", Coordinates: tt.rings})
+			if !closeTo(got, tt.want) {
+				t.Errorf("polygonArea = %f, want %f", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package farm_models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Plot is one field of a farmer (collection: farm_plots)
+type Plot struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OwnerID  primitive.ObjectID `bson:"owner_id" json:"owner_id"`
+	Name     string             `bson:"name" json:"name"`
+	Boundary Polygon            `bson:"boundary" json:"boundary"` // 2dsphere indexed
+	Centroid Point              `bson:"centroid" json:"centroid"` // For near/distance queries
+
+	// Computed from Boundary on every write
+	AreaSqM      float64 `bson:"area_sqm" json:"area_sqm"`
+	AreaAcres    float64 `bson:"area_acres" json:"area_acres"`
+	AreaHectares float64 `bson:"area_hectares" json:"area_hectares"`
+
+	SoilType         string `bson:"soil_type,omitempty" json:"soil_type,omitempty"`                 // One of SoilTypes
+	IrrigationSource string `bson:"irrigation_source,omitempty" json:"irrigation_source,omitempty"` // One of IrrigationSources
+
+	Seasons []CropSeason `bson:"seasons" json:"seasons"`
+
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// Polygon is a GeoJSON polygon: an outer ring followed by optional holes, each ring closed
+type Polygon struct {
+	Type        string        `bson:"type" json:"type"`
+	Coordinates [][][]float64 `bson:"coordinates" json:"coordinates"` // [ring][vertex][lon, lat]
+}
+
+// Point is a GeoJSON point
+type Point struct {
+	Type        string    `bson:"type" json:"type"`
+	Coordinates []float64 `bson:"coordinates" json:"coordinates"` // [lon, lat]
+}
+
+// CropSeason is one crop grown on a plot in one season
+type CropSeason struct {
+	ID          primitive.ObjectID `bson:"id" json:"id"`
+	Season      string             `bson:"season" json:"season"` // kharif, rabi or zaid
+	Year        int                `bson:"year" json:"year"`
+	CropName    string             `bson:"crop_name" json:"crop_name"`
+	Variety     string             `bson:"variety,omitempty" json:"variety,omitempty"`
+	SowingDate  *time.Time         `bson:"sowing_date,omitempty" json:"sowing_date,omitempty"`
+	HarvestDate *time.Time         `bson:"harvest_date,omitempty" json:"harvest_date,omitempty"`
+	YieldValue  float64            `bson:"yield_value,omitempty" json:"yield_value,omitempty"`
+	YieldUnit   string             `bson:"yield_unit,omitempty" json:"yield_unit,omitempty"` // kg, quintal or tonne
+	Notes       string             `bson:"notes,omitempty" json:"notes,omitempty"`
+}
+
+// Seasons of the Indian cropping calendar
+var Seasons = []string{"kharif", "rabi", "zaid"}
+
+// SoilTypes accepted on a plot
+var SoilTypes = []string{"alluvial", "black", "red", "laterite", "arid", "saline", "peaty", "forest", "sandy", "clay", "loamy", "other"}
+
+// IrrigationSources accepted on a plot
+var IrrigationSources = []string{"rainfed", "canal", "borewell", "open_well", "tank", "river", "drip", "sprinkler", "other"}
+
+// YieldUnits and their size in kg
+var YieldUnits = map[string]float64{
+	"kg":      1,
+	"quintal": 100,
+	"tonne":   1000,
+}
+
+// Limits
+const (
+	MaxPlotsPerOwner  = 100
+	MaxPolygonPoints  = 1000
+	MaxSeasonsPerPlot = 200
+	MaxPlotAcres      = 10000 // Rejects boundaries that are obviously wrong (e.g. lat/lon swapped)
+)
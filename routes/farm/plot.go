@@ -0,0 +1,240 @@
+package farm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"Agromi/database"
+	"Agromi/routes/auth"
+	"Agromi/routes/calendar"
+	farm_models "Agromi/routes/farm/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// plotInput is the editable part of a plot
+type plotInput struct {
+	Name             *string              `json:"name"`
+	Boundary         *farm_models.Polygon `json:"boundary"`
+	SoilType         *string              `json:"soil_type"`
+	IrrigationSource *string              `json:"irrigation_source"`
+}
+
+// apply validates the input onto plot
+func (in plotInput) apply(plot *farm_models.Plot) string {
+	if in.Name != nil {
+		plot.Name = strings.TrimSpace(*in.Name)
+		if plot.Name == "" {
+			return "name cannot be empty"
+		}
+	}
+	if in.Boundary != nil {
+		if err := applyBoundary(plot, *in.Boundary); err != nil {
+			return err.Error()
+		}
+	}
+	if in.SoilType != nil {
+		plot.SoilType = strings.ToLower(strings.TrimSpace(*in.SoilType))
+		if plot.SoilType != "" && !slices.Contains(farm_models.SoilTypes, plot.SoilType) {
+			return "unknown soil_type"
+		}
+	}
+	if in.IrrigationSource != nil {
+		plot.IrrigationSource = strings.ToLower(strings.TrimSpace(*in.IrrigationSource))
+		if plot.IrrigationSource != "" && !slices.Contains(farm_models.IrrigationSources, plot.IrrigationSource) {
+			return "unknown irrigation_source"
+		}
+	}
+	return ""
+}
+
+// isInvalidGeometry reports MongoDB's rejection of a polygon it cannot index (e.g. self-intersecting)
+func isInvalidGeometry(err error) bool {
+	var we mongo.WriteException
+	if errors.As(err, &we) {
+		for _, e := range we.WriteErrors {
+			if e.Code == 16755 { // Can't extract geo keys
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CreatePlot registers a plot for the authenticated farmer
+func CreatePlot(c *gin.Context) {
+	var in plotInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if in.Name == nil || in.Boundary == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name and boundary are required"})
+		return
+	}
+
+	now := time.Now()
+	plot := farm_models.Plot{
+		ID:        primitive.NewObjectID(),
+		OwnerID:   auth.CurrentUserID(c),
+		Seasons:   []farm_models.CropSeason{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if msg := in.apply(&plot); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	coll := database.GetCollection("farm_plots")
+	if n, _ := coll.CountDocuments(ctx, bson.M{"owner_id": plot.OwnerID}); n >= farm_models.MaxPlotsPerOwner {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Plot limit reached"})
+		return
+	}
+
+	if _, err := coll.InsertOne(ctx, plot); err != nil {
+		if isInvalidGeometry(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "boundary is not a valid polygon (edges may cross)"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create plot"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, plot)
+}
+
+// ListMyPlots returns the authenticated farmer's plots with their total area
+func ListMyPlots(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.M{"created_at": 1})
+	cursor, err := database.GetCollection("farm_plots").Find(ctx, bson.M{"owner_id": auth.CurrentUserID(c)}, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	plots := []farm_models.Plot{}
+	if err := cursor.All(ctx, &plots); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error parsing plots"})
+		return
+	}
+
+	var acres float64
+	for _, p := range plots {
+		acres += p.AreaAcres
+	}
+	c.JSON(http.StatusOK, gin.H{"plots": plots, "total_acres": acres})
+}
+
+// GetPlot returns one of the authenticated farmer's plots
+func GetPlot(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	plot, ok := loadOwnPlot(ctx, c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, plot)
+}
+
+// UpdatePlot changes name, boundary, soil or irrigation. A new boundary recomputes the area.
+func UpdatePlot(c *gin.Context) {
+	var in plotInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	plot, ok := loadOwnPlot(ctx, c)
+	if !ok {
+		return
+	}
+	if msg := in.apply(&plot); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	set := bson.M{
+		"name":              plot.Name,
+		"soil_type":         plot.SoilType,
+		"irrigation_source": plot.IrrigationSource,
+		"updated_at":        time.Now(),
+	}
+	if in.Boundary != nil {
+		set["boundary"] = plot.Boundary
+		set["centroid"] = plot.Centroid
+		set["area_sqm"] = plot.AreaSqM
+		set["area_acres"] = plot.AreaAcres
+		set["area_hectares"] = plot.AreaHectares
+	}
+
+	_, err := database.GetCollection("farm_plots").UpdateOne(ctx, bson.M{"_id": plot.ID, "owner_id": plot.OwnerID}, bson.M{"$set": set})
+	if err != nil {
+		if isInvalidGeometry(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "boundary is not a valid polygon (edges may cross)"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update plot"})
+		return
+	}
+
+	c.JSON(http.StatusOK, plot)
+}
+
+// DeletePlot removes a plot, its season history and the seasons' pending calendar tasks
+func DeletePlot(c *gin.Context) {
+	plotID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := database.GetCollection("farm_plots").DeleteOne(ctx, bson.M{"_id": plotID, "owner_id": auth.CurrentUserID(c)})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete plot"})
+		return
+	}
+	if res.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Plot not found"})
+		return
+	}
+	calendar.RemovePlotSchedules(ctx, auth.CurrentUserID(c), plotID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Plot deleted"})
+}
+
+// loadOwnPlot fetches :id if it belongs to the authenticated farmer, writing an error response otherwise
+func loadOwnPlot(ctx context.Context, c *gin.Context) (farm_models.Plot, bool) {
+	var plot farm_models.Plot
+	plotID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return plot, false
+	}
+	err = database.GetCollection("farm_plots").FindOne(ctx, bson.M{"_id": plotID, "owner_id": auth.CurrentUserID(c)}).Decode(&plot)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Plot not found"})
+		return plot, false
+	}
+	return plot, true
+}
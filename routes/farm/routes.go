@@ -0,0 +1,49 @@
+package farm
+
+import (
+	"context"
+	"time"
+
+	"Agromi/core/router"
+	"Agromi/database"
+	"Agromi/routes/auth"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func init() {
+	router.Register(func(r *gin.Engine) {
+		group := r.Group("/api/farm", auth.RequireAuth(), auth.RequireRole(auth.RoleUser))
+		{
+			group.POST("/plots", CreatePlot)
+			group.GET("/plots", ListMyPlots)
+			group.GET("/plots/:id", GetPlot)
+			group.PUT("/plots/:id", UpdatePlot)
+			group.DELETE("/plots/:id", DeletePlot)
+
+			group.POST("/plots/:id/seasons", AddSeason)
+			group.PUT("/plots/:id/seasons/:seasonId", UpdateSeason)
+			group.DELETE("/plots/:id/seasons/:seasonId", DeleteSeason)
+		}
+	})
+
+	go createPlotIndexes()
+}
+
+// createPlotIndexes backs owner listing and the within/near queries
+func createPlotIndexes() {
+	if !database.WaitForClient(30 * time.Second) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, _ = database.GetCollection("farm_plots").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "boundary", Value: "2dsphere"}}},
+		{Keys: bson.D{{Key: "centroid", Value: "2dsphere"}}},
+	})
+}
@@ -0,0 +1,170 @@
+package farm
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"Agromi/database"
+	"Agromi/routes/auth"
+	"Agromi/routes/calendar"
+	farm_models "Agromi/routes/farm/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// validateSeason normalizes a season entry; returns an error message or ""
+func validateSeason(s *farm_models.CropSeason, now time.Time) string {
+	s.Season = strings.ToLower(strings.TrimSpace(s.Season))
+	s.CropName = strings.TrimSpace(s.CropName)
+	s.YieldUnit = strings.ToLower(strings.TrimSpace(s.YieldUnit))
+
+	if !slices.Contains(farm_models.Seasons, s.Season) {
+		return "season must be kharif, rabi or zaid"
+	}
+	if s.Year < 1950 || s.Year > now.Year()+1 {
+		return "invalid year"
+	}
+	if s.CropName == "" {
+		return "crop_name required"
+	}
+	if s.SowingDate != nil && s.HarvestDate != nil && s.HarvestDate.Before(*s.SowingDate) {
+		return "harvest_date is before sowing_date"
+	}
+	if s.YieldValue < 0 {
+		return "yield_value cannot be negative"
+	}
+	if s.YieldValue > 0 {
+		if s.YieldUnit == "" {
+			s.YieldUnit = "quintal"
+		}
+		if _, ok := farm_models.YieldUnits[s.YieldUnit]; !ok {
+			return "yield_unit must be kg, quintal or tonne"
+		}
+	}
+	return ""
+}
+
+// syncSeasonCalendar schedules tasks for a growing season; harvested seasons keep no pending tasks
+func syncSeasonCalendar(ctx context.Context, c *gin.Context, plotID primitive.ObjectID, s farm_models.CropSeason) {
+	sowing := s.SowingDate
+	if s.HarvestDate != nil {
+		sowing = nil
+	}
+	calendar.Sync(ctx, auth.CurrentUserID(c), calendar.Source{PlotID: plotID, SeasonID: s.ID}, s.CropName, s.Variety, sowing)
+}
+
+// AddSeason records a crop season on one of the farmer's plots
+func AddSeason(c *gin.Context) {
+	plotID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var season farm_models.CropSeason
+	if err := c.ShouldBindJSON(&season); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if msg := validateSeason(&season, time.Now()); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	season.ID = primitive.NewObjectID()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := database.GetCollection("farm_plots").UpdateOne(ctx,
+		bson.M{
+			"_id":      plotID,
+			"owner_id": auth.CurrentUserID(c),
+			"$expr":    bson.M{"$lt": []interface{}{bson.M{"$size": bson.M{"$ifNull": []interface{}{"$seasons", []interface{}{}}}}, farm_models.MaxSeasonsPerPlot}},
+		},
+		bson.M{"$push": bson.M{"seasons": season}, "$set": bson.M{"updated_at": time.Now()}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add season"})
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Plot not found or season limit reached"})
+		return
+	}
+
+	syncSeasonCalendar(ctx, c, plotID, season)
+
+	c.JSON(http.StatusCreated, season)
+}
+
+// UpdateSeason replaces a season entry, e.g. to record the harvest and yield
+func UpdateSeason(c *gin.Context) {
+	plotID, err1 := primitive.ObjectIDFromHex(c.Param("id"))
+	seasonID, err2 := primitive.ObjectIDFromHex(c.Param("seasonId"))
+	if err1 != nil || err2 != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var season farm_models.CropSeason
+	if err := c.ShouldBindJSON(&season); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if msg := validateSeason(&season, time.Now()); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	season.ID = seasonID
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := database.GetCollection("farm_plots").UpdateOne(ctx,
+		bson.M{"_id": plotID, "owner_id": auth.CurrentUserID(c), "seasons.id": seasonID},
+		bson.M{"$set": bson.M{"seasons.$": season, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update season"})
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Season not found"})
+		return
+	}
+	syncSeasonCalendar(ctx, c, plotID, season)
+
+	c.JSON(http.StatusOK, season)
+}
+
+// DeleteSeason removes a season entry from a plot
+func DeleteSeason(c *gin.Context) {
+	plotID, err1 := primitive.ObjectIDFromHex(c.Param("id"))
+	seasonID, err2 := primitive.ObjectIDFromHex(c.Param("seasonId"))
+	if err1 != nil || err2 != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := database.GetCollection("farm_plots").UpdateOne(ctx,
+		bson.M{"_id": plotID, "owner_id": auth.CurrentUserID(c), "seasons.id": seasonID},
+		bson.M{"$pull": bson.M{"seasons": bson.M{"id": seasonID}}, "$set": bson.M{"updated_at": time.Now()}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete season"})
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Season not found"})
+		return
+	}
+	calendar.RemoveSchedule(ctx, auth.CurrentUserID(c), calendar.Source{PlotID: plotID, SeasonID: seasonID})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Season removed"})
+}
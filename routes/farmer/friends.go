@@ -16,10 +16,11 @@ import (
 
 func init() {
 	router.Register(func(r *gin.Engine) {
-		r.GET("/api/farmer/nearby", getNearbyFarmers)
+		r.GET("/api/farmer/nearby", auth.OptionalAuth(), getNearbyFarmers)
 	})
 }
 
+// getNearbyFarmers lists farmers around a point. Each profile is the view the caller is entitled to.
 func getNearbyFarmers(c *gin.Context) {
 	latStr := c.Query("lat")
 	longStr := c.Query("long")
@@ -70,5 +71,5 @@ func getNearbyFarmers(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, farmers)
+	c.JSON(http.StatusOK, auth.Views(ctx, auth.CurrentUserID(c), farmers))
 }
@@ -0,0 +1,232 @@
+package farmer
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"Agromi/core/router"
+	"Agromi/database"
+	"Agromi/routes/auth"
+	"Agromi/routes/calendar"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const maxCrops = 50
+
+func init() {
+	router.Register(func(r *gin.Engine) {
+		group := r.Group("/api/farmer/me", auth.RequireAuth(), auth.RequireRole(auth.RoleUser))
+		{
+			group.GET("", getMyProfile)
+			group.PUT("", updateMyProfile)
+
+			group.GET("/crops", listMyCrops)
+			group.POST("/crops", addMyCrop)
+			group.PUT("/crops/:cropId", updateMyCrop)
+			group.DELETE("/crops/:cropId", deleteMyCrop)
+		}
+	})
+}
+
+// loadMe fetches the authenticated farmer, giving legacy crops without an ID one.
+// Writes an error response and returns false when the user does not exist.
+func loadMe(ctx context.Context, c *gin.Context) (auth.User, bool) {
+	var user auth.User
+	userID := auth.CurrentUserID(c)
+	coll := database.GetCollection("users")
+	if err := coll.FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		}
+		return user, false
+	}
+
+	original := append([]auth.Crop(nil), user.Crops...)
+	missing := false
+	for i := range user.Crops {
+		if user.Crops[i].ID.IsZero() {
+			user.Crops[i].ID = primitive.NewObjectID()
+			missing = true
+		}
+	}
+	if missing {
+		// Only if nobody changed the list meanwhile; otherwise the next load retries
+		coll.UpdateOne(ctx, bson.M{"_id": userID, "crops": original}, bson.M{"$set": bson.M{"crops": user.Crops}})
+	}
+	return user, true
+}
+
+func getMyProfile(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, ok := loadMe(ctx, c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// updateMyProfile changes whitelisted profile fields; unknown or protected fields are rejected
+func updateMyProfile(c *gin.Context) {
+	var input auth.ProfileUpdate
+	if err := auth.DecodeStrict(c.Request.Body, &input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	set, unset, err := input.Apply()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(set) == 0 && len(unset) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to update"})
+		return
+	}
+	set["last_active_at"] = time.Now()
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var user auth.User
+	err = database.GetCollection("users").FindOneAndUpdate(ctx,
+		bson.M{"_id": auth.CurrentUserID(c)},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func listMyCrops(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, ok := loadMe(ctx, c)
+	if !ok {
+		return
+	}
+	crops := user.Crops
+	if crops == nil {
+		crops = []auth.Crop{}
+	}
+	c.JSON(http.StatusOK, crops)
+}
+
+func addMyCrop(c *gin.Context) {
+	var crop auth.Crop
+	if err := c.ShouldBindJSON(&crop); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	crop.ID = primitive.NilObjectID // Always server assigned
+	if err := crop.Normalize(time.Now()); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The size check in the filter keeps the list bounded even under concurrent adds
+	res, err := database.GetCollection("users").UpdateOne(ctx,
+		bson.M{"_id": auth.CurrentUserID(c), "$expr": bson.M{"$lt": []interface{}{bson.M{"$size": bson.M{"$ifNull": []interface{}{"$crops", []interface{}{}}}}, maxCrops}}},
+		bson.M{"$push": bson.M{"crops": crop}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add crop"})
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User not found or crop limit reached"})
+		return
+	}
+	calendar.Sync(ctx, auth.CurrentUserID(c), calendar.Source{CropID: crop.ID}, crop.Name, crop.Variety, crop.SowingDate)
+
+	c.JSON(http.StatusCreated, crop)
+}
+
+func updateMyCrop(c *gin.Context) {
+	cropID, err := primitive.ObjectIDFromHex(c.Param("cropId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid crop ID"})
+		return
+	}
+	var crop auth.Crop
+	if err := c.ShouldBindJSON(&crop); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	crop.ID = cropID
+	if err := crop.Normalize(time.Now()); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := database.GetCollection("users").UpdateOne(ctx,
+		bson.M{"_id": auth.CurrentUserID(c), "crops.id": cropID},
+		bson.M{"$set": bson.M{"crops.$": crop}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update crop"})
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Crop not found"})
+		return
+	}
+	calendar.Sync(ctx, auth.CurrentUserID(c), calendar.Source{CropID: cropID}, crop.Name, crop.Variety, crop.SowingDate)
+
+	c.JSON(http.StatusOK, crop)
+}
+
+func deleteMyCrop(c *gin.Context) {
+	cropID, err := primitive.ObjectIDFromHex(c.Param("cropId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid crop ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := database.GetCollection("users").UpdateOne(ctx,
+		bson.M{"_id": auth.CurrentUserID(c), "crops.id": cropID},
+		bson.M{"$pull": bson.M{"crops": bson.M{"id": cropID}}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete crop"})
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Crop not found"})
+		return
+	}
+	calendar.RemoveSchedule(ctx, auth.CurrentUserID(c), calendar.Source{CropID: cropID})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Crop removed"})
+}
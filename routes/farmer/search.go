@@ -2,16 +2,30 @@ package farmer
 
 import (
 	"context"
+	"math"
 	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"Agromi/core/router"
 	"Agromi/database"
 	"Agromi/routes/auth"
+	"Agromi/utils"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
-	// "go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	searchEarthRadiusKm = 6371.0
+	maxSearchRadiusKm   = 500
+	suggestLimit        = 5
+	minSuggestChars     = 2
 )
 
 func init() {
@@ -22,98 +36,252 @@ func init() {
 			group.GET("/suggest", suggestFarmers) // Auto-complete
 		}
 	})
+
+	go createSearchIndexes()
+}
+
+// FarmerCard is the public projection of a farmer returned by directory search.
+// Contact details and login identifiers are never included.
+type FarmerCard struct {
+	ID               primitive.ObjectID `bson:"_id" json:"id"`
+	Name             string             `bson:"name" json:"name"`
+	ProfilePhotoURL  string             `bson:"profile_photo_url,omitempty" json:"profile_photo_url,omitempty"`
+	RegionalLanguage string             `bson:"regional_language,omitempty" json:"regional_language,omitempty"`
+	State            string             `bson:"state,omitempty" json:"state,omitempty"`
+	District         string             `bson:"district,omitempty" json:"district,omitempty"`
+	Crops            []string           `bson:"crops,omitempty" json:"crops"`
+	IsVerified       bool               `bson:"is_verified" json:"is_verified"`
+	Score            float64            `bson:"score" json:"score,omitempty"` // Text relevance, only when q is given
+	DistanceKm       *float64           `bson:"distance_km,omitempty" json:"distance_km,omitempty"`
+}
+
+// farmerCardProjection keeps FarmerCard fields only. Crops are reduced to their names.
+var farmerCardProjection = bson.M{
+	"name":              1,
+	"profile_photo_url": 1,
+	"regional_language": 1,
+	"state":             1,
+	"district":          1,
+	"crops":             "$crops.name",
+	"is_verified":       bson.M{"$eq": []interface{}{"$is_verified", true}},
+	"score":             1,
+	"distance_km":       1,
 }
 
-// searchFarmers uses MongoDB $text search for high performance on large datasets
+// searchFarmers is the farmer directory. All parameters are optional:
+// q (name text search, ranked by text score), crop, language, state, district,
+// lat/lon/radius_km (radius filter, km), verified=true, limit, cursor.
+// Without q results are newest first.
 func searchFarmers(c *gin.Context) {
-	query := c.Query("q")
-	if query == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter 'q' is required"})
-		return
+	query := strings.TrimSpace(c.Query("q"))
+
+	match := bson.M{"user_type": "farmer", "is_blocked": bson.M{"$ne": true}}
+	if query != "" {
+		match["$text"] = bson.M{"$search": query}
+	}
+	if crop := strings.TrimSpace(c.Query("crop")); crop != "" {
+		match["crops.name"] = bson.M{"$regex": "^" + regexp.QuoteMeta(crop) + "$", "$options": "i"}
+	}
+	if lang := strings.TrimSpace(c.Query("language")); lang != "" {
+		match["regional_language"] = bson.M{"$regex": "^" + regexp.QuoteMeta(lang) + "$", "$options": "i"}
+	}
+	if state := strings.TrimSpace(c.Query("state")); state != "" {
+		match["state"] = bson.M{"$regex": "^" + regexp.QuoteMeta(state) + "$", "$options": "i"}
+	}
+	if district := strings.TrimSpace(c.Query("district")); district != "" {
+		match["district"] = bson.M{"$regex": "^" + regexp.QuoteMeta(district) + "$", "$options": "i"}
+	}
+	if c.Query("verified") == "true" {
+		match["is_verified"] = true
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	// $near cannot be combined with $text, so the radius is a $geoWithin filter
+	var lat, lon float64
+	hasGeo := false
+	if c.Query("lat") != "" || c.Query("lon") != "" {
+		var err1, err2 error
+		lat, err1 = strconv.ParseFloat(c.Query("lat"), 64)
+		lon, err2 = strconv.ParseFloat(c.Query("lon"), 64)
+		if err1 != nil || err2 != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid lat/lon"})
+			return
+		}
+		radius, err := strconv.ParseFloat(c.DefaultQuery("radius_km", "50"), 64)
+		if err != nil || radius <= 0 || radius > maxSearchRadiusKm {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "radius_km must be between 0 and 500"})
+			return
+		}
+		match["geo_location"] = bson.M{"$geoWithin": bson.M{
+			"$centerSphere": []interface{}{[]float64{lon, lat}, radius / searchEarthRadiusKm},
+		}}
+		hasGeo = true
+	}
 
-	usersColl := database.GetCollection("users")
+	limit := utils.ParseLimit(c.Query("limit"), 20, 50)
+	var after *utils.Cursor
+	if raw := c.Query("cursor"); raw != "" {
+		cur, err := utils.DecodeCursor(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		after = &cur
+	}
 
-	// Filter: only farmers, match text
-	filter := bson.M{
-		"user_type": "farmer",
-		"$text":     bson.M{"$search": query},
+	// Sort key: text score when searching, otherwise creation time (unix millis)
+	var sortKey interface{} = bson.M{"$toLong": bson.M{"$ifNull": []interface{}{"$created_at", 0}}}
+	if query != "" {
+		sortKey = bson.M{"$meta": "textScore"}
+	}
+	pipeline := []bson.M{
+		{"$match": match},
+		{"$addFields": bson.M{"sort_key": sortKey}},
 	}
+	if query != "" {
+		pipeline = append(pipeline, bson.M{"$addFields": bson.M{"score": "$sort_key"}})
+	}
+	if after != nil {
+		pipeline = append(pipeline, bson.M{"$match": bson.M{"$or": []bson.M{
+			{"sort_key": bson.M{"$lt": after.Key}},
+			{"sort_key": after.Key, "_id": bson.M{"$lt": after.ID}},
+		}}})
+	}
+	pipeline = append(pipeline,
+		bson.M{"$sort": bson.D{{Key: "sort_key", Value: -1}, {Key: "_id", Value: -1}}},
+		bson.M{"$limit": limit + 1},
+	)
+	if hasGeo {
+		pipeline = append(pipeline, bson.M{"$addFields": bson.M{"distance_km": utils.HaversineExpr("geo_location", lat, lon)}})
+	}
+	projection := bson.M{"sort_key": 1}
+	for k, v := range farmerCardProjection {
+		projection[k] = v
+	}
+	pipeline = append(pipeline, bson.M{"$project": projection})
 
-	// Score-based sorting happens automatically with $text if requested, but simple find is also okay.
-	// We Limit to 20 for performance
-	// To get score: .Project(bson.M{"score": bson.M{"$meta": "textScore"}}).Sort(bson.M{"score": bson.M{"$meta": "textScore"}})
-	// For simplicity using standard Find with filter:
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
-	cursor, err := usersColl.Find(ctx, filter) // options.Find().SetLimit(20)
+	cursor, err := database.GetCollection("users").Aggregate(ctx, pipeline)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Search failed"})
 		return
 	}
-	defer cursor.Close(ctx)
-
-	var results []auth.User
-	if err = cursor.All(ctx, &results); err != nil {
+	var rows []struct {
+		FarmerCard `bson:",inline"`
+		SortKey    float64 `bson:"sort_key"`
+	}
+	if err = cursor.All(ctx, &rows); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Decoding failed"})
 		return
 	}
 
-	c.JSON(http.StatusOK, results)
+	nextCursor := ""
+	if int64(len(rows)) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		nextCursor = utils.EncodeCursor(last.SortKey, last.ID)
+	}
+	farmers := make([]FarmerCard, len(rows))
+	for i := range rows {
+		farmers[i] = rows[i].FarmerCard
+		if farmers[i].DistanceKm != nil {
+			d := math.Round(*farmers[i].DistanceKm*10) / 10
+			farmers[i].DistanceKm = &d
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"farmers": farmers, "next_cursor": nextCursor})
 }
 
-// suggestFarmers provides fast prefix-based suggestions (Limit 5)
+// suggestFarmers provides fast prefix-based suggestions (Limit 5).
+// Input is normalized like name_search and regex-escaped, so the anchored
+// case-sensitive prefix match is an index range scan.
 func suggestFarmers(c *gin.Context) {
-	query := c.Query("q")
-	if query == "" {
-		c.JSON(http.StatusOK, []string{})
+	prefix := auth.SearchName(c.Query("q"))
+	if len([]rune(prefix)) < minSuggestChars {
+		c.JSON(http.StatusOK, []gin.H{})
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	usersColl := database.GetCollection("users")
-
-	// Regex for prefix match (start anchor ^)
-	// Case insensitive option 'i'
 	filter := bson.M{
-		"user_type": "farmer",
-		"name":      bson.M{"$regex": "^" + query, "$options": "i"},
+		"user_type":   "farmer",
+		"name_search": bson.M{"$regex": "^" + regexp.QuoteMeta(prefix)},
+		"is_blocked":  bson.M{"$ne": true},
 	}
+	opts := options.Find().
+		SetProjection(bson.M{"name": 1, "profile_photo_url": 1, "district": 1}).
+		SetSort(bson.M{"name_search": 1}).
+		SetLimit(suggestLimit)
 
-	// Projection: only return names for suggestions
-	// Limit 5 for speed
-	// Use Options to set Limit
-	// We need internal mongo options, but to keep imports clean/standard, assume basic finding works.
-	// Properly: use options.Find().SetLimit(5).SetProjection(bson.M{"name": 1})
-	// For now, fetching full docs but limiting in code if needed or just relying on fast index scan.
-
-	// Efficient way:
-	cursor, err := usersColl.Find(ctx, filter)
+	cursor, err := database.GetCollection("users").Find(ctx, filter, opts)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Suggestion failed"})
 		return
 	}
-	defer cursor.Close(ctx)
 
-	var results []auth.User
-	// Decode
+	var results []FarmerCard
 	if err := cursor.All(ctx, &results); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Decoding failed"})
 		return
 	}
 
-	// Extract names and limit to 5
-	names := make([]string, 0)
-	for i, u := range results {
-		if i >= 5 {
-			break
-		}
-		names = append(names, u.Name)
+	suggestions := make([]gin.H, 0, len(results))
+	for _, u := range results {
+		suggestions = append(suggestions, gin.H{
+			"id":                u.ID,
+			"name":              u.Name,
+			"profile_photo_url": u.ProfilePhotoURL,
+			"district":          u.District,
+		})
+	}
+
+	c.JSON(http.StatusOK, suggestions)
+}
+
+// createSearchIndexes adds the autocomplete index and backfills name_search
+// for users created before the field existed
+func createSearchIndexes() {
+	if !database.WaitForClient(30 * time.Second) {
+		return
 	}
 
-	c.JSON(http.StatusOK, names)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	coll := database.GetCollection("users")
+	_, _ = coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_type", Value: 1}, {Key: "name_search", Value: 1}}},
+		{Keys: bson.D{{Key: "user_type", Value: 1}, {Key: "crops.name", Value: 1}}},
+	})
+
+	cursor, err := coll.Find(ctx, bson.M{"name_search": bson.M{"$exists": false}}, options.Find().SetProjection(bson.M{"name": 1}))
+	if err != nil {
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var writes []mongo.WriteModel
+	for cursor.Next(ctx) {
+		var u struct {
+			ID   primitive.ObjectID `bson:"_id"`
+			Name string             `bson:"name"`
+		}
+		if cursor.Decode(&u) != nil {
+			continue
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": u.ID}).
+			SetUpdate(bson.M{"$set": bson.M{"name_search": auth.SearchName(u.Name)}}))
+		if len(writes) == 500 {
+			_, _ = coll.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+			writes = writes[:0]
+		}
+	}
+	if len(writes) > 0 {
+		_, _ = coll.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	}
 }
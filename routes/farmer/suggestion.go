@@ -17,7 +17,7 @@ import (
 func init() {
 	// Register route
 	router.Register(func(r *gin.Engine) {
-		r.GET("/api/farmer/suggest-similar/:id", getSimilarFarmers)
+		r.GET("/api/farmer/suggest-similar/:id", auth.OptionalAuth(), getSimilarFarmers)
 	})
 }
 
@@ -88,5 +88,5 @@ func getSimilarFarmers(c *gin.Context) {
 		}
 	}
 
-	c.JSON(http.StatusOK, similar)
+	c.JSON(http.StatusOK, auth.Views(ctx, auth.CurrentUserID(c), similar))
 }
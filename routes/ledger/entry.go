@@ -0,0 +1,384 @@
+package ledger
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"Agromi/database"
+	"Agromi/routes/auth"
+	farm_models "Agromi/routes/farm/models"
+	ledger_models "Agromi/routes/ledger/models"
+	"Agromi/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// entryInput is the writable part of an entry
+type entryInput struct {
+	Kind        string     `json:"kind"`
+	Category    string     `json:"category"`
+	Amount      float64    `json:"amount"`
+	Date        *time.Time `json:"date"` // Defaults to now
+	Description string     `json:"description"`
+	Quantity    float64    `json:"quantity"`
+	Unit        string     `json:"unit"`
+
+	PlotID   string `json:"plot_id"`
+	SeasonID string `json:"season_id"`
+	CropID   string `json:"crop_id"`
+	CropName string `json:"crop_name"`
+	Season   string `json:"season"`
+	Year     int    `json:"year"`
+}
+
+// validate checks the money fields of an entry
+func validate(e *ledger_models.Entry) error {
+	e.Kind = strings.ToLower(strings.TrimSpace(e.Kind))
+	e.Category = strings.ToLower(strings.TrimSpace(e.Category))
+	e.Description = strings.TrimSpace(e.Description)
+	e.Unit = strings.TrimSpace(e.Unit)
+
+	switch e.Kind {
+	case ledger_models.KindExpense:
+		if !slices.Contains(ledger_models.ExpenseCategories, e.Category) {
+			return fmt.Errorf("expense category must be one of %s", strings.Join(ledger_models.ExpenseCategories, ", "))
+		}
+	case ledger_models.KindIncome:
+		if !slices.Contains(ledger_models.IncomeCategories, e.Category) {
+			return fmt.Errorf("income category must be one of %s", strings.Join(ledger_models.IncomeCategories, ", "))
+		}
+	default:
+		return errors.New("kind must be expense or income")
+	}
+	if e.Amount <= 0 || e.Amount > ledger_models.MaxAmount {
+		return errors.New("amount must be positive")
+	}
+	if e.Quantity < 0 {
+		return errors.New("quantity cannot be negative")
+	}
+	if utf8.RuneCountInString(e.Description) > ledger_models.MaxDescriptionSize {
+		return fmt.Errorf("description longer than %d characters", ledger_models.MaxDescriptionSize)
+	}
+	if e.Date.After(time.Now().Add(24 * time.Hour)) {
+		return errors.New("date is in the future")
+	}
+	return nil
+}
+
+// errNotFound marks attribution to a plot, season or crop the farmer does not have
+var errNotFound = errors.New("attributed plot season or crop not found")
+
+// attribute resolves the crop/plot/season an entry belongs to.
+// Plot seasons and profile crops fill in the crop name, season and year themselves.
+func attribute(ctx context.Context, ownerID primitive.ObjectID, in entryInput, e *ledger_models.Entry) error {
+	e.PlotID, e.SeasonID, e.CropID = primitive.NilObjectID, primitive.NilObjectID, primitive.NilObjectID
+	e.CropName = strings.TrimSpace(in.CropName)
+	e.Season = strings.ToLower(strings.TrimSpace(in.Season))
+	e.Year = in.Year
+
+	switch {
+	case in.PlotID != "":
+		plotID, err1 := primitive.ObjectIDFromHex(in.PlotID)
+		seasonID, err2 := primitive.ObjectIDFromHex(in.SeasonID)
+		if err1 != nil || err2 != nil {
+			return errors.New("plot_id needs a valid season_id")
+		}
+		var plot farm_models.Plot
+		opts := options.FindOne().SetProjection(bson.M{"seasons": bson.M{"$elemMatch": bson.M{"id": seasonID}}})
+		if err := database.GetCollection("farm_plots").FindOne(ctx, bson.M{"_id": plotID, "owner_id": ownerID}, opts).Decode(&plot); err != nil || len(plot.Seasons) == 0 {
+			return errNotFound
+		}
+		s := plot.Seasons[0]
+		e.PlotID, e.SeasonID = plotID, seasonID
+		e.CropName, e.Season, e.Year = s.CropName, s.Season, s.Year
+
+	case in.CropID != "":
+		cropID, err := primitive.ObjectIDFromHex(in.CropID)
+		if err != nil {
+			return errors.New("invalid crop_id")
+		}
+		var user auth.User
+		opts := options.FindOne().SetProjection(bson.M{"crops": bson.M{"$elemMatch": bson.M{"id": cropID}}})
+		if err := database.GetCollection("users").FindOne(ctx, bson.M{"_id": ownerID}, opts).Decode(&user); err != nil || len(user.Crops) == 0 {
+			return errNotFound
+		}
+		e.CropID = cropID
+		e.CropName = user.Crops[0].Name
+		if e.Year == 0 && user.Crops[0].SowingDate != nil {
+			e.Year = user.Crops[0].SowingDate.Year()
+		}
+	}
+
+	if e.Season != "" && !slices.Contains(farm_models.Seasons, e.Season) {
+		return errors.New("season must be kharif, rabi or zaid")
+	}
+	if e.Year != 0 && (e.Year < 1950 || e.Year > time.Now().Year()+1) {
+		return errors.New("invalid year")
+	}
+	return nil
+}
+
+// attributionStatus maps attribution errors to HTTP statuses
+func attributionStatus(err error) int {
+	if err == errNotFound {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
+
+// CreateEntry records a manual expense or income
+func CreateEntry(c *gin.Context) {
+	var in entryInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ownerID := auth.CurrentUserID(c)
+	now := time.Now()
+
+	entry := ledger_models.Entry{
+		ID:          primitive.NewObjectID(),
+		OwnerID:     ownerID,
+		Kind:        in.Kind,
+		Category:    in.Category,
+		Amount:      in.Amount,
+		Date:        now,
+		Description: in.Description,
+		Quantity:    in.Quantity,
+		Unit:        in.Unit,
+		Source:      ledger_models.SourceManual,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if in.Date != nil {
+		entry.Date = *in.Date
+	}
+	if err := validate(&entry); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := attribute(ctx, ownerID, in, &entry); err != nil {
+		c.JSON(attributionStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := database.GetCollection("ledger_entries").InsertOne(ctx, entry); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save entry"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+// UpdateEntry replaces an entry. Auto-posted entries keep their amount and kind;
+// only the category, description and attribution can change.
+func UpdateEntry(c *gin.Context) {
+	entryID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var in entryInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ownerID := auth.CurrentUserID(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	coll := database.GetCollection("ledger_entries")
+	var entry ledger_models.Entry
+	if err := coll.FindOne(ctx, bson.M{"_id": entryID, "owner_id": ownerID}).Decode(&entry); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found"})
+		return
+	}
+
+	if in.Category != "" {
+		entry.Category = in.Category
+	}
+	entry.Description = in.Description
+	if entry.Source == ledger_models.SourceManual {
+		entry.Kind, entry.Amount = in.Kind, in.Amount
+		entry.Quantity, entry.Unit = in.Quantity, in.Unit
+		if in.Date != nil {
+			entry.Date = *in.Date
+		}
+	}
+	if err := validate(&entry); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := attribute(ctx, ownerID, in, &entry); err != nil {
+		c.JSON(attributionStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	entry.UpdatedAt = time.Now()
+
+	if _, err := coll.ReplaceOne(ctx, bson.M{"_id": entryID, "owner_id": ownerID}, entry); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update entry"})
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// DeleteEntry removes a manual entry; auto-posted entries mirror an order and stay
+func DeleteEntry(c *gin.Context) {
+	entryID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := database.GetCollection("ledger_entries").DeleteOne(ctx, bson.M{
+		"_id":      entryID,
+		"owner_id": auth.CurrentUserID(c),
+		"source":   ledger_models.SourceManual,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete entry"})
+		return
+	}
+	if res.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found or auto-posted from an order"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Entry deleted"})
+}
+
+// entryFilter builds the list filter from the query string
+func entryFilter(c *gin.Context) bson.M {
+	filter := bson.M{"owner_id": auth.CurrentUserID(c)}
+	for _, key := range []string{"kind", "category", "season", "source"} {
+		if v := c.Query(key); v != "" {
+			filter[key] = strings.ToLower(v)
+		}
+	}
+	for _, key := range []string{"plot_id", "season_id", "crop_id"} {
+		if id, err := primitive.ObjectIDFromHex(c.Query(key)); err == nil {
+			filter[key] = id
+		}
+	}
+	if crop := strings.TrimSpace(c.Query("crop")); crop != "" {
+		filter["crop_name"] = primitive.Regex{Pattern: "^" + regexp.QuoteMeta(crop) + "$", Options: "i"}
+	}
+	if year, err := strconv.Atoi(c.Query("year")); err == nil {
+		filter["year"] = year
+	}
+
+	date := bson.M{}
+	if from, err := time.Parse("2006-01-02", c.Query("from")); err == nil {
+		date["$gte"] = from
+	}
+	if to, err := time.Parse("2006-01-02", c.Query("to")); err == nil {
+		date["$lt"] = to.AddDate(0, 0, 1)
+	}
+	if len(date) > 0 {
+		filter["date"] = date
+	}
+	return filter
+}
+
+// ListEntries returns ledger entries, newest first.
+// Filters: kind, category, source, plot_id, season_id, crop_id, crop, season, year, from/to (YYYY-MM-DD).
+// format=csv exports every matching entry instead of a page.
+func ListEntries(c *gin.Context) {
+	filter := entryFilter(c)
+	csvExport := c.Query("format") == "csv"
+
+	limit := utils.ParseLimit(c.Query("limit"), 50, 200)
+	if raw := c.Query("cursor"); raw != "" && !csvExport {
+		cur, err := utils.DecodeCursor(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		before := time.UnixMilli(int64(cur.Key))
+		filter["$or"] = []bson.M{
+			{"date": bson.M{"$lt": before}},
+			{"date": before, "_id": bson.M{"$lt": cur.ID}},
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: -1}, {Key: "_id", Value: -1}})
+	if !csvExport {
+		opts.SetLimit(limit + 1)
+	}
+	cursor, err := database.GetCollection("ledger_entries").Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	entries := []ledger_models.Entry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error parsing entries"})
+		return
+	}
+
+	if csvExport {
+		c.Header("Content-Type", "text/csv")
+		c.Header("Content-Disposition", "attachment; filename=ledger.csv")
+		w := csv.NewWriter(c.Writer)
+		w.Write([]string{"date", "kind", "category", "amount", "quantity", "unit", "crop", "season", "year", "plot_id", "description", "source"})
+		for _, e := range entries {
+			plot := ""
+			if !e.PlotID.IsZero() {
+				plot = e.PlotID.Hex()
+			}
+			w.Write([]string{
+				e.Date.Format("2006-01-02"), e.Kind, e.Category, money(e.Amount),
+				strconv.FormatFloat(e.Quantity, 'f', -1, 64), e.Unit,
+				e.CropName, e.Season, yearString(e.Year), plot, e.Description, e.Source,
+			})
+		}
+		w.Flush()
+		return
+	}
+
+	nextCursor := ""
+	if int64(len(entries)) > limit {
+		entries = entries[:limit]
+		last := entries[len(entries)-1]
+		nextCursor = utils.EncodeCursor(float64(last.Date.UnixMilli()), last.ID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entries": entries, "next_cursor": nextCursor})
+}
+
+// money formats rupees with two decimals
+func money(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+func yearString(y int) string {
+	if y == 0 {
+		return ""
+	}
+	return strconv.Itoa(y)
+}
@@ -0,0 +1,86 @@
+package ledger_models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Entry kinds
+const (
+	KindExpense = "expense"
+	KindIncome  = "income"
+)
+
+// Entry sources
+const (
+	SourceManual = "manual"
+	SourceOrder  = "order" // Auto-posted from a completed marketplace order or rental
+)
+
+// ExpenseCategories accepted on expense entries
+var ExpenseCategories = []string{"seed", "fertilizer", "pesticide", "labour", "machinery", "rental", "irrigation", "transport", "land_lease", "other"}
+
+// IncomeCategories accepted on income entries
+var IncomeCategories = []string{"crop_sale", "rental_income", "subsidy", "other"}
+
+// Limits
+const (
+	MaxAmount          = 1e9
+	MaxDescriptionSize = 500
+)
+
+// Entry is one expense or income line in a farmer's ledger (collection: ledger_entries).
+// Amounts are in rupees.
+type Entry struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OwnerID     primitive.ObjectID `bson:"owner_id" json:"owner_id"`
+	Kind        string             `bson:"kind" json:"kind"`
+	Category    string             `bson:"category" json:"category"`
+	Amount      float64            `bson:"amount" json:"amount"`
+	Date        time.Time          `bson:"date" json:"date"`
+	Description string             `bson:"description,omitempty" json:"description,omitempty"`
+	Quantity    float64            `bson:"quantity,omitempty" json:"quantity,omitempty"`
+	Unit        string             `bson:"unit,omitempty" json:"unit,omitempty"`
+
+	// Attribution: a plot season (PlotID + SeasonID), a profile crop (CropID), or just a crop name/season
+	PlotID   primitive.ObjectID `bson:"plot_id,omitempty" json:"plot_id,omitempty"`
+	SeasonID primitive.ObjectID `bson:"season_id,omitempty" json:"season_id,omitempty"`
+	CropID   primitive.ObjectID `bson:"crop_id,omitempty" json:"crop_id,omitempty"`
+	CropName string             `bson:"crop_name,omitempty" json:"crop_name,omitempty"`
+	Season   string             `bson:"season,omitempty" json:"season,omitempty"` // kharif, rabi or zaid
+	Year     int                `bson:"year,omitempty" json:"year,omitempty"`
+
+	Source  string             `bson:"source" json:"source"`
+	OrderID primitive.ObjectID `bson:"order_id,omitempty" json:"order_id,omitempty"`
+
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// SeasonReport is the profit/loss of one attributed crop season
+type SeasonReport struct {
+	PlotID    primitive.ObjectID `json:"plot_id,omitempty"`
+	PlotName  string             `json:"plot_name,omitempty"`
+	SeasonID  primitive.ObjectID `json:"season_id,omitempty"`
+	CropID    primitive.ObjectID `json:"crop_id,omitempty"`
+	CropName  string             `json:"crop_name"`
+	Season    string             `json:"season,omitempty"`
+	Year      int                `json:"year,omitempty"`
+	AreaAcres float64            `json:"area_acres,omitempty"`
+
+	YieldValue float64 `json:"yield_value,omitempty"`
+	YieldUnit  string  `json:"yield_unit,omitempty"`
+
+	Expenses float64 `json:"expenses"`
+	Income   float64 `json:"income"`
+	Profit   float64 `json:"profit"`
+
+	CostPerAcre   float64 `json:"cost_per_acre,omitempty"`
+	CostPerUnit   float64 `json:"cost_per_unit,omitempty"` // Per YieldUnit
+	CostPerQtl    float64 `json:"cost_per_quintal,omitempty"`
+	ProfitPerAcre float64 `json:"profit_per_acre,omitempty"`
+
+	ExpenseByCategory map[string]float64 `json:"expense_by_category"`
+	IncomeByCategory  map[string]float64 `json:"income_by_category"`
+}
@@ -0,0 +1,133 @@
+package ledger
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"Agromi/database"
+	"Agromi/routes/auth"
+	ledger_models "Agromi/routes/ledger/models"
+	market "Agromi/routes/market/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Product categories are free text; these keywords map them onto expense categories
+var categoryKeywords = []struct {
+	keyword, category string
+}{
+	{"seed", "seed"},
+	{"fertili", "fertilizer"},
+	{"manure", "fertilizer"},
+	{"urea", "fertilizer"},
+	{"pestic", "pesticide"},
+	{"insectic", "pesticide"},
+	{"herbic", "pesticide"},
+	{"fungic", "pesticide"},
+	{"tractor", "machinery"},
+	{"machine", "machinery"},
+	{"equipment", "machinery"},
+	{"tool", "machinery"},
+	{"pump", "irrigation"},
+	{"pipe", "irrigation"},
+	{"drip", "irrigation"},
+}
+
+// expenseCategory picks the ledger category of a purchased product
+func expenseCategory(o market.Order) string {
+	if o.ProductType == market.TypeRent {
+		return "rental"
+	}
+	text := strings.ToLower(o.Category + " " + o.ProductName)
+	for _, k := range categoryKeywords {
+		if strings.Contains(text, k.keyword) {
+			return k.category
+		}
+	}
+	return "other"
+}
+
+// PostOrder mirrors a completed order into the buyer's and seller's ledgers.
+// Orders not completed through a seller's (or admin's) session are skipped, so a forged
+// completion from before sessions were required never reaches anyone's ledger.
+// It is idempotent: each side is upserted on (owner_id, order_id, kind).
+func PostOrder(ctx context.Context, o market.Order) {
+	if o.Status != market.OrderStatusCompleted || o.Total <= 0 || o.CompletedBy.IsZero() {
+		return
+	}
+	date := o.UpdatedAt
+	if o.CompletedAt != nil {
+		date = *o.CompletedAt
+	}
+
+	desc := o.ProductName
+	if o.ProductType == market.TypeRent && o.RentalStart != nil && o.RentalEnd != nil {
+		desc += " (" + o.RentalStart.Format("2006-01-02") + " to " + o.RentalEnd.Format("2006-01-02") + ")"
+	}
+
+	post := func(ownerID primitive.ObjectID, kind, category string) {
+		if ownerID.IsZero() {
+			return // Admin catalogue items have no seller ledger
+		}
+		now := time.Now()
+		_, err := database.GetCollection("ledger_entries").UpdateOne(ctx,
+			bson.M{"owner_id": ownerID, "order_id": o.ID, "kind": kind},
+			bson.M{
+				"$set": bson.M{"amount": o.Total, "quantity": o.Quantity, "unit": o.Unit, "date": date, "updated_at": now},
+				"$setOnInsert": bson.M{
+					"_id":         primitive.NewObjectID(),
+					"category":    category,
+					"description": desc,
+					"source":      ledger_models.SourceOrder,
+					"created_at":  now,
+				},
+			},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			log.Println("Ledger: failed to post order", o.ID.Hex(), err)
+		}
+	}
+
+	post(o.BuyerID, ledger_models.KindExpense, expenseCategory(o))
+	incomeCategory := "crop_sale"
+	if o.ProductType == market.TypeRent {
+		incomeCategory = "rental_income"
+	}
+	post(o.SellerID, ledger_models.KindIncome, incomeCategory)
+}
+
+// SyncOrders posts the farmer's completed orders that predate the ledger (or whose posting failed)
+func SyncOrders(c *gin.Context) {
+	userID := auth.CurrentUserID(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cursor, err := database.GetCollection("market_orders").Find(ctx, bson.M{
+		"status":       market.OrderStatusCompleted,
+		"completed_by": bson.M{"$exists": true},
+		"$or":          []bson.M{{"buyer_id": userID}, {"seller_id": userID}},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	var orders []market.Order
+	if err := cursor.All(ctx, &orders); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error parsing orders"})
+		return
+	}
+	for _, o := range orders {
+		PostOrder(ctx, o)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Orders synced", "orders": len(orders)})
+}
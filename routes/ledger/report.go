@@ -0,0 +1,209 @@
+package ledger
+
+import (
+	"context"
+	"encoding/csv"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"Agromi/database"
+	"Agromi/routes/auth"
+	farm_models "Agromi/routes/farm/models"
+	ledger_models "Agromi/routes/ledger/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// seasonKey groups ledger totals per attributed crop season
+type seasonKey struct {
+	PlotID   primitive.ObjectID
+	SeasonID primitive.ObjectID
+	CropID   primitive.ObjectID
+	CropName string
+	Season   string
+	Year     int
+}
+
+// SeasonReports returns profit/loss per crop season with cost per acre and per unit of yield.
+// Area comes from the plot boundary or the profile crop; yield from the plot season record.
+// Query: year, crop, format (json|csv)
+func SeasonReports(c *gin.Context) {
+	ownerID := auth.CurrentUserID(c)
+	match := bson.M{"owner_id": ownerID}
+	if year, err := strconv.Atoi(c.Query("year")); err == nil {
+		match["year"] = year
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	pipeline := []bson.M{
+		{"$match": match},
+		{"$group": bson.M{
+			"_id": bson.M{
+				"plot_id":   "$plot_id",
+				"season_id": "$season_id",
+				"crop_id":   "$crop_id",
+				"crop_name": bson.M{"$toLower": bson.M{"$ifNull": []interface{}{"$crop_name", ""}}},
+				"season":    "$season",
+				"year":      "$year",
+				"kind":      "$kind",
+				"category":  "$category",
+			},
+			"total": bson.M{"$sum": "$amount"},
+		}},
+	}
+	cursor, err := database.GetCollection("ledger_entries").Aggregate(ctx, pipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	var rows []struct {
+		ID struct {
+			PlotID   primitive.ObjectID `bson:"plot_id"`
+			SeasonID primitive.ObjectID `bson:"season_id"`
+			CropID   primitive.ObjectID `bson:"crop_id"`
+			CropName string             `bson:"crop_name"`
+			Season   string             `bson:"season"`
+			Year     int                `bson:"year"`
+			Kind     string             `bson:"kind"`
+			Category string             `bson:"category"`
+		} `bson:"_id"`
+		Total float64 `bson:"total"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error parsing report"})
+		return
+	}
+
+	reports := map[seasonKey]*ledger_models.SeasonReport{}
+	var order []seasonKey
+	for _, r := range rows {
+		key := seasonKey{r.ID.PlotID, r.ID.SeasonID, r.ID.CropID, r.ID.CropName, r.ID.Season, r.ID.Year}
+		rep, ok := reports[key]
+		if !ok {
+			rep = &ledger_models.SeasonReport{
+				PlotID:            key.PlotID,
+				SeasonID:          key.SeasonID,
+				CropID:            key.CropID,
+				CropName:          key.CropName,
+				Season:            key.Season,
+				Year:              key.Year,
+				ExpenseByCategory: map[string]float64{},
+				IncomeByCategory:  map[string]float64{},
+			}
+			reports[key] = rep
+			order = append(order, key)
+		}
+		if r.ID.Kind == ledger_models.KindExpense {
+			rep.Expenses += r.Total
+			rep.ExpenseByCategory[r.ID.Category] += r.Total
+		} else {
+			rep.Income += r.Total
+			rep.IncomeByCategory[r.ID.Category] += r.Total
+		}
+	}
+
+	plots, crops := loadAreas(ctx, ownerID)
+
+	out := make([]ledger_models.SeasonReport, 0, len(order))
+	cropFilter := strings.ToLower(strings.TrimSpace(c.Query("crop")))
+	for _, key := range order {
+		rep := reports[key]
+		if cropFilter != "" && rep.CropName != cropFilter {
+			continue
+		}
+		if p, ok := plots[rep.PlotID]; ok && !rep.PlotID.IsZero() {
+			rep.PlotName = p.Name
+			rep.AreaAcres = p.AreaAcres
+			for _, s := range p.Seasons {
+				if s.ID == rep.SeasonID {
+					rep.YieldValue, rep.YieldUnit = s.YieldValue, s.YieldUnit
+				}
+			}
+		} else if acres, ok := crops[rep.CropID]; ok && !rep.CropID.IsZero() {
+			rep.AreaAcres = acres
+		}
+		finish(rep)
+		out = append(out, *rep)
+	}
+
+	// Newest seasons first, unattributed spending last
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Year != out[j].Year {
+			return out[i].Year > out[j].Year
+		}
+		return out[i].CropName > out[j].CropName
+	})
+
+	if c.Query("format") == "csv" {
+		c.Header("Content-Type", "text/csv")
+		c.Header("Content-Disposition", "attachment; filename=season_report.csv")
+		w := csv.NewWriter(c.Writer)
+		w.Write([]string{"year", "season", "crop", "plot", "area_acres", "yield", "yield_unit", "expenses", "income", "profit", "cost_per_acre", "cost_per_unit", "cost_per_quintal", "profit_per_acre"})
+		for _, r := range out {
+			w.Write([]string{
+				yearString(r.Year), r.Season, r.CropName, r.PlotName,
+				strconv.FormatFloat(r.AreaAcres, 'f', 2, 64),
+				strconv.FormatFloat(r.YieldValue, 'f', -1, 64), r.YieldUnit,
+				money(r.Expenses), money(r.Income), money(r.Profit),
+				money(r.CostPerAcre), money(r.CostPerUnit), money(r.CostPerQtl), money(r.ProfitPerAcre),
+			})
+		}
+		w.Flush()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"seasons": out})
+}
+
+// loadAreas returns the farmer's plots by id and profile crop acreage by crop id
+func loadAreas(ctx context.Context, ownerID primitive.ObjectID) (map[primitive.ObjectID]farm_models.Plot, map[primitive.ObjectID]float64) {
+	plots := map[primitive.ObjectID]farm_models.Plot{}
+	opts := options.Find().SetProjection(bson.M{"name": 1, "area_acres": 1, "seasons": 1})
+	if cursor, err := database.GetCollection("farm_plots").Find(ctx, bson.M{"owner_id": ownerID}, opts); err == nil {
+		var list []farm_models.Plot
+		if cursor.All(ctx, &list) == nil {
+			for _, p := range list {
+				plots[p.ID] = p
+			}
+		}
+	}
+
+	crops := map[primitive.ObjectID]float64{}
+	var user auth.User
+	if database.GetCollection("users").FindOne(ctx, bson.M{"_id": ownerID}, options.FindOne().SetProjection(bson.M{"crops": 1})).Decode(&user) == nil {
+		for _, crop := range user.Crops {
+			crops[crop.ID] = crop.Acres()
+		}
+	}
+	return plots, crops
+}
+
+// finish derives profit and the per-acre and per-yield ratios
+func finish(r *ledger_models.SeasonReport) {
+	r.Profit = round2(r.Income - r.Expenses)
+	r.Expenses, r.Income = round2(r.Expenses), round2(r.Income)
+	if r.AreaAcres > 0 {
+		r.CostPerAcre = round2(r.Expenses / r.AreaAcres)
+		r.ProfitPerAcre = round2(r.Profit / r.AreaAcres)
+		r.AreaAcres = round2(r.AreaAcres)
+	}
+	if r.YieldValue > 0 {
+		r.CostPerUnit = round2(r.Expenses / r.YieldValue)
+		if kg, ok := farm_models.YieldUnits[r.YieldUnit]; ok {
+			r.CostPerQtl = round2(r.Expenses / (r.YieldValue * kg / 100))
+		}
+	}
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
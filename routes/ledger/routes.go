@@ -0,0 +1,51 @@
+package ledger
+
+import (
+	"context"
+	"time"
+
+	"Agromi/core/router"
+	"Agromi/database"
+	"Agromi/routes/auth"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	router.Register(func(r *gin.Engine) {
+		group := r.Group("/api/ledger", auth.RequireAuth(), auth.RequireRole(auth.RoleUser))
+		{
+			group.POST("/entries", CreateEntry)
+			group.GET("/entries", ListEntries)
+			group.PUT("/entries/:id", UpdateEntry)
+			group.DELETE("/entries/:id", DeleteEntry)
+
+			group.POST("/sync-orders", SyncOrders)
+			group.GET("/reports/seasons", SeasonReports)
+		}
+	})
+
+	go createLedgerIndexes()
+}
+
+// createLedgerIndexes backs listing, reports and idempotent order posting
+func createLedgerIndexes() {
+	if !database.WaitForClient(30 * time.Second) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, _ = database.GetCollection("ledger_entries").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "date", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "year", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "owner_id", Value: 1}, {Key: "order_id", Value: 1}, {Key: "kind", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"order_id": bson.M{"$exists": true}}),
+		},
+	})
+}
@@ -0,0 +1,210 @@
+package mandi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"Agromi/database"
+	"Agromi/routes/auth"
+	mandi_models "Agromi/routes/mandi/models"
+	"Agromi/routes/social"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// scopeFilter matches the prices an alert (or a query) covers.
+// Market keys are "<state>|<district>|<market>", so regions are key prefixes.
+func scopeFilter(commodity, variety, state, district, market string) bson.M {
+	filter := bson.M{"commodity_key": strings.ToLower(commodity)}
+	if variety != "" {
+		filter["variety"] = primitive.Regex{Pattern: "^" + regexp.QuoteMeta(variety) + "$", Options: "i"}
+	}
+	switch {
+	case market != "":
+		filter["market_key"] = strings.ToLower(market)
+	case state != "" && district != "":
+		filter["market_key"] = primitive.Regex{Pattern: "^" + regexp.QuoteMeta(strings.ToLower(state+"|"+district+"|"))}
+	case state != "":
+		filter["market_key"] = primitive.Regex{Pattern: "^" + regexp.QuoteMeta(strings.ToLower(state+"|"))}
+	}
+	return filter
+}
+
+// alertPrice returns the price an alert compares against: on the latest arrival date in scope,
+// the best modal price for "above" alerts and the lowest for "below" alerts
+func alertPrice(ctx context.Context, a mandi_models.Alert) (mandi_models.Price, bool) {
+	coll := database.GetCollection("mandi_prices")
+	filter := scopeFilter(a.Commodity, a.Variety, a.State, a.District, a.MarketKey)
+
+	var latest mandi_models.Price
+	if err := coll.FindOne(ctx, filter, options.FindOne().SetSort(bson.M{"date": -1})).Decode(&latest); err != nil {
+		return latest, false
+	}
+	filter["date"] = latest.Date
+	order := -1
+	if a.Direction == mandi_models.AlertBelow {
+		order = 1
+	}
+	var p mandi_models.Price
+	if err := coll.FindOne(ctx, filter, options.FindOne().SetSort(bson.M{"modal_price": order})).Decode(&p); err != nil {
+		return latest, true
+	}
+	return p, true
+}
+
+// evaluateAlerts checks every alert on a commodity after new prices arrive.
+// An alert fires once when its condition becomes true and re-arms when it stops holding.
+func evaluateAlerts(ctx context.Context, commodity string) int {
+	coll := database.GetCollection("mandi_alerts")
+	cursor, err := coll.Find(ctx, bson.M{"commodity": commodity})
+	if err != nil {
+		return 0
+	}
+	var alerts []mandi_models.Alert
+	if err := cursor.All(ctx, &alerts); err != nil {
+		return 0
+	}
+
+	fired := 0
+	for _, a := range alerts {
+		p, ok := alertPrice(ctx, a)
+		if !ok {
+			continue
+		}
+		hit := p.ModalPrice >= a.Threshold
+		if a.Direction == mandi_models.AlertBelow {
+			hit = p.ModalPrice <= a.Threshold
+		}
+
+		if hit == a.Triggered {
+			continue
+		}
+		set := bson.M{"triggered": hit, "last_price": p.ModalPrice, "last_market": p.Market}
+		if hit {
+			set["last_triggered_at"] = time.Now()
+		}
+		// Conditional on the old state so concurrent imports notify once
+		res, err := coll.UpdateOne(ctx, bson.M{"_id": a.ID, "triggered": a.Triggered}, bson.M{"$set": set})
+		if err != nil || res.ModifiedCount == 0 || !hit {
+			continue
+		}
+		msg := fmt.Sprintf("%s is ₹%.0f/quintal at %s (%s), %s your alert of ₹%.0f",
+			p.Commodity, p.ModalPrice, p.Market, p.Date.Format("02 Jan"), a.Direction, a.Threshold)
+		social.CreateNotification(ctx, a.UserID, "mandi_price_alert", msg, a.ID)
+		fired++
+	}
+	return fired
+}
+
+// CreateAlert subscribes the farmer to a price threshold
+func CreateAlert(c *gin.Context) {
+	var body struct {
+		Commodity string  `json:"commodity" binding:"required"`
+		Variety   string  `json:"variety"`
+		State     string  `json:"state"`
+		District  string  `json:"district"`
+		MarketKey string  `json:"market_key"`
+		Direction string  `json:"direction" binding:"required,oneof=above below"`
+		Threshold float64 `json:"threshold" binding:"required,gt=0"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if body.District != "" && body.State == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "district needs a state"})
+		return
+	}
+	userID := auth.CurrentUserID(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	coll := database.GetCollection("mandi_alerts")
+	if n, _ := coll.CountDocuments(ctx, bson.M{"user_id": userID}); n >= mandi_models.MaxAlertsPerUser {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d price alerts allowed", mandi_models.MaxAlertsPerUser)})
+		return
+	}
+
+	alert := mandi_models.Alert{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		Commodity: strings.ToLower(strings.TrimSpace(body.Commodity)),
+		Variety:   strings.TrimSpace(body.Variety),
+		State:     strings.TrimSpace(body.State),
+		District:  strings.TrimSpace(body.District),
+		MarketKey: strings.ToLower(strings.TrimSpace(body.MarketKey)),
+		Direction: body.Direction,
+		Threshold: body.Threshold,
+		CreatedAt: time.Now(),
+	}
+
+	// Start from the current state so an alert that already holds does not fire immediately
+	if p, ok := alertPrice(ctx, alert); ok {
+		alert.LastPrice, alert.LastMarket = p.ModalPrice, p.Market
+		if alert.Direction == mandi_models.AlertAbove {
+			alert.Triggered = p.ModalPrice >= alert.Threshold
+		} else {
+			alert.Triggered = p.ModalPrice <= alert.Threshold
+		}
+	}
+
+	if _, err := coll.InsertOne(ctx, alert); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create alert"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, alert)
+}
+
+// ListAlerts returns the farmer's price alerts
+func ListAlerts(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := database.GetCollection("mandi_alerts").Find(ctx,
+		bson.M{"user_id": auth.CurrentUserID(c)},
+		options.Find().SetSort(bson.M{"created_at": -1}),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	alerts := []mandi_models.Alert{}
+	if err := cursor.All(ctx, &alerts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error parsing alerts"})
+		return
+	}
+	c.JSON(http.StatusOK, alerts)
+}
+
+// DeleteAlert removes one of the farmer's price alerts
+func DeleteAlert(c *gin.Context) {
+	alertID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := database.GetCollection("mandi_alerts").DeleteOne(ctx, bson.M{"_id": alertID, "user_id": auth.CurrentUserID(c)})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete alert"})
+		return
+	}
+	if res.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Alert not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Alert deleted"})
+}
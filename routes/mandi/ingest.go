@@ -0,0 +1,245 @@
+package mandi
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"Agromi/database"
+	mandi_models "Agromi/routes/mandi/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ImportResult summarizes one imported file
+type ImportResult struct {
+	Records  int      `json:"records"`
+	Inserted int64    `json:"inserted"`
+	Updated  int64    `json:"updated"`
+	Skipped  int      `json:"skipped"`
+	Markets  int      `json:"markets"`
+	Alerts   int      `json:"alerts_triggered"`
+	Errors   []string `json:"errors,omitempty"` // First few rejected rows
+}
+
+const maxReportedErrors = 20
+
+// Date layouts seen in data.gov.in exports
+var dateLayouts = []string{"02/01/2006", "2006-01-02", "02-01-2006", "2/1/2006"}
+
+// normalizeHeader maps the export's column names onto one spelling:
+// "Min_x0020_Price", "Min Price" and "min_price" all become "min_price"
+func normalizeHeader(h string) string {
+	h = strings.ToLower(strings.TrimSpace(h))
+	h = strings.TrimPrefix(h, "\ufeff") // Excel adds a byte order mark
+	h = strings.ReplaceAll(h, "_x0020_", "_")
+	h = strings.ReplaceAll(h, " ", "_")
+	return h
+}
+
+// ParseCSV reads the data.gov.in mandi price CSV
+// (State, District, Market, Commodity, Variety, Grade, Arrival_Date, Min/Max/Modal Price)
+func ParseCSV(r io.Reader) ([]map[string]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("empty or unreadable CSV")
+	}
+	for i := range header {
+		header[i] = normalizeHeader(header[i])
+	}
+
+	var rows []map[string]string
+	for {
+		rec, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("CSV line %d: %v", len(rows)+2, err)
+		}
+		row := make(map[string]string, len(header))
+		for i, v := range rec {
+			if i < len(header) {
+				row[header[i]] = strings.TrimSpace(v)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// ParseJSON reads the data.gov.in API response ({"records": [...]}) or a bare array of records
+func ParseJSON(r io.Reader) ([]map[string]string, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []map[string]interface{}
+	var wrapped struct {
+		Records []map[string]interface{} `json:"records"`
+	}
+	if err := json.Unmarshal(raw, &wrapped); err == nil && wrapped.Records != nil {
+		records = wrapped.Records
+	} else if err := json.Unmarshal(raw, &records); err != nil {
+		return nil, errors.New("JSON must be an array of records or an object with a records array")
+	}
+
+	rows := make([]map[string]string, 0, len(records))
+	for _, rec := range records {
+		row := make(map[string]string, len(rec))
+		for k, v := range rec {
+			switch t := v.(type) {
+			case string:
+				row[normalizeHeader(k)] = strings.TrimSpace(t)
+			case float64:
+				row[normalizeHeader(k)] = strconv.FormatFloat(t, 'f', -1, 64)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// marketKey identifies a mandi across files
+func marketKey(state, district, market string) string {
+	return strings.ToLower(state + "|" + district + "|" + market)
+}
+
+// toPrice validates one parsed row
+func toPrice(row map[string]string, source string, now time.Time) (mandi_models.Price, error) {
+	p := mandi_models.Price{
+		State:      row["state"],
+		District:   row["district"],
+		Market:     row["market"],
+		Commodity:  row["commodity"],
+		Variety:    row["variety"],
+		Grade:      row["grade"],
+		Source:     source,
+		ImportedAt: now,
+	}
+	if p.Market == "" || p.Commodity == "" {
+		return p, errors.New("market and commodity required")
+	}
+
+	var err error
+	date := row["arrival_date"]
+	for _, layout := range dateLayouts {
+		if p.Date, err = time.Parse(layout, date); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return p, fmt.Errorf("invalid arrival_date %q", date)
+	}
+	if p.Date.After(now.Add(48 * time.Hour)) {
+		return p, fmt.Errorf("arrival_date %s is in the future", date)
+	}
+
+	prices := []*float64{&p.MinPrice, &p.MaxPrice, &p.ModalPrice}
+	for i, key := range []string{"min_price", "max_price", "modal_price"} {
+		v, err := strconv.ParseFloat(strings.ReplaceAll(row[key], ",", ""), 64)
+		if err != nil || v < 0 {
+			return p, fmt.Errorf("invalid %s %q", key, row[key])
+		}
+		*prices[i] = v
+	}
+	if p.ModalPrice == 0 {
+		return p, errors.New("modal_price is zero")
+	}
+	if p.MinPrice > p.MaxPrice {
+		p.MinPrice, p.MaxPrice = p.MaxPrice, p.MinPrice
+	}
+
+	p.MarketKey = marketKey(p.State, p.District, p.Market)
+	p.CommodityKey = strings.ToLower(p.Commodity)
+	return p, nil
+}
+
+// Import upserts parsed rows into mandi_prices and mandi_markets, then evaluates price alerts
+func Import(ctx context.Context, rows []map[string]string, source string) (ImportResult, error) {
+	res := ImportResult{Records: len(rows)}
+	now := time.Now()
+
+	prices := database.GetCollection("mandi_prices")
+	var models []mongo.WriteModel
+	markets := map[string]mandi_models.Market{}
+	commodities := map[string]bool{}
+
+	flush := func() error {
+		if len(models) == 0 {
+			return nil
+		}
+		out, err := prices.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		if out != nil {
+			res.Inserted += out.UpsertedCount
+			res.Updated += out.MatchedCount
+		}
+		models = models[:0]
+		return err
+	}
+
+	for i, row := range rows {
+		p, err := toPrice(row, source, now)
+		if err != nil {
+			res.Skipped++
+			if len(res.Errors) < maxReportedErrors {
+				res.Errors = append(res.Errors, fmt.Sprintf("record %d: %v", i+1, err))
+			}
+			continue
+		}
+		commodities[p.CommodityKey] = true
+		markets[p.MarketKey] = mandi_models.Market{Key: p.MarketKey, Name: p.Market, State: p.State, District: p.District}
+
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"market_key": p.MarketKey, "commodity_key": p.CommodityKey, "variety": p.Variety, "grade": p.Grade, "date": p.Date}).
+			SetUpdate(bson.M{
+				"$set": bson.M{
+					"market": p.Market, "state": p.State, "district": p.District, "commodity": p.Commodity,
+					"min_price": p.MinPrice, "max_price": p.MaxPrice, "modal_price": p.ModalPrice,
+					"source": p.Source, "imported_at": p.ImportedAt,
+				},
+				"$setOnInsert": bson.M{"_id": primitive.NewObjectID()},
+			}).
+			SetUpsert(true))
+		if len(models) == 1000 {
+			if err := flush(); err != nil {
+				return res, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return res, err
+	}
+
+	// Markets keep any admin-set location
+	var marketModels []mongo.WriteModel
+	for _, m := range markets {
+		marketModels = append(marketModels, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": m.Key}).
+			SetUpdate(bson.M{"$set": bson.M{"name": m.Name, "state": m.State, "district": m.District, "updated_at": now}}).
+			SetUpsert(true))
+	}
+	if len(marketModels) > 0 {
+		database.GetCollection("mandi_markets").BulkWrite(ctx, marketModels, options.BulkWrite().SetOrdered(false))
+	}
+	res.Markets = len(markets)
+
+	for commodity := range commodities {
+		res.Alerts += evaluateAlerts(ctx, commodity)
+	}
+	return res, nil
+}
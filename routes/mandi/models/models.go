@@ -0,0 +1,80 @@
+package mandi_models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Price is one day's arrival price of a commodity at a mandi (collection: mandi_prices).
+// Prices are rupees per quintal, as published on data.gov.in.
+// Documents are keyed by market, commodity, variety, grade and date, so re-importing a file updates in place.
+type Price struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	MarketKey    string             `bson:"market_key" json:"market_key"` // "<state>|<district>|<market>", lowercase
+	Market       string             `bson:"market" json:"market"`
+	State        string             `bson:"state" json:"state"`
+	District     string             `bson:"district" json:"district"`
+	Commodity    string             `bson:"commodity" json:"commodity"`
+	CommodityKey string             `bson:"commodity_key" json:"-"` // Lowercase, for matching
+	Variety      string             `bson:"variety" json:"variety"`
+	Grade        string             `bson:"grade" json:"grade"`
+	Date         time.Time          `bson:"date" json:"date"` // Arrival date, midnight UTC
+	MinPrice     float64            `bson:"min_price" json:"min_price"`
+	MaxPrice     float64            `bson:"max_price" json:"max_price"`
+	ModalPrice   float64            `bson:"modal_price" json:"modal_price"`
+	Source       string             `bson:"source,omitempty" json:"source,omitempty"` // Imported file name
+	ImportedAt   time.Time          `bson:"imported_at" json:"imported_at"`
+}
+
+// Market is a mandi seen in price files (collection: mandi_markets).
+// Location is filled by admins so prices can be searched by distance.
+type Market struct {
+	Key       string    `bson:"_id" json:"key"`
+	Name      string    `bson:"name" json:"name"`
+	State     string    `bson:"state" json:"state"`
+	District  string    `bson:"district" json:"district"`
+	Location  *GeoJSON  `bson:"location,omitempty" json:"location,omitempty"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// GeoJSON point
+type GeoJSON struct {
+	Type        string    `bson:"type" json:"type"`
+	Coordinates []float64 `bson:"coordinates" json:"coordinates"` // [longitude, latitude]
+}
+
+// Alert directions
+const (
+	AlertAbove = "above"
+	AlertBelow = "below"
+)
+
+// Alert notifies a farmer when the modal price of a commodity crosses a threshold (collection: mandi_alerts).
+// Scope narrows the markets considered: a market key, else district/state, else anywhere.
+type Alert struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Commodity string             `bson:"commodity" json:"commodity"` // Lowercase commodity key
+	Variety   string             `bson:"variety,omitempty" json:"variety,omitempty"`
+	State     string             `bson:"state,omitempty" json:"state,omitempty"`
+	District  string             `bson:"district,omitempty" json:"district,omitempty"`
+	MarketKey string             `bson:"market_key,omitempty" json:"market_key,omitempty"`
+	Direction string             `bson:"direction" json:"direction"` // above or below
+	Threshold float64            `bson:"threshold" json:"threshold"` // Rs/quintal
+
+	// Triggered is set while the condition holds, so one crossing sends one notification
+	Triggered       bool       `bson:"triggered" json:"triggered"`
+	LastPrice       float64    `bson:"last_price,omitempty" json:"last_price,omitempty"`
+	LastMarket      string     `bson:"last_market,omitempty" json:"last_market,omitempty"`
+	LastTriggeredAt *time.Time `bson:"last_triggered_at,omitempty" json:"last_triggered_at,omitempty"`
+	CreatedAt       time.Time  `bson:"created_at" json:"created_at"`
+}
+
+// Limits
+const (
+	MaxImportBytes   = 50 << 20
+	MaxAlertsPerUser = 20
+	DefaultRadiusKm  = 100
+	MaxRadiusKm      = 500
+)
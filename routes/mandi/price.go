@@ -0,0 +1,321 @@
+package mandi
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"Agromi/database"
+	mandi_models "Agromi/routes/mandi/models"
+	"Agromi/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Latest prices older than this are not "current"
+const latestWindow = 30 * 24 * time.Hour
+
+// scopeFromQuery reads commodity/variety/state/district/market from the query string
+func scopeFromQuery(c *gin.Context) bson.M {
+	return scopeFilter(c.Query("commodity"), c.Query("variety"), c.Query("state"), c.Query("district"), c.Query("market"))
+}
+
+// LatestPrices returns each nearby mandi's most recent price per commodity, variety and grade.
+// Query: lat, lon, radius_km (markets with a known location), or state/district; commodity optional.
+func LatestPrices(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := scopeFromQuery(c)
+	if c.Query("commodity") == "" {
+		delete(filter, "commodity_key")
+	}
+	filter["date"] = bson.M{"$gte": time.Now().Add(-latestWindow)}
+
+	// Distance per market key when searching around a point
+	distances := map[string]float64{}
+	lat, errLat := strconv.ParseFloat(c.Query("lat"), 64)
+	lon, errLon := strconv.ParseFloat(c.Query("lon"), 64)
+	if errLat == nil && errLon == nil {
+		radius, err := strconv.ParseFloat(c.Query("radius_km"), 64)
+		if err != nil || radius <= 0 {
+			radius = mandi_models.DefaultRadiusKm
+		}
+		radius = math.Min(radius, mandi_models.MaxRadiusKm)
+
+		cursor, err := database.GetCollection("mandi_markets").Aggregate(ctx, []bson.M{
+			{"$geoNear": bson.M{
+				"near":          bson.M{"type": "Point", "coordinates": []float64{lon, lat}},
+				"distanceField": "distance",
+				"maxDistance":   radius * 1000,
+				"spherical":     true,
+			}},
+			{"$limit": 50},
+			{"$project": bson.M{"distance": 1}},
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		var near []struct {
+			Key      string  `bson:"_id"`
+			Distance float64 `bson:"distance"`
+		}
+		if err := cursor.All(ctx, &near); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error parsing markets"})
+			return
+		}
+		keys := make([]string, 0, len(near))
+		for _, m := range near {
+			keys = append(keys, m.Key)
+			distances[m.Key] = math.Round(m.Distance/100) / 10
+		}
+		filter["market_key"] = bson.M{"$in": keys}
+	} else if c.Query("state") == "" && c.Query("market") == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "lat and lon, or state, required"})
+		return
+	}
+
+	limit := utils.ParseLimit(c.Query("limit"), 100, 500)
+	cursor, err := database.GetCollection("mandi_prices").Aggregate(ctx, []bson.M{
+		{"$match": filter},
+		{"$sort": bson.M{"date": -1}},
+		{"$group": bson.M{
+			"_id":   bson.M{"market": "$market_key", "commodity": "$commodity_key", "variety": "$variety", "grade": "$grade"},
+			"price": bson.M{"$first": "$$ROOT"},
+		}},
+		{"$replaceRoot": bson.M{"newRoot": "$price"}},
+		{"$sort": bson.D{{Key: "commodity_key", Value: 1}, {Key: "modal_price", Value: -1}}},
+		{"$limit": limit},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	var prices []mandi_models.Price
+	if err := cursor.All(ctx, &prices); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error parsing prices"})
+		return
+	}
+
+	type latestPrice struct {
+		mandi_models.Price
+		DistanceKm *float64 `json:"distance_km,omitempty"`
+	}
+	out := make([]latestPrice, 0, len(prices))
+	for _, p := range prices {
+		row := latestPrice{Price: p}
+		if d, ok := distances[p.MarketKey]; ok {
+			row.DistanceKm = &d
+		}
+		out = append(out, row)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"prices": out})
+}
+
+// PriceTrend returns a commodity's price series bucketed by day, week or month.
+// Query: commodity (required), variety, state, district, market, from/to (YYYY-MM-DD, default last 90 days), interval.
+func PriceTrend(c *gin.Context) {
+	if c.Query("commodity") == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "commodity required"})
+		return
+	}
+	formats := map[string]string{"day": "%Y-%m-%d", "week": "%G-W%V", "month": "%Y-%m"}
+	interval := c.DefaultQuery("interval", "day")
+	format, ok := formats[interval]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "interval must be day, week or month"})
+		return
+	}
+
+	to := time.Now()
+	if t, err := time.Parse("2006-01-02", c.Query("to")); err == nil {
+		to = t.AddDate(0, 0, 1)
+	}
+	from := to.AddDate(0, 0, -90)
+	if f, err := time.Parse("2006-01-02", c.Query("from")); err == nil {
+		from = f
+	}
+	if to.Sub(from) > 3*366*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "range is limited to 3 years"})
+		return
+	}
+
+	filter := scopeFromQuery(c)
+	filter["date"] = bson.M{"$gte": from, "$lt": to}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	cursor, err := database.GetCollection("mandi_prices").Aggregate(ctx, []bson.M{
+		{"$match": filter},
+		{"$group": bson.M{
+			"_id":       bson.M{"$dateToString": bson.M{"format": format, "date": "$date"}},
+			"min_price": bson.M{"$min": "$min_price"},
+			"max_price": bson.M{"$max": "$max_price"},
+			"avg_modal": bson.M{"$avg": "$modal_price"},
+			"markets":   bson.M{"$addToSet": "$market_key"},
+			"records":   bson.M{"$sum": 1},
+		}},
+		{"$project": bson.M{
+			"period":    "$_id",
+			"min_price": 1,
+			"max_price": 1,
+			"avg_modal": bson.M{"$round": []interface{}{"$avg_modal", 0}},
+			"markets":   bson.M{"$size": "$markets"},
+			"records":   1,
+		}},
+		{"$sort": bson.M{"_id": 1}},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	series := []bson.M{}
+	if err := cursor.All(ctx, &series); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error parsing trend"})
+		return
+	}
+	for _, s := range series {
+		delete(s, "_id")
+	}
+
+	c.JSON(http.StatusOK, gin.H{"commodity": strings.ToLower(c.Query("commodity")), "interval": interval, "series": series})
+}
+
+// PriceStats returns min/max/modal statistics over the last N days (default 30) and the change
+// against the N days before, overall and per variety.
+// Query: commodity (required), variety, state, district, market, days.
+func PriceStats(c *gin.Context) {
+	if c.Query("commodity") == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "commodity required"})
+		return
+	}
+	days := int(utils.ParseLimit(c.Query("days"), 30, 365))
+	now := time.Now()
+	start := now.AddDate(0, 0, -days)
+	prevStart := start.AddDate(0, 0, -days)
+
+	filter := scopeFromQuery(c)
+	filter["date"] = bson.M{"$gte": prevStart}
+
+	stats := bson.M{
+		"min_price":    bson.M{"$min": "$min_price"},
+		"max_price":    bson.M{"$max": "$max_price"},
+		"avg_modal":    bson.M{"$avg": "$modal_price"},
+		"lowest_modal": bson.M{"$min": "$modal_price"},
+		"top_modal":    bson.M{"$max": "$modal_price"},
+		"markets":      bson.M{"$addToSet": "$market_key"},
+		"records":      bson.M{"$sum": 1},
+	}
+	current := bson.M{"$match": bson.M{"date": bson.M{"$gte": start}}}
+	byVariety := bson.M{"_id": "$variety"}
+	for k, v := range stats {
+		byVariety[k] = v
+	}
+	overall := bson.M{"_id": nil}
+	for k, v := range stats {
+		overall[k] = v
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	cursor, err := database.GetCollection("mandi_prices").Aggregate(ctx, []bson.M{
+		{"$match": filter},
+		{"$facet": bson.M{
+			"current":    []bson.M{current, {"$group": overall}},
+			"previous":   []bson.M{{"$match": bson.M{"date": bson.M{"$lt": start}}}, {"$group": bson.M{"_id": nil, "avg_modal": bson.M{"$avg": "$modal_price"}}}},
+			"by_variety": []bson.M{current, {"$group": byVariety}, {"$sort": bson.M{"records": -1}}, {"$limit": 20}},
+			"latest":     []bson.M{{"$sort": bson.M{"date": -1}}, {"$limit": 1}, {"$project": bson.M{"date": 1}}},
+		}},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	type statRow struct {
+		Variety     string   `bson:"_id" json:"variety,omitempty"`
+		MinPrice    float64  `bson:"min_price" json:"min_price"`
+		MaxPrice    float64  `bson:"max_price" json:"max_price"`
+		AvgModal    float64  `bson:"avg_modal" json:"avg_modal"`
+		LowestModal float64  `bson:"lowest_modal" json:"lowest_modal"`
+		TopModal    float64  `bson:"top_modal" json:"top_modal"`
+		Markets     []string `bson:"markets" json:"-"`
+		MarketCount int      `bson:"-" json:"markets"`
+		Records     int      `bson:"records" json:"records"`
+	}
+	var result []struct {
+		Current   []statRow `bson:"current"`
+		ByVariety []statRow `bson:"by_variety"`
+		Previous  []struct {
+			AvgModal float64 `bson:"avg_modal"`
+		} `bson:"previous"`
+		Latest []struct {
+			Date time.Time `bson:"date"`
+		} `bson:"latest"`
+	}
+	if err := cursor.All(ctx, &result); err != nil || len(result) == 0 {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error parsing stats"})
+		return
+	}
+	r := result[0]
+	if len(r.Current) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No prices in this period"})
+		return
+	}
+
+	finish := func(s *statRow) {
+		s.AvgModal = math.Round(s.AvgModal)
+		s.MarketCount = len(s.Markets)
+	}
+	cur := r.Current[0]
+	finish(&cur)
+	for i := range r.ByVariety {
+		finish(&r.ByVariety[i])
+	}
+
+	resp := gin.H{
+		"commodity":  strings.ToLower(c.Query("commodity")),
+		"days":       days,
+		"stats":      cur,
+		"by_variety": r.ByVariety,
+	}
+	if len(r.Latest) > 0 {
+		resp["latest_date"] = r.Latest[0].Date
+	}
+	if len(r.Previous) > 0 && r.Previous[0].AvgModal > 0 {
+		prev := math.Round(r.Previous[0].AvgModal)
+		resp["previous_avg_modal"] = prev
+		resp["change_pct"] = math.Round((cur.AvgModal-prev)/prev*1000) / 10
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ListCommodities returns commodities with prices in the last 30 days, optionally within a state
+func ListCommodities(c *gin.Context) {
+	filter := bson.M{"date": bson.M{"$gte": time.Now().Add(-latestWindow)}}
+	if state := c.Query("state"); state != "" {
+		filter = scopeFilter("", "", state, c.Query("district"), "")
+		delete(filter, "commodity_key")
+		filter["date"] = bson.M{"$gte": time.Now().Add(-latestWindow)}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	values, err := database.GetCollection("mandi_prices").Distinct(ctx, "commodity", filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"commodities": values})
+}
@@ -0,0 +1,65 @@
+package mandi
+
+import (
+	"context"
+	"time"
+
+	"Agromi/core/router"
+	"Agromi/database"
+	"Agromi/routes/auth"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	router.Register(func(r *gin.Engine) {
+		group := r.Group("/api/mandi")
+		{
+			group.GET("/latest", LatestPrices)
+			group.GET("/trend", PriceTrend)
+			group.GET("/stats", PriceStats)
+			group.GET("/commodities", ListCommodities)
+		}
+
+		alerts := r.Group("/api/mandi/alerts", auth.RequireAuth(), auth.RequireRole(auth.RoleUser))
+		{
+			alerts.POST("", CreateAlert)
+			alerts.GET("", ListAlerts)
+			alerts.DELETE("/:id", DeleteAlert)
+		}
+	})
+
+	go createMandiIndexes()
+}
+
+// createMandiIndexes backs idempotent imports, series queries and nearby markets
+func createMandiIndexes() {
+	if !database.WaitForClient(30 * time.Second) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, _ = database.GetCollection("mandi_prices").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "market_key", Value: 1}, {Key: "commodity_key", Value: 1},
+				{Key: "variety", Value: 1}, {Key: "grade", Value: 1}, {Key: "date", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "commodity_key", Value: 1}, {Key: "date", Value: -1}}},
+		{Keys: bson.D{{Key: "date", Value: -1}}},
+	})
+	_, _ = database.GetCollection("mandi_markets").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "location", Value: "2dsphere"}}},
+	})
+	_, _ = database.GetCollection("mandi_alerts").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "commodity", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+	})
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Order Status
+const (
+	OrderStatusPending   = "pending"
+	OrderStatusAccepted  = "accepted"
+	OrderStatusCompleted = "completed" // Delivered, or rental returned
+	OrderStatusCancelled = "cancelled"
+)
+
+// Order is a purchase or rental of a marketplace product (collection: market_orders)
+type Order struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ProductID   primitive.ObjectID `json:"product_id" bson:"product_id"`
+	ProductType string             `json:"product_type" bson:"product_type"` // buy, sell, rent (copied from Product.Type)
+	ProductName string             `json:"product_name" bson:"product_name"`
+	Category    string             `json:"category" bson:"category"`
+
+	BuyerID  primitive.ObjectID `json:"buyer_id" bson:"buyer_id"`
+	SellerID primitive.ObjectID `json:"seller_id" bson:"seller_id"` // Product.OwnerID (zero for admin catalogue items)
+
+	Quantity  float64 `json:"quantity" bson:"quantity"`
+	Unit      string  `json:"unit" bson:"unit"`
+	UnitPrice float64 `json:"unit_price" bson:"unit_price"`
+	Total     float64 `json:"total" bson:"total"`
+
+	// Rentals only
+	RentalStart *time.Time `json:"rental_start,omitempty" bson:"rental_start,omitempty"`
+	RentalEnd   *time.Time `json:"rental_end,omitempty" bson:"rental_end,omitempty"`
+
+	Status      string     `json:"status" bson:"status"`
+	CreatedAt   time.Time  `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" bson:"updated_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" bson:"completed_at,omitempty"`
+
+	// Signed-in seller (or admin, for catalogue items) who accepted and completed the order.
+	// Orders without them predate sessions and grant neither ledger entries nor contact.
+	AcceptedBy  primitive.ObjectID `json:"-" bson:"accepted_by,omitempty"`
+	CompletedBy primitive.ObjectID `json:"-" bson:"completed_by,omitempty"`
+}
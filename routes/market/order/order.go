@@ -0,0 +1,213 @@
+package order
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"Agromi/database"
+	"Agromi/routes/auth"
+	"Agromi/routes/ledger"
+	market "Agromi/routes/market/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CreateOrder places a purchase or rental request for a listing on behalf of the signed-in user
+func CreateOrder(c *gin.Context) {
+	var body struct {
+		ProductID   string     `json:"product_id" binding:"required"`
+		Quantity    float64    `json:"quantity" binding:"required,gt=0"`
+		RentalStart *time.Time `json:"rental_start"` // Required for rent listings
+		RentalEnd   *time.Time `json:"rental_end"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	productID, err := primitive.ObjectIDFromHex(body.ProductID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Product ID"})
+		return
+	}
+	buyerID := auth.CurrentUserID(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var product market.Product
+	err = database.GetCollection("market_products").FindOne(ctx, bson.M{"_id": productID, "is_blocked": false}).Decode(&product)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+	if product.OwnerID == buyerID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot order your own listing"})
+		return
+	}
+
+	if product.Type == market.TypeRent {
+		if body.RentalStart == nil || body.RentalEnd == nil || !body.RentalEnd.After(*body.RentalStart) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "rental_start and rental_end (after start) are required for rentals"})
+			return
+		}
+	}
+
+	now := time.Now()
+	order := market.Order{
+		ID:          primitive.NewObjectID(),
+		ProductID:   product.ID,
+		ProductType: product.Type,
+		ProductName: product.Name,
+		Category:    product.Category,
+		BuyerID:     buyerID,
+		SellerID:    product.OwnerID,
+		Quantity:    body.Quantity,
+		Unit:        product.Unit,
+		UnitPrice:   product.Price,
+		Total:       product.Price * body.Quantity,
+		RentalStart: body.RentalStart,
+		RentalEnd:   body.RentalEnd,
+		Status:      market.OrderStatusPending,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if _, err := database.GetCollection("market_orders").InsertOne(ctx, order); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to place order"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Order placed", "id": order.ID})
+}
+
+// UpdateOrderStatus moves an order through its lifecycle.
+// Seller: pending -> accepted -> completed. Either party may cancel before completion.
+// The acting party is the signed-in user.
+// Orders for the admin catalogue have no seller and are handled under /api/admin/market.
+func UpdateOrderStatus(c *gin.Context) {
+	orderID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var body struct {
+		Status string `json:"status" binding:"required,oneof=accepted completed cancelled"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	actorID := auth.CurrentUserID(c)
+
+	// Who may make each move
+	filter := bson.M{"_id": orderID}
+	if body.Status == market.OrderStatusCancelled {
+		filter["$or"] = []bson.M{{"seller_id": actorID}, {"buyer_id": actorID}}
+	} else {
+		filter["seller_id"] = actorID
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := SetStatus(ctx, filter, body.Status, actorID); err != nil {
+		writeStatusError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Order " + body.Status})
+}
+
+// SetStatus applies a status change to the order matching filter if its current state allows it.
+// filter decides who may act and actorID is the signed-in user acting; orders it completes are
+// posted to the farm ledgers.
+func SetStatus(ctx context.Context, filter bson.M, status string, actorID primitive.ObjectID) (market.Order, error) {
+	// Allowed previous states
+	switch status {
+	case market.OrderStatusAccepted:
+		filter["status"] = market.OrderStatusPending
+	case market.OrderStatusCompleted:
+		filter["status"] = market.OrderStatusAccepted
+	case market.OrderStatusCancelled:
+		filter["status"] = bson.M{"$in": []string{market.OrderStatusPending, market.OrderStatusAccepted}}
+	}
+
+	now := time.Now()
+	set := bson.M{"status": status, "updated_at": now}
+	switch status {
+	case market.OrderStatusAccepted:
+		set["accepted_by"] = actorID
+	case market.OrderStatusCompleted:
+		set["completed_at"] = now
+		set["completed_by"] = actorID
+	}
+
+	var order market.Order
+	err := database.GetCollection("market_orders").FindOneAndUpdate(ctx, filter, bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&order)
+	if err != nil {
+		return order, err
+	}
+
+	// Completed purchases and rentals land in both parties' farm ledgers
+	if order.Status == market.OrderStatusCompleted {
+		ledger.PostOrder(ctx, order)
+	}
+	return order, nil
+}
+
+func writeStatusError(c *gin.Context, err error) {
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusConflict, gin.H{"error": "Order not found, not yours, or not in a state that allows this change"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Update failed"})
+}
+
+// ListOrders returns the signed-in user's orders as buyer (default) or seller
+func ListOrders(c *gin.Context) {
+	userID := auth.CurrentUserID(c)
+
+	filter := bson.M{"buyer_id": userID}
+	if c.Query("role") == "seller" {
+		filter = bson.M{"seller_id": userID}
+	}
+	if status := c.Query("status"); status != "" {
+		filter["status"] = status
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.M{"created_at": -1}).SetLimit(100)
+	cursor, err := database.GetCollection("market_orders").Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	orders := []market.Order{}
+	if err = cursor.All(ctx, &orders); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error parsing orders"})
+		return
+	}
+
+	c.JSON(http.StatusOK, orders)
+}
+
+func RegisterRoutes(router *gin.RouterGroup) {
+	orderGroup := router.Group("/order", auth.RequireAuth(), auth.RequireRole(auth.RoleUser))
+	{
+		orderGroup.POST("/create", CreateOrder)
+		orderGroup.PUT("/status/:id", UpdateOrderStatus)
+		orderGroup.GET("/list", ListOrders)
+	}
+}
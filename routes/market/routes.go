@@ -3,6 +3,7 @@ package market
 import (
 	"Agromi/core/router"
 	"Agromi/routes/market/buy"
+	"Agromi/routes/market/order"
 	"Agromi/routes/market/rent"
 	"Agromi/routes/market/sell"
 
@@ -17,6 +18,7 @@ func init() {
 			buy.RegisterRoutes(marketGroup)
 			rent.RegisterRoutes(marketGroup)
 			sell.RegisterRoutes(marketGroup)
+			order.RegisterRoutes(marketGroup)
 		}
 	})
 }
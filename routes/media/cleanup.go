@@ -0,0 +1,188 @@
+package media
+
+import (
+	"context"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"Agromi/database"
+	media_models "Agromi/routes/media/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	go runCleanupJob()
+}
+
+const (
+	cleanupInterval = time.Hour
+	orphanGrace     = 24 * time.Hour     // Time a client has to attach an upload to something
+	recheckAfter    = 7 * 24 * time.Hour // Referenced files are checked again after this
+	orphanBatch     = 500
+)
+
+// mediaRefs lists every field that can hold a media URL.
+// Viewers is nil for public content; otherwise it narrows the documents to those the viewer may see.
+var mediaRefs = []struct {
+	Collection string
+	Fields     []string
+	Viewers    func(ctx context.Context, viewerID primitive.ObjectID, userType string) bson.M
+}{
+	{"community_posts", []string{"media_url", "attachments.url"}, nil},
+	{"community_post_revisions", []string{"media_url", "attachments.url"}, nil},
+	{"community_answers", []string{"media_url"}, nil},
+	{"comments", []string{"media_url"}, nil},
+	{"messages", []string{"media_url"}, chatParticipants},
+	{"market_products", []string{"image_url"}, nil},
+	{"consultants", []string{"profile_photo_url", "gallery_photo_urls", "video_urls"}, nil},
+	{"users", []string{"profile_photo_url"}, nil},
+	{"pest_incidents", []string{"photo_urls"}, incidentReviewers},
+}
+
+var mediaIDPattern = regexp.MustCompile(`/api/media/([0-9a-f]{24})`)
+
+// runCleanupJob periodically drops expired upload sessions and unreferenced media
+func runCleanupJob() {
+	if !database.WaitForClient(30 * time.Second) {
+		return
+	}
+
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		cleanupSessions(ctx)
+		if n, err := cleanupOrphans(ctx, time.Now()); err != nil {
+			log.Println("media cleanup:", err)
+		} else if n > 0 {
+			log.Println("media cleanup: removed", n, "orphaned files")
+		}
+		cancel()
+		<-ticker.C
+	}
+}
+
+// cleanupSessions deletes expired resumable uploads and their parts
+func cleanupSessions(ctx context.Context) {
+	coll := database.GetCollection("media_upload_sessions")
+	cursor, err := coll.Find(ctx, bson.M{"expires_at": bson.M{"$lte": time.Now()}})
+	if err != nil {
+		return
+	}
+	var sessions []media_models.UploadSession
+	if cursor.All(ctx, &sessions) != nil {
+		return
+	}
+	for _, s := range sessions {
+		os.Remove(s.TempPath)
+		coll.DeleteOne(ctx, bson.M{"_id": s.ID})
+	}
+}
+
+// cleanupOrphans deletes uploads older than the grace period that nothing refers to.
+// Returns the number of files removed.
+func cleanupOrphans(ctx context.Context, now time.Time) (int, error) {
+	filter := bson.M{
+		"created_at": bson.M{"$lt": now.Add(-orphanGrace)},
+		"$or": []bson.M{
+			{"checked_at": bson.M{"$exists": false}},
+			{"checked_at": bson.M{"$lt": now.Add(-recheckAfter)}},
+		},
+	}
+	opts := options.Find().SetSort(bson.M{"created_at": 1}).SetLimit(orphanBatch)
+	cursor, err := database.GetCollection("media_files").Find(ctx, filter, opts)
+	if err != nil {
+		return 0, err
+	}
+	var candidates []media_models.MediaFile
+	if err := cursor.All(ctx, &candidates); err != nil {
+		return 0, err
+	}
+	if len(candidates) == 0 {
+		return 0, nil
+	}
+
+	hexIDs := make([]string, len(candidates))
+	for i, mf := range candidates {
+		hexIDs[i] = mf.ID.Hex()
+	}
+	referenced, err := findReferences(ctx, hexIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	var keep []primitive.ObjectID
+	for _, mf := range candidates {
+		if referenced[mf.ID.Hex()] {
+			keep = append(keep, mf.ID)
+			continue
+		}
+		if err := removeMedia(ctx, mf); err != nil {
+			log.Println("media cleanup:", mf.ID.Hex(), err)
+			continue
+		}
+		removed++
+	}
+	if len(keep) > 0 {
+		database.GetCollection("media_files").UpdateMany(ctx, bson.M{"_id": bson.M{"$in": keep}}, bson.M{"$set": bson.M{"checked_at": now}})
+	}
+	return removed, nil
+}
+
+// findReferences returns which of the media ids appear in any media URL field
+func findReferences(ctx context.Context, hexIDs []string) (map[string]bool, error) {
+	pattern := "/api/media/(" + strings.Join(hexIDs, "|") + ")"
+	found := map[string]bool{}
+	for _, ref := range mediaRefs {
+		coll := database.GetCollection(ref.Collection)
+		for _, field := range ref.Fields {
+			values, err := coll.Distinct(ctx, field, bson.M{field: primitive.Regex{Pattern: pattern}})
+			if err != nil {
+				return nil, err
+			}
+			for _, v := range values {
+				s, ok := v.(string)
+				if !ok {
+					continue
+				}
+				for _, m := range mediaIDPattern.FindAllStringSubmatch(s, -1) {
+					found[m[1]] = true
+				}
+			}
+		}
+	}
+	return found, nil
+}
+
+// createMediaIndexes backs the orphan sweep, session expiry and the reference lookups of canView
+func createMediaIndexes() {
+	if !database.WaitForClient(30 * time.Second) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, _ = database.GetCollection("media_files").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	_, _ = database.GetCollection("media_upload_sessions").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "expires_at", Value: 1}},
+	})
+	for _, ref := range mediaRefs {
+		models := make([]mongo.IndexModel, len(ref.Fields))
+		for i, field := range ref.Fields {
+			models[i] = mongo.IndexModel{Keys: bson.D{{Key: field, Value: 1}}}
+		}
+		_, _ = database.GetCollection(ref.Collection).Indexes().CreateMany(ctx, models)
+	}
+}
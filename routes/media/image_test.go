@@ -0,0 +1,105 @@
+package media
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// exifJPEG builds the header of a JPEG whose APP1 segment carries the given orientation
+// in IFD0, preceded by an APP0 segment as most cameras write it
+func exifJPEG(order binary.ByteOrder, orientation uint16) []byte {
+	tiff := make([]byte, 8+2+12+4)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8) // IFD0 right after the header
+	order.PutUint16(tiff[8:], 1) // One entry
+	order.PutUint16(tiff[10:], 0x0112)
+	order.PutUint16(tiff[12:], 3) // SHORT
+	order.PutUint32(tiff[14:], 1)
+	order.PutUint16(tiff[18:], orientation)
+
+	app1 := append([]byte("Exif\x00\x00"), tiff...)
+	app0 := []byte("JFIF\x00\x01\x01\x00\x00\x01\x00\x01\x00\x00")
+
+	data := []byte{0xFF, 0xD8}
+	data = appendSegment(data, 0xE0, app0)
+	data = appendSegment(data, 0xE1, app1)
+	return appendSegment(data, 0xDA, []byte{0, 0})
+}
+
+func appendSegment(data []byte, marker byte, payload []byte) []byte {
+	data = append(data, 0xFF, marker, 0, 0)
+	binary.BigEndian.PutUint16(data[len(data)-2:], uint16(len(payload)+2))
+	return append(data, payload...)
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	truncated := exifJPEG(binary.BigEndian, 6)
+
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"little endian", exifJPEG(binary.LittleEndian, 6), 6},
+		{"big endian", exifJPEG(binary.BigEndian, 8), 8},
+		{"upright", exifJPEG(binary.BigEndian, 1), 1},
+		{"out of range value", exifJPEG(binary.LittleEndian, 9), 1},
+		{"no exif", appendSegment([]byte{0xFF, 0xD8}, 0xDA, []byte{0, 0}), 1},
+		{"not a jpeg", []byte("\x89PNG\r\n\x1a\n"), 1},
+		{"empty", nil, 1},
+		{"truncated segment", truncated[:30], 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jpegOrientation(tt.data); got != tt.want {
+				t.Errorf("jpegOrientation = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrient(t *testing.T) {
+	// 3x2 source with a red top-left and a blue top-right pixel
+	red := color.RGBA{R: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+	src := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	src.Set(0, 0, red)
+	src.Set(2, 0, blue)
+
+	tests := []struct {
+		orientation int
+		w, h        int
+		red, blue   image.Point
+	}{
+		{1, 3, 2, image.Pt(0, 0), image.Pt(2, 0)},
+		{2, 3, 2, image.Pt(2, 0), image.Pt(0, 0)},
+		{3, 3, 2, image.Pt(2, 1), image.Pt(0, 1)},
+		{4, 3, 2, image.Pt(0, 1), image.Pt(2, 1)},
+		{5, 2, 3, image.Pt(0, 0), image.Pt(0, 2)},
+		{6, 2, 3, image.Pt(1, 0), image.Pt(1, 2)},
+		{7, 2, 3, image.Pt(1, 2), image.Pt(1, 0)},
+		{8, 2, 3, image.Pt(0, 2), image.Pt(0, 0)},
+		{0, 3, 2, image.Pt(0, 0), image.Pt(2, 0)},
+		{9, 3, 2, image.Pt(0, 0), image.Pt(2, 0)},
+	}
+	for _, tt := range tests {
+		got := orient(src, tt.orientation)
+		if b := got.Bounds(); b.Dx() != tt.w || b.Dy() != tt.h {
+			t.Errorf("orientation %d: size %dx%d, want %dx%d", tt.orientation, b.Dx(), b.Dy(), tt.w, tt.h)
+			continue
+		}
+		if c := color.RGBAModel.Convert(got.At(tt.red.X, tt.red.Y)); c != red {
+			t.Errorf("orientation %d: red pixel not at %v", tt.orientation, tt.red)
+		}
+		if c := color.RGBAModel.Convert(got.At(tt.blue.X, tt.blue.Y)); c != blue {
+			t.Errorf("orientation %d: blue pixel not at %v", tt.orientation, tt.blue)
+		}
+	}
+}
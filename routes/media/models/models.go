@@ -0,0 +1,55 @@
+package media_models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Media kinds
+const (
+	KindImage = "image"
+	KindVideo = "video"
+	KindAudio = "audio"
+)
+
+// Size limits per kind, in bytes
+const (
+	MaxImageBytes = 15 << 20
+	MaxVideoBytes = 200 << 20
+	MaxAudioBytes = 30 << 20
+)
+
+// MediaFile is a stored upload (collection: media_files).
+// Clients store URL ("/api/media/<id>") in MediaURL/ImageURL fields; it redirects to a signed URL.
+type MediaFile struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OwnerID     primitive.ObjectID `bson:"owner_id" json:"owner_id"`
+	Kind        string             `bson:"kind" json:"kind"`
+	ContentType string             `bson:"content_type" json:"content_type"`
+	Size        int64              `bson:"size" json:"size"`
+	Width       int                `bson:"width,omitempty" json:"width,omitempty"`
+	Height      int                `bson:"height,omitempty" json:"height,omitempty"`
+	Key         string             `bson:"key" json:"-"`
+	ThumbKey    string             `bson:"thumb_key,omitempty" json:"-"`
+	Filename    string             `bson:"filename,omitempty" json:"filename,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	CheckedAt   *time.Time         `bson:"checked_at,omitempty" json:"-"` // Last time the orphan sweep found it referenced
+
+	// Filled per request, never stored
+	URL          string `bson:"-" json:"url"`
+	ThumbnailURL string `bson:"-" json:"thumbnail_url,omitempty"`
+}
+
+// UploadSession tracks a resumable upload (collection: media_upload_sessions).
+// Chunks are appended to a temp file on the server that accepted the session.
+type UploadSession struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OwnerID   primitive.ObjectID `bson:"owner_id" json:"owner_id"`
+	Filename  string             `bson:"filename,omitempty" json:"filename,omitempty"`
+	Size      int64              `bson:"size" json:"size"`
+	Received  int64              `bson:"received" json:"received"`
+	TempPath  string             `bson:"temp_path" json:"-"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	ExpiresAt time.Time          `bson:"expires_at" json:"expires_at"`
+}
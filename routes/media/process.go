@@ -0,0 +1,201 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "image/gif" // Register GIF decoding
+
+	"Agromi/database"
+	media_models "Agromi/routes/media/models"
+	"Agromi/storage"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Validation errors, mapped to HTTP statuses by the handlers
+var (
+	ErrUnsupportedType = errors.New("unsupported file type")
+	ErrTooLarge        = errors.New("file too large")
+	ErrBadImage        = errors.New("image could not be decoded")
+)
+
+// allowedTypes maps accepted content types to their media kind and stored extension
+var allowedTypes = map[string]struct{ Kind, Ext string }{
+	"image/jpeg":      {media_models.KindImage, ".jpg"},
+	"image/png":       {media_models.KindImage, ".png"},
+	"image/gif":       {media_models.KindImage, ".png"}, // First frame, re-encoded as PNG
+	"video/mp4":       {media_models.KindVideo, ".mp4"},
+	"video/webm":      {media_models.KindVideo, ".webm"},
+	"audio/mpeg":      {media_models.KindAudio, ".mp3"},
+	"audio/mp4":       {media_models.KindAudio, ".m4a"},
+	"audio/wave":      {media_models.KindAudio, ".wav"},
+	"application/ogg": {media_models.KindAudio, ".ogg"}, // Opus voice notes
+}
+
+// maxBytes is the size limit of a media kind
+func maxBytes(kind string) int64 {
+	switch kind {
+	case media_models.KindVideo:
+		return media_models.MaxVideoBytes
+	case media_models.KindAudio:
+		return media_models.MaxAudioBytes
+	default:
+		return media_models.MaxImageBytes
+	}
+}
+
+// sniffType detects the content type from the file's first bytes; the client's claim is ignored
+func sniffType(f io.ReadSeeker) (string, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	head = head[:n]
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	// net/http does not know the M4A brand used by phone voice recorders
+	if len(head) >= 12 && string(head[4:8]) == "ftyp" && string(head[8:11]) == "M4A" {
+		return "audio/mp4", nil
+	}
+	ct := http.DetectContentType(head)
+	if i := strings.Index(ct, ";"); i >= 0 {
+		ct = ct[:i]
+	}
+	return ct, nil
+}
+
+// storeUpload validates the file at path, strips image metadata, makes a thumbnail and
+// saves everything to the store. The caller owns (and removes) path.
+func storeUpload(ctx context.Context, path string, ownerID primitive.ObjectID, filename string) (*media_models.MediaFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	ct, err := sniffType(f)
+	if err != nil {
+		return nil, err
+	}
+	allowed, ok := allowedTypes[ct]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, ct)
+	}
+	if info.Size() > maxBytes(allowed.Kind) {
+		return nil, ErrTooLarge
+	}
+
+	mf := &media_models.MediaFile{
+		ID:          primitive.NewObjectID(),
+		OwnerID:     ownerID,
+		Kind:        allowed.Kind,
+		ContentType: ct,
+		Size:        info.Size(),
+		Filename:    filepath.Base(filename),
+		CreatedAt:   time.Now(),
+	}
+	mf.Key = fmt.Sprintf("%s/%s%s", ownerID.Hex(), mf.ID.Hex(), allowed.Ext)
+
+	store := storage.Default()
+	if allowed.Kind != media_models.KindImage {
+		// Audio and video are stored as uploaded
+		if err := store.Put(ctx, mf.Key, f, mf.Size, ct); err != nil {
+			return nil, err
+		}
+	} else {
+		full, thumb, w, h, err := processImage(f, ct)
+		if err != nil {
+			return nil, err
+		}
+		mf.Width, mf.Height = w, h
+		mf.ContentType = "image/jpeg"
+		if allowed.Ext == ".png" {
+			mf.ContentType = "image/png"
+		}
+		mf.Size = int64(len(full))
+		mf.ThumbKey = fmt.Sprintf("%s/%s_thumb.jpg", ownerID.Hex(), mf.ID.Hex())
+
+		if err := store.Put(ctx, mf.Key, bytes.NewReader(full), mf.Size, mf.ContentType); err != nil {
+			return nil, err
+		}
+		if err := store.Put(ctx, mf.ThumbKey, bytes.NewReader(thumb), int64(len(thumb)), "image/jpeg"); err != nil {
+			store.Delete(ctx, mf.Key)
+			return nil, err
+		}
+	}
+
+	if _, err := database.GetCollection("media_files").InsertOne(ctx, mf); err != nil {
+		store.Delete(ctx, mf.Key)
+		if mf.ThumbKey != "" {
+			store.Delete(ctx, mf.ThumbKey)
+		}
+		return nil, err
+	}
+	return mf, nil
+}
+
+// processImage decodes the image, applies its EXIF orientation, downsizes it and re-encodes it.
+// Re-encoding drops all metadata (EXIF, GPS location, comments). Returns the image and a JPEG thumbnail.
+func processImage(f io.Reader, contentType string) (full, thumb []byte, w, h int, err error) {
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, nil, 0, 0, err
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, 0, 0, ErrBadImage
+	}
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return nil, nil, 0, 0, fmt.Errorf("%w: image dimensions too large", ErrTooLarge)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, 0, 0, ErrBadImage
+	}
+	if contentType == "image/jpeg" {
+		img = orient(img, jpegOrientation(data))
+	}
+
+	b := img.Bounds()
+	w, h = fitWithin(b.Dx(), b.Dy(), maxImageEdge)
+	scaled := resize(img, w, h)
+
+	var buf bytes.Buffer
+	if contentType == "image/jpeg" {
+		err = jpeg.Encode(&buf, scaled, &jpeg.Options{Quality: 85})
+	} else {
+		err = png.Encode(&buf, scaled)
+	}
+	if err != nil {
+		return nil, nil, 0, 0, err
+	}
+	full = buf.Bytes()
+
+	tw, th := fitWithin(w, h, thumbEdge)
+	var tbuf bytes.Buffer
+	if err := jpeg.Encode(&tbuf, flatten(resize(scaled, tw, th)), &jpeg.Options{Quality: 80}); err != nil {
+		return nil, nil, 0, 0, err
+	}
+	return full, tbuf.Bytes(), w, h, nil
+}
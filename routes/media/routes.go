@@ -0,0 +1,28 @@
+package media
+
+import (
+	"Agromi/core/router"
+	"Agromi/routes/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	router.Register(func(r *gin.Engine) {
+		group := r.Group("/api/media")
+		{
+			group.POST("/upload", auth.RequireAuth(), UploadMedia)
+			group.POST("/upload/session", auth.RequireAuth(), StartUploadSession)
+			group.GET("/upload/session/:id", auth.RequireAuth(), GetUploadSession)
+			group.PUT("/upload/session/:id", auth.RequireAuth(), UploadChunk)
+
+			group.GET("/file/*key", ServeLocalFile) // storage.LocalURLPrefix
+			group.GET("/:id", auth.OptionalAuth(), RedirectMedia)
+			group.GET("/:id/thumb", auth.OptionalAuth(), RedirectThumbnail)
+			group.GET("/:id/signed", auth.OptionalAuth(), GetSignedURL)
+			group.DELETE("/:id", auth.RequireAuth(), DeleteMedia)
+		}
+	})
+
+	go createMediaIndexes()
+}
@@ -0,0 +1,253 @@
+package media
+
+import (
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"Agromi/database"
+	"Agromi/routes/auth"
+	media_models "Agromi/routes/media/models"
+	"Agromi/storage"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	redirectURLTTL = 15 * time.Minute
+	maxSignedTTL   = 24 * time.Hour
+)
+
+// findMedia loads the media named by :id, writing an error response if it does not exist
+func findMedia(c *gin.Context) (media_models.MediaFile, bool) {
+	var mf media_models.MediaFile
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return mf, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := database.GetCollection("media_files").FindOne(ctx, bson.M{"_id": id}).Decode(&mf); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Media not found"})
+		return mf, false
+	}
+	return mf, true
+}
+
+// findVisibleMedia is findMedia for files the caller may see: their own uploads, files used in
+// public content, and files in chats or incident reports the caller takes part in.
+// Files the caller may not see are reported as missing.
+func findVisibleMedia(c *gin.Context) (media_models.MediaFile, bool) {
+	mf, ok := findMedia(c)
+	if !ok {
+		return mf, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	viewerID := auth.CurrentUserID(c)
+	if !viewerID.IsZero() && viewerID == mf.OwnerID {
+		return mf, true
+	}
+	visible, err := canView(ctx, mf.ID, viewerID, auth.CurrentUserType(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return mf, false
+	}
+	if !visible {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Media not found"})
+		return mf, false
+	}
+	return mf, true
+}
+
+// canView reports whether any document the viewer may see refers to the file.
+// References are the stable URLs from withURLs, matched exactly so the field indexes are used.
+func canView(ctx context.Context, id, viewerID primitive.ObjectID, userType string) (bool, error) {
+	mf := withURLs(&media_models.MediaFile{ID: id})
+	urls := bson.M{"$in": []string{mf.URL, mf.URL + "/thumb"}}
+	for _, ref := range mediaRefs {
+		clauses := make([]bson.M, len(ref.Fields))
+		for i, field := range ref.Fields {
+			clauses[i] = bson.M{field: urls}
+		}
+		filter := bson.M{"$or": clauses}
+		if ref.Viewers != nil {
+			if viewerID.IsZero() {
+				continue
+			}
+			scope := ref.Viewers(ctx, viewerID, userType)
+			if scope == nil {
+				continue
+			}
+			filter = bson.M{"$and": []bson.M{filter, scope}}
+		}
+		n, err := database.GetCollection(ref.Collection).CountDocuments(ctx, filter, options.Count().SetLimit(1))
+		if err != nil {
+			return false, err
+		}
+		if n > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// chatParticipants limits messages to those the viewer sent, received or can read in a group
+func chatParticipants(ctx context.Context, viewerID primitive.ObjectID, _ string) bson.M {
+	scope := []bson.M{{"sender_id": viewerID}, {"receiver_id": viewerID}}
+	groups, err := database.GetCollection("chat_groups").Distinct(ctx, "_id", bson.M{"member_ids": viewerID})
+	if err == nil && len(groups) > 0 {
+		scope = append(scope, bson.M{"group_id": bson.M{"$in": groups}})
+	}
+	return bson.M{"$or": scope}
+}
+
+// incidentReviewers lets consultants see incident photos; reporters see them as owners
+func incidentReviewers(_ context.Context, _ primitive.ObjectID, userType string) bson.M {
+	if userType != auth.RoleConsultant {
+		return nil
+	}
+	return bson.M{}
+}
+
+// RedirectMedia sends the client to a short-lived signed URL for the file.
+// This is the stable URL stored in posts, products and profiles; files in private
+// chats and incident reports need the viewer's token.
+func RedirectMedia(c *gin.Context) {
+	mf, ok := findVisibleMedia(c)
+	if !ok {
+		return
+	}
+	redirectTo(c, mf.Key)
+}
+
+// RedirectThumbnail is RedirectMedia for the image thumbnail
+func RedirectThumbnail(c *gin.Context) {
+	mf, ok := findVisibleMedia(c)
+	if !ok {
+		return
+	}
+	if mf.ThumbKey == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No thumbnail for this media"})
+		return
+	}
+	redirectTo(c, mf.ThumbKey)
+}
+
+func redirectTo(c *gin.Context, key string) {
+	signed, err := storage.Default().SignedURL(key, redirectURLTTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign URL"})
+		return
+	}
+	c.Header("Cache-Control", "private, max-age=600")
+	c.Redirect(http.StatusFound, signed)
+}
+
+// GetSignedURL returns signed URLs for the file and thumbnail. Query: ttl (seconds, default 900, max 86400).
+func GetSignedURL(c *gin.Context) {
+	mf, ok := findVisibleMedia(c)
+	if !ok {
+		return
+	}
+
+	ttl := redirectURLTTL
+	if s := c.Query("ttl"); s != "" {
+		secs, err := strconv.Atoi(s)
+		if err != nil || secs <= 0 || time.Duration(secs)*time.Second > maxSignedTTL {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ttl must be 1-86400 seconds"})
+			return
+		}
+		ttl = time.Duration(secs) * time.Second
+	}
+
+	store := storage.Default()
+	url, err := store.SignedURL(mf.Key, ttl)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign URL"})
+		return
+	}
+	resp := gin.H{"url": url, "expires_at": time.Now().Add(ttl)}
+	if mf.ThumbKey != "" {
+		if thumb, err := store.SignedURL(mf.ThumbKey, ttl); err == nil {
+			resp["thumbnail_url"] = thumb
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// ServeLocalFile serves objects of the local store behind a signed URL (expires, sig)
+func ServeLocalFile(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	if !storage.VerifyLocal(key, c.Query("expires"), c.Query("sig")) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid or expired link"})
+		return
+	}
+
+	rc, err := storage.Default().Open(c.Request.Context(), key)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	defer rc.Close()
+
+	if ct := mime.TypeByExtension(path.Ext(key)); ct != "" {
+		c.Header("Content-Type", ct)
+	}
+	// Seekable files get Range support, which video players rely on
+	if rs, ok := rc.(io.ReadSeeker); ok {
+		http.ServeContent(c.Writer, c.Request, "", time.Time{}, rs)
+		return
+	}
+	c.Status(http.StatusOK)
+	io.Copy(c.Writer, rc)
+}
+
+// DeleteMedia removes a file and its thumbnail. Only the signed-in uploader may delete it.
+func DeleteMedia(c *gin.Context) {
+	mf, ok := findMedia(c)
+	if !ok {
+		return
+	}
+	if auth.CurrentUserID(c) != mf.OwnerID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the uploader can delete this media"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := removeMedia(ctx, mf); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Media deleted"})
+}
+
+// removeMedia deletes the stored objects, then the record
+func removeMedia(ctx context.Context, mf media_models.MediaFile) error {
+	store := storage.Default()
+	if err := store.Delete(ctx, mf.Key); err != nil {
+		return err
+	}
+	if mf.ThumbKey != "" {
+		if err := store.Delete(ctx, mf.ThumbKey); err != nil {
+			return err
+		}
+	}
+	_, err := database.GetCollection("media_files").DeleteOne(ctx, bson.M{"_id": mf.ID})
+	return err
+}
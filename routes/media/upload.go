@@ -0,0 +1,287 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"Agromi/database"
+	"Agromi/routes/auth"
+	media_models "Agromi/routes/media/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	maxChunkBytes     = 8 << 20
+	sessionLifetime   = 24 * time.Hour
+	multipartOverhead = 1 << 20 // Room for multipart headers on top of the file itself
+)
+
+// tempDir holds multipart spools and resumable upload parts
+func tempDir() string {
+	dir := os.Getenv("MEDIA_TMP_DIR")
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "agromi-uploads")
+	}
+	os.MkdirAll(dir, 0o700)
+	return dir
+}
+
+// withURLs fills the stable URLs clients should store in MediaURL/ImageURL fields
+func withURLs(mf *media_models.MediaFile) *media_models.MediaFile {
+	mf.URL = "/api/media/" + mf.ID.Hex()
+	if mf.ThumbKey != "" {
+		mf.ThumbnailURL = mf.URL + "/thumb"
+	}
+	return mf
+}
+
+// respondUploadError maps validation errors to HTTP statuses
+func respondUploadError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrUnsupportedType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrBadImage):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store upload"})
+	}
+}
+
+// UploadMedia accepts a single multipart file (form field: file) owned by the signed-in user
+func UploadMedia(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, media_models.MaxVideoBytes+multipartOverhead)
+
+	ownerID := auth.CurrentUserID(c)
+	fh, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": ErrTooLarge.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file required"})
+		return
+	}
+
+	src, err := fh.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not read file"})
+		return
+	}
+	defer src.Close()
+
+	// Spool to disk so large videos never sit in memory
+	tmp, err := os.CreateTemp(tempDir(), "multipart-*")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store upload"})
+		return
+	}
+	defer os.Remove(tmp.Name())
+	_, err = io.Copy(tmp, src)
+	tmp.Close()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store upload"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	mf, err := storeUpload(ctx, tmp.Name(), ownerID, fh.Filename)
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, withURLs(mf))
+}
+
+// StartUploadSession opens a resumable upload for the signed-in user. Body: filename, size (bytes).
+func StartUploadSession(c *gin.Context) {
+	var body struct {
+		Filename string `json:"filename"`
+		Size     int64  `json:"size" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ownerID := auth.CurrentUserID(c)
+	// The type is only known once the first bytes arrive, so check against the largest limit here
+	if body.Size <= 0 || body.Size > media_models.MaxVideoBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": ErrTooLarge.Error()})
+		return
+	}
+
+	tmp, err := os.CreateTemp(tempDir(), "resumable-*")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start upload"})
+		return
+	}
+	tmp.Close()
+
+	now := time.Now()
+	session := media_models.UploadSession{
+		ID:        primitive.NewObjectID(),
+		OwnerID:   ownerID,
+		Filename:  body.Filename,
+		Size:      body.Size,
+		TempPath:  tmp.Name(),
+		CreatedAt: now,
+		ExpiresAt: now.Add(sessionLifetime),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := database.GetCollection("media_upload_sessions").InsertOne(ctx, session); err != nil {
+		os.Remove(tmp.Name())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start upload"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"session_id": session.ID, "received": 0, "size": session.Size, "max_chunk": maxChunkBytes, "expires_at": session.ExpiresAt})
+}
+
+// GetUploadSession reports how many bytes have arrived, so a client can resume after a drop
+func GetUploadSession(c *gin.Context) {
+	session, ok := loadSession(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"session_id": session.ID, "received": session.Received, "size": session.Size, "expires_at": session.ExpiresAt})
+}
+
+// UploadChunk appends bytes to a resumable upload. Header: Content-Range: bytes <start>-<end>/<size>.
+// start must equal the bytes received so far. The last chunk finalizes the upload and returns the media.
+func UploadChunk(c *gin.Context) {
+	session, ok := loadSession(c)
+	if !ok {
+		return
+	}
+
+	start, end, total, err := parseContentRange(c.GetHeader("Content-Range"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if total != session.Size {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Content-Range size does not match the session"})
+		return
+	}
+	if start != session.Received {
+		c.JSON(http.StatusConflict, gin.H{"error": "Unexpected offset", "received": session.Received})
+		return
+	}
+	length := end - start + 1
+	if length > maxChunkBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Chunk too large", "max_chunk": maxChunkBytes})
+		return
+	}
+
+	f, err := os.OpenFile(session.TempPath, os.O_WRONLY, 0o600)
+	if err != nil {
+		c.JSON(http.StatusGone, gin.H{"error": "Upload parts not found on this server, start a new session"})
+		return
+	}
+	_, err = f.Seek(start, io.SeekStart)
+	var n int64
+	if err == nil {
+		n, err = io.Copy(f, io.LimitReader(c.Request.Body, length))
+	}
+	f.Close()
+	if err != nil || n != length {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Incomplete chunk", "received": session.Received})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	sessions := database.GetCollection("media_upload_sessions")
+	// The offset filter rejects a concurrent write of the same range
+	res, err := sessions.UpdateOne(ctx, bson.M{"_id": session.ID, "received": start}, bson.M{"$set": bson.M{"received": end + 1}})
+	if err != nil || res.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Chunk already written"})
+		return
+	}
+
+	if end+1 < session.Size {
+		c.JSON(http.StatusOK, gin.H{"session_id": session.ID, "received": end + 1, "size": session.Size})
+		return
+	}
+
+	// Last chunk: validate and store like a multipart upload
+	defer func() {
+		os.Remove(session.TempPath)
+		sessions.DeleteOne(context.Background(), bson.M{"_id": session.ID})
+	}()
+	mf, err := storeUpload(ctx, session.TempPath, session.OwnerID, session.Filename)
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, withURLs(mf))
+}
+
+// loadSession fetches the signed-in user's live session named by :id, writing an error response if there is none
+func loadSession(c *gin.Context) (media_models.UploadSession, bool) {
+	var session media_models.UploadSession
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return session, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = database.GetCollection("media_upload_sessions").FindOne(ctx, bson.M{"_id": id, "owner_id": auth.CurrentUserID(c), "expires_at": bson.M{"$gt": time.Now()}}).Decode(&session)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload session not found or expired"})
+		return session, false
+	}
+	return session, true
+}
+
+// parseContentRange parses "bytes <start>-<end>/<size>"
+func parseContentRange(h string) (start, end, total int64, err error) {
+	invalid := errors.New("Content-Range must be: bytes <start>-<end>/<size>")
+	rest, ok := strings.CutPrefix(strings.TrimSpace(h), "bytes ")
+	if !ok {
+		return 0, 0, 0, invalid
+	}
+	rng, size, ok := strings.Cut(rest, "/")
+	if !ok {
+		return 0, 0, 0, invalid
+	}
+	s, e, ok := strings.Cut(rng, "-")
+	if !ok {
+		return 0, 0, 0, invalid
+	}
+	if start, err = strconv.ParseInt(s, 10, 64); err != nil {
+		return 0, 0, 0, invalid
+	}
+	if end, err = strconv.ParseInt(e, 10, 64); err != nil {
+		return 0, 0, 0, invalid
+	}
+	if total, err = strconv.ParseInt(size, 10, 64); err != nil {
+		return 0, 0, 0, invalid
+	}
+	if start < 0 || end < start || end >= total {
+		return 0, 0, 0, invalid
+	}
+	return start, end, total, nil
+}
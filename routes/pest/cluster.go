@@ -0,0 +1,208 @@
+package pest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"time"
+
+	"Agromi/database"
+	pest_models "Agromi/routes/pest/models"
+	"Agromi/routes/social"
+	"Agromi/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// nearFilter is the $near clause used for incidents, outbreaks and farmers
+func nearFilter(coordinates []float64, maxKm float64) bson.M {
+	return bson.M{
+		"$near": bson.M{
+			"$geometry": bson.M{
+				"type":        "Point",
+				"coordinates": coordinates,
+			},
+			"$maxDistance": maxKm * 1000,
+		},
+	}
+}
+
+// evaluateCluster looks for an outbreak around a new or reviewed incident.
+// Recent incidents of the same crop and label within ClusterRadiusKm form a cluster; with enough
+// distinct reporters (or a consultant confirmation) it becomes, or extends, an outbreak.
+func evaluateCluster(incidentID primitive.ObjectID) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	incidents := database.GetCollection("pest_incidents")
+	var inc pest_models.Incident
+	if err := incidents.FindOne(ctx, bson.M{"_id": incidentID}).Decode(&inc); err != nil {
+		return
+	}
+	if inc.Status == pest_models.StatusRejected || inc.Label == unknownLabel {
+		return
+	}
+
+	now := time.Now()
+	cursor, err := incidents.Find(ctx, bson.M{
+		"crop":        inc.Crop,
+		"label":       inc.Label,
+		"status":      bson.M{"$ne": pest_models.StatusRejected},
+		"reported_at": bson.M{"$gte": now.AddDate(0, 0, -pest_models.ClusterWindowDays)},
+		"location":    nearFilter(inc.Location.Coordinates, pest_models.ClusterRadiusKm),
+	}, options.Find().SetLimit(500))
+	if err != nil {
+		log.Println("Pest: cluster query failed:", err)
+		return
+	}
+	var members []pest_models.Incident
+	if err := cursor.All(ctx, &members); err != nil {
+		return
+	}
+
+	reporters := map[primitive.ObjectID]bool{}
+	confirmed, severity := 0, "low"
+	var sumLat, sumLon float64
+	first, last := now, time.Time{}
+	ids := make([]primitive.ObjectID, 0, len(members))
+	for _, m := range members {
+		reporters[m.ReporterID] = true
+		if m.Status == pest_models.StatusConfirmed {
+			confirmed++
+		}
+		if pest_models.SeverityWeight[m.Severity] > pest_models.SeverityWeight[severity] {
+			severity = m.Severity
+		}
+		sumLon += m.Location.Coordinates[0]
+		sumLat += m.Location.Coordinates[1]
+		if m.ReportedAt.Before(first) {
+			first = m.ReportedAt
+		}
+		if m.ReportedAt.After(last) {
+			last = m.ReportedAt
+		}
+		ids = append(ids, m.ID)
+	}
+	if len(reporters) < pest_models.OutbreakMinReports && !(confirmed > 0 && len(reporters) >= 2) {
+		return
+	}
+
+	centerLat, centerLon := sumLat/float64(len(members)), sumLon/float64(len(members))
+	radius := 1.0
+	for _, m := range members {
+		radius = math.Max(radius, utils.Haversine(centerLat, centerLon, m.Location.Coordinates[1], m.Location.Coordinates[0]))
+	}
+	center := pest_models.GeoJSON{Type: "Point", Coordinates: []float64{centerLon, centerLat}}
+
+	set := bson.M{
+		"center":         center,
+		"radius_km":      math.Round(radius*10) / 10,
+		"incident_count": len(members),
+		"reporters":      len(reporters),
+		"confirmed":      confirmed,
+		"severity":       severity,
+		"last_seen":      last,
+		"updated_at":     now,
+	}
+
+	// Extend a nearby active outbreak of the same pest rather than opening a second one
+	outbreaks := database.GetCollection("pest_outbreaks")
+	var outbreak pest_models.Outbreak
+	err = outbreaks.FindOne(ctx, bson.M{
+		"crop":   inc.Crop,
+		"label":  inc.Label,
+		"status": pest_models.OutbreakActive,
+		"center": nearFilter(inc.Location.Coordinates, 2*pest_models.ClusterRadiusKm),
+	}).Decode(&outbreak)
+	switch err {
+	case nil:
+		outbreaks.UpdateOne(ctx, bson.M{"_id": outbreak.ID}, bson.M{"$set": set})
+	case mongo.ErrNoDocuments:
+		outbreak = pest_models.Outbreak{
+			ID:        primitive.NewObjectID(),
+			Crop:      inc.Crop,
+			Category:  inc.Category,
+			Label:     inc.Label,
+			Status:    pest_models.OutbreakActive,
+			FirstSeen: first,
+			CreatedAt: now,
+		}
+		if _, err := outbreaks.InsertOne(ctx, outbreak); err != nil {
+			return
+		}
+		outbreaks.UpdateOne(ctx, bson.M{"_id": outbreak.ID}, bson.M{"$set": set})
+		log.Printf("Pest: new outbreak of %s on %s (%d reports)", inc.Label, inc.Crop, len(members))
+	default:
+		return
+	}
+
+	incidents.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}}, bson.M{"$set": bson.M{"outbreak_id": outbreak.ID}})
+
+	outbreak.Center, outbreak.RadiusKm, outbreak.Severity = center, radius, severity
+	alertFarmers(ctx, outbreak)
+}
+
+// alertFarmers notifies farmers growing the crop around an outbreak, each at most once per outbreak
+func alertFarmers(ctx context.Context, o pest_models.Outbreak) {
+	users := database.GetCollection("users")
+	cursor, err := users.Find(ctx, bson.M{
+		"user_type":    "farmer",
+		"is_blocked":   bson.M{"$ne": true},
+		"crops.name":   primitive.Regex{Pattern: "^" + regexp.QuoteMeta(o.Crop) + "$", Options: "i"},
+		"geo_location": nearFilter(o.Center.Coordinates, pest_models.AlertRadiusKm+o.RadiusKm),
+	}, options.Find().SetProjection(bson.M{"_id": 1}).SetLimit(pest_models.MaxAlertRecipients))
+	if err != nil {
+		log.Println("Pest: alert query failed:", err)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	alerts := database.GetCollection("pest_outbreak_alerts")
+	msg := fmt.Sprintf("%s outbreak reported on %s near you (%s severity). Inspect your field.", capitalize(o.Label), o.Crop, o.Severity)
+	sent := 0
+	for cursor.Next(ctx) {
+		var u struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if cursor.Decode(&u) != nil {
+			continue
+		}
+		// The unique (outbreak_id, user_id) index turns repeat alerts into duplicate key errors
+		if _, err := alerts.InsertOne(ctx, pest_models.OutbreakAlert{OutbreakID: o.ID, UserID: u.ID, SentAt: time.Now()}); err != nil {
+			continue
+		}
+		social.CreateNotification(ctx, u.ID, "pest_outbreak", msg, o.ID)
+		sent++
+	}
+	if sent > 0 {
+		database.GetCollection("pest_outbreaks").UpdateOne(ctx, bson.M{"_id": o.ID}, bson.M{"$inc": bson.M{"alerted_users": sent}})
+	}
+}
+
+// runOutbreakJob resolves outbreaks that stopped receiving reports
+func runOutbreakJob() {
+	if !database.WaitForClient(30 * time.Second) {
+		log.Println("Pest: database not ready, outbreak job not started")
+		return
+	}
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		res, err := database.GetCollection("pest_outbreaks").UpdateMany(ctx,
+			bson.M{"status": pest_models.OutbreakActive, "last_seen": bson.M{"$lt": time.Now().AddDate(0, 0, -pest_models.ResolveAfterDays)}},
+			bson.M{"$set": bson.M{"status": pest_models.OutbreakResolved, "updated_at": time.Now()}},
+		)
+		if err == nil && res.ModifiedCount > 0 {
+			log.Printf("Pest: resolved %d quiet outbreaks", res.ModifiedCount)
+		}
+		cancel()
+		<-ticker.C
+	}
+}
@@ -0,0 +1,184 @@
+package pest
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"Agromi/database"
+	"Agromi/routes/auth"
+	pest_models "Agromi/routes/pest/models"
+	"Agromi/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// unknownLabel is used when the farmer cannot name the pest; such reports wait for a consultant
+const unknownLabel = "unknown"
+
+// normalize lowercases and collapses spaces in crop and pest names
+func normalize(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// ReportIncident records a pest or disease sighting and checks for an outbreak around it.
+// lat/lon default to the farmer's saved location.
+func ReportIncident(c *gin.Context) {
+	var body struct {
+		Crop        string   `json:"crop" binding:"required"`
+		Category    string   `json:"category" binding:"required,oneof=pest disease"`
+		Label       string   `json:"label"`
+		Severity    string   `json:"severity" binding:"required,oneof=low medium high"`
+		Description string   `json:"description"`
+		PhotoURLs   []string `json:"photo_urls"`
+		Lat         *float64 `json:"lat"`
+		Lon         *float64 `json:"lon"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(body.PhotoURLs) > pest_models.MaxPhotos {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Too many photos"})
+		return
+	}
+	if utf8.RuneCountInString(body.Description) > pest_models.MaxDescriptionChars {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Description too long"})
+		return
+	}
+	reporterID := auth.CurrentUserID(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var coordinates []float64
+	if body.Lat != nil && body.Lon != nil {
+		if *body.Lat < -90 || *body.Lat > 90 || *body.Lon < -180 || *body.Lon > 180 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid coordinates"})
+			return
+		}
+		coordinates = []float64{*body.Lon, *body.Lat}
+	} else {
+		var user auth.User
+		database.GetCollection("users").FindOne(ctx, bson.M{"_id": reporterID}, options.FindOne().SetProjection(bson.M{"geo_location": 1})).Decode(&user)
+		if user.GeoLocation == nil || len(user.GeoLocation.Coordinates) != 2 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "lat and lon required"})
+			return
+		}
+		coordinates = user.GeoLocation.Coordinates
+	}
+
+	label := normalize(body.Label)
+	if label == "" {
+		label = unknownLabel
+	}
+	photos := make([]string, 0, len(body.PhotoURLs))
+	for _, u := range body.PhotoURLs {
+		if u = strings.TrimSpace(u); u != "" {
+			photos = append(photos, u)
+		}
+	}
+
+	incident := pest_models.Incident{
+		ID:          primitive.NewObjectID(),
+		ReporterID:  reporterID,
+		Crop:        normalize(body.Crop),
+		Category:    body.Category,
+		Label:       label,
+		Severity:    body.Severity,
+		Description: strings.TrimSpace(body.Description),
+		PhotoURLs:   photos,
+		Location:    pest_models.GeoJSON{Type: "Point", Coordinates: coordinates},
+		Status:      pest_models.StatusReported,
+		ReportedAt:  time.Now(),
+	}
+	if _, err := database.GetCollection("pest_incidents").InsertOne(ctx, incident); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to report incident"})
+		return
+	}
+
+	// Clustering and alerting can take a while with many farmers around
+	go evaluateCluster(incident.ID)
+
+	c.JSON(http.StatusCreated, incident)
+}
+
+// ListMyIncidents returns the farmer's own reports, newest first
+func ListMyIncidents(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.M{"reported_at": -1}).SetLimit(100)
+	cursor, err := database.GetCollection("pest_incidents").Find(ctx, bson.M{"reporter_id": auth.CurrentUserID(c)}, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	incidents := []pest_models.Incident{}
+	if err := cursor.All(ctx, &incidents); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error parsing incidents"})
+		return
+	}
+	c.JSON(http.StatusOK, incidents)
+}
+
+// NearbyOutbreaks lists active outbreaks around a point, nearest first. Query: lat, lon, radius_km, crop
+func NearbyOutbreaks(c *gin.Context) {
+	lat, err1 := strconv.ParseFloat(c.Query("lat"), 64)
+	lon, err2 := strconv.ParseFloat(c.Query("lon"), 64)
+	if err1 != nil || err2 != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "lat and lon required"})
+		return
+	}
+	radius, err := strconv.ParseFloat(c.Query("radius_km"), 64)
+	if err != nil || radius <= 0 || radius > 200 {
+		radius = 50
+	}
+
+	filter := bson.M{
+		"status": pest_models.OutbreakActive,
+		"center": nearFilter([]float64{lon, lat}, radius),
+	}
+	if crop := normalize(c.Query("crop")); crop != "" {
+		filter["crop"] = crop
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := database.GetCollection("pest_outbreaks").Find(ctx, filter, options.Find().SetLimit(50))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	var outbreaks []pest_models.Outbreak
+	if err := cursor.All(ctx, &outbreaks); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error parsing outbreaks"})
+		return
+	}
+
+	type nearbyOutbreak struct {
+		pest_models.Outbreak
+		DistanceKm float64 `json:"distance_km"`
+	}
+	out := make([]nearbyOutbreak, 0, len(outbreaks))
+	for _, o := range outbreaks {
+		d := utils.Haversine(lat, lon, o.Center.Coordinates[1], o.Center.Coordinates[0])
+		out = append(out, nearbyOutbreak{Outbreak: o, DistanceKm: float64(int(d*10)) / 10})
+	}
+	c.JSON(http.StatusOK, gin.H{"outbreaks": out})
+}
@@ -0,0 +1,106 @@
+package pest_models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Categories
+const (
+	CategoryPest    = "pest"
+	CategoryDisease = "disease"
+)
+
+// Severities, weighted for outbreaks and heatmaps
+var SeverityWeight = map[string]int{"low": 1, "medium": 2, "high": 3}
+
+// Incident statuses
+const (
+	StatusReported  = "reported"
+	StatusConfirmed = "confirmed" // By a consultant
+	StatusRejected  = "rejected"  // Not a pest/disease, or a bad report
+)
+
+// Outbreak statuses
+const (
+	OutbreakActive   = "active"
+	OutbreakResolved = "resolved"
+)
+
+// Clustering and alerting parameters
+const (
+	ClusterRadiusKm     = 10 // Incidents this close belong to one cluster
+	ClusterWindowDays   = 7  // Only recent incidents count
+	OutbreakMinReports  = 3  // Distinct reporters needed for an outbreak
+	AlertRadiusKm       = 25 // Farmers growing the crop this close are alerted
+	ResolveAfterDays    = 14 // Outbreaks without new incidents are resolved
+	MaxAlertRecipients  = 5000
+	MaxPhotos           = 5
+	MaxDescriptionChars = 1000
+)
+
+// GeoJSON point
+type GeoJSON struct {
+	Type        string    `bson:"type" json:"type"`
+	Coordinates []float64 `bson:"coordinates" json:"coordinates"` // [longitude, latitude]
+}
+
+// Relabel records a consultant changing an incident's diagnosis
+type Relabel struct {
+	FromLabel    string             `bson:"from_label" json:"from_label"`
+	ToLabel      string             `bson:"to_label" json:"to_label"`
+	FromCategory string             `bson:"from_category" json:"from_category"`
+	ToCategory   string             `bson:"to_category" json:"to_category"`
+	ConsultantID primitive.ObjectID `bson:"consultant_id" json:"consultant_id"`
+	Note         string             `bson:"note,omitempty" json:"note,omitempty"`
+	At           time.Time          `bson:"at" json:"at"`
+}
+
+// Incident is a farmer's pest or disease sighting (collection: pest_incidents)
+type Incident struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ReporterID  primitive.ObjectID `bson:"reporter_id" json:"reporter_id"`
+	Crop        string             `bson:"crop" json:"crop"` // Lowercase
+	Category    string             `bson:"category" json:"category"`
+	Label       string             `bson:"label" json:"label"` // Lowercase, e.g. "pink bollworm"; "unknown" when unsure
+	Severity    string             `bson:"severity" json:"severity"`
+	Description string             `bson:"description,omitempty" json:"description,omitempty"`
+	PhotoURLs   []string           `bson:"photo_urls,omitempty" json:"photo_urls,omitempty"`
+	Location    GeoJSON            `bson:"location" json:"location"` // 2dsphere indexed
+
+	Status     string             `bson:"status" json:"status"`
+	ReviewedBy primitive.ObjectID `bson:"reviewed_by,omitempty" json:"reviewed_by,omitempty"`
+	ReviewedAt *time.Time         `bson:"reviewed_at,omitempty" json:"reviewed_at,omitempty"`
+	ReviewNote string             `bson:"review_note,omitempty" json:"review_note,omitempty"`
+	Relabels   []Relabel          `bson:"relabels,omitempty" json:"relabels,omitempty"`
+	OutbreakID primitive.ObjectID `bson:"outbreak_id,omitempty" json:"outbreak_id,omitempty"`
+	ReportedAt time.Time          `bson:"reported_at" json:"reported_at"`
+}
+
+// Outbreak is a cluster of incidents of one pest/disease on one crop (collection: pest_outbreaks)
+type Outbreak struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Crop          string             `bson:"crop" json:"crop"`
+	Category      string             `bson:"category" json:"category"`
+	Label         string             `bson:"label" json:"label"`
+	Center        GeoJSON            `bson:"center" json:"center"`
+	RadiusKm      float64            `bson:"radius_km" json:"radius_km"`
+	IncidentCount int                `bson:"incident_count" json:"incident_count"`
+	Reporters     int                `bson:"reporters" json:"reporters"`
+	Confirmed     int                `bson:"confirmed" json:"confirmed"` // Incidents confirmed by consultants
+	Severity      string             `bson:"severity" json:"severity"`   // Highest among incidents
+	Status        string             `bson:"status" json:"status"`
+	FirstSeen     time.Time          `bson:"first_seen" json:"first_seen"`
+	LastSeen      time.Time          `bson:"last_seen" json:"last_seen"`
+	AlertedUsers  int                `bson:"alerted_users" json:"alerted_users"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// OutbreakAlert records that a farmer was alerted about an outbreak (collection: pest_outbreak_alerts)
+type OutbreakAlert struct {
+	OutbreakID primitive.ObjectID `bson:"outbreak_id"`
+	UserID     primitive.ObjectID `bson:"user_id"`
+	SentAt     time.Time          `bson:"sent_at"`
+}
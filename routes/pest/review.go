@@ -0,0 +1,156 @@
+package pest
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"Agromi/database"
+	"Agromi/routes/auth"
+	consultant_models "Agromi/routes/consultant/models"
+	pest_models "Agromi/routes/pest/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// verifiedConsultant checks that the consultant may review incidents
+func verifiedConsultant(ctx context.Context, id primitive.ObjectID) (primitive.ObjectID, bool) {
+	n, _ := database.GetCollection("consultants").CountDocuments(ctx, bson.M{
+		"_id":                 id,
+		"verification_status": consultant_models.StatusVerified,
+		"is_blocked":          bson.M{"$ne": true},
+	})
+	return id, n > 0
+}
+
+// ReviewQueue lists incidents for consultants, nearest first.
+// Query: lat, lon, radius_km (default 100), status (default reported), crop
+func ReviewQueue(c *gin.Context) {
+	filter := bson.M{"status": c.DefaultQuery("status", pest_models.StatusReported)}
+	if crop := normalize(c.Query("crop")); crop != "" {
+		filter["crop"] = crop
+	}
+	opts := options.Find().SetLimit(100)
+
+	lat, err1 := strconv.ParseFloat(c.Query("lat"), 64)
+	lon, err2 := strconv.ParseFloat(c.Query("lon"), 64)
+	if err1 == nil && err2 == nil {
+		radius, err := strconv.ParseFloat(c.Query("radius_km"), 64)
+		if err != nil || radius <= 0 || radius > 500 {
+			radius = 100
+		}
+		filter["location"] = nearFilter([]float64{lon, lat}, radius)
+	} else {
+		opts.SetSort(bson.M{"reported_at": -1})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := database.GetCollection("pest_incidents").Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	incidents := []pest_models.Incident{}
+	if err := cursor.All(ctx, &incidents); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error parsing incidents"})
+		return
+	}
+	c.JSON(http.StatusOK, incidents)
+}
+
+// ReviewIncident lets a verified consultant confirm, reject or relabel an incident.
+// Relabelling also confirms it under the new diagnosis. Outbreaks are re-evaluated afterwards.
+func ReviewIncident(c *gin.Context) {
+	incidentID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var body struct {
+		Action   string `json:"action" binding:"required,oneof=confirm reject relabel"`
+		Label    string `json:"label"`    // Relabel: the correct pest/disease
+		Category string `json:"category"` // Relabel: pest or disease, defaults to unchanged
+		Note     string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	consultantID, ok := verifiedConsultant(ctx, auth.CurrentUserID(c))
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only verified consultants can review incidents"})
+		return
+	}
+
+	coll := database.GetCollection("pest_incidents")
+	var inc pest_models.Incident
+	if err := coll.FindOne(ctx, bson.M{"_id": incidentID}).Decode(&inc); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
+		return
+	}
+
+	now := time.Now()
+	set := bson.M{"reviewed_by": consultantID, "reviewed_at": now, "review_note": strings.TrimSpace(body.Note)}
+	update := bson.M{"$set": set}
+
+	switch body.Action {
+	case "confirm":
+		if inc.Label == unknownLabel {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Relabel an unknown incident instead of confirming it"})
+			return
+		}
+		set["status"] = pest_models.StatusConfirmed
+	case "reject":
+		set["status"] = pest_models.StatusRejected
+	case "relabel":
+		label := normalize(body.Label)
+		category := body.Category
+		if category == "" {
+			category = inc.Category
+		}
+		if label == "" || label == unknownLabel || (category != pest_models.CategoryPest && category != pest_models.CategoryDisease) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "relabel needs a label and category pest or disease"})
+			return
+		}
+		set["status"] = pest_models.StatusConfirmed
+		set["label"] = label
+		set["category"] = category
+		update["$push"] = bson.M{"relabels": pest_models.Relabel{
+			FromLabel:    inc.Label,
+			ToLabel:      label,
+			FromCategory: inc.Category,
+			ToCategory:   category,
+			ConsultantID: consultantID,
+			Note:         strings.TrimSpace(body.Note),
+			At:           now,
+		}}
+		// The old cluster no longer includes this incident
+		update["$unset"] = bson.M{"outbreak_id": ""}
+	}
+
+	err = coll.FindOneAndUpdate(ctx, bson.M{"_id": incidentID}, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&inc)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to review incident"})
+		return
+	}
+
+	go evaluateCluster(inc.ID)
+
+	c.JSON(http.StatusOK, inc)
+}
@@ -0,0 +1,58 @@
+package pest
+
+import (
+	"context"
+	"time"
+
+	"Agromi/core/router"
+	"Agromi/database"
+	"Agromi/routes/auth"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	router.Register(func(r *gin.Engine) {
+		farmer := r.Group("/api/pest")
+		{
+			farmer.POST("/incidents", auth.RequireAuth(), auth.RequireRole(auth.RoleUser), ReportIncident)
+			farmer.GET("/incidents/mine", auth.RequireAuth(), auth.RequireRole(auth.RoleUser), ListMyIncidents)
+			farmer.GET("/outbreaks/nearby", NearbyOutbreaks)
+		}
+
+		consultant := r.Group("/api/consultant/pest", auth.RequireAuth(), auth.RequireRole(auth.RoleConsultant))
+		{
+			consultant.GET("/incidents", ReviewQueue)
+			consultant.PUT("/incidents/:id/review", ReviewIncident)
+		}
+	})
+
+	go createPestIndexes()
+	go runOutbreakJob()
+}
+
+// createPestIndexes backs the $near clustering queries and one alert per farmer per outbreak
+func createPestIndexes() {
+	if !database.WaitForClient(30 * time.Second) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, _ = database.GetCollection("pest_incidents").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "location", Value: "2dsphere"}}},
+		{Keys: bson.D{{Key: "crop", Value: 1}, {Key: "label", Value: 1}, {Key: "reported_at", Value: -1}}},
+		{Keys: bson.D{{Key: "reporter_id", Value: 1}, {Key: "reported_at", Value: -1}}},
+	})
+	_, _ = database.GetCollection("pest_outbreaks").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "center", Value: "2dsphere"}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "last_seen", Value: 1}}},
+	})
+	_, _ = database.GetCollection("pest_outbreak_alerts").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "outbreak_id", Value: 1}, {Key: "user_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+	})
+}
@@ -0,0 +1,308 @@
+package recommend
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"Agromi/database"
+	"Agromi/routes/auth"
+	recommend_models "Agromi/routes/recommend/models"
+	"Agromi/routes/social"
+	"Agromi/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// candidateProjection is what scoring needs from a user document
+var candidateProjection = bson.M{"name": 1, "crops": 1, "geo_location": 1, "regional_language": 1, "last_active_at": 1, "user_type": 1}
+
+// Compute scores candidates for one farmer and stores the result
+func Compute(ctx context.Context, userID primitive.ObjectID) (recommend_models.Set, error) {
+	set := recommend_models.Set{UserID: userID, Items: []recommend_models.Item{}, ComputedAt: time.Now()}
+
+	users := database.GetCollection("users")
+	var viewer auth.User
+	if err := users.FindOne(ctx, bson.M{"_id": userID}, options.FindOne().SetProjection(candidateProjection)).Decode(&viewer); err != nil {
+		return set, err
+	}
+
+	followees := idsOf(ctx, "follows", bson.M{"follower_id": userID}, "followee_id")
+	excluded := append([]primitive.ObjectID{userID}, followees...)
+	excluded = append(excluded, social.BlockedIDs(ctx, userID)...)
+
+	base := bson.M{
+		"user_type":  "farmer",
+		"is_blocked": bson.M{"$ne": true},
+		"_id":        bson.M{"$nin": excluded},
+	}
+
+	// 1. Candidates: nearby farmers (or farmers sharing a crop when the viewer has no location) ...
+	var candidates []auth.User
+	viewerCrops := cropSet(viewer.Crops)
+	if viewer.GeoLocation != nil {
+		near := bson.M{}
+		for k, v := range base {
+			near[k] = v
+		}
+		near["geo_location"] = bson.M{"$near": bson.M{
+			"$geometry":    bson.M{"type": "Point", "coordinates": viewer.GeoLocation.Coordinates},
+			"$maxDistance": recommend_models.CandidateRadiusKm * 1000,
+		}}
+		candidates = findUsers(ctx, near, recommend_models.MaxNearby)
+	} else if len(viewer.Crops) > 0 {
+		var names []string
+		for _, crop := range viewer.Crops {
+			names = append(names, crop.Name)
+		}
+		byCrop := bson.M{"crops.name": bson.M{"$in": names}}
+		for k, v := range base {
+			byCrop[k] = v
+		}
+		candidates = findUsers(ctx, byCrop, recommend_models.MaxNearby)
+	}
+
+	// ... plus farmers followed by the people the viewer follows
+	seen := map[primitive.ObjectID]bool{}
+	for _, u := range candidates {
+		seen[u.ID] = true
+	}
+	if len(followees) > 0 {
+		var fof []primitive.ObjectID
+		for _, id := range topFollowed(ctx, followees, excluded, recommend_models.MaxFriendsOfFriends) {
+			if !seen[id] {
+				fof = append(fof, id)
+			}
+		}
+		if len(fof) > 0 {
+			byID := bson.M{"_id": bson.M{"$in": fof}, "user_type": "farmer", "is_blocked": bson.M{"$ne": true}}
+			candidates = append(candidates, findUsers(ctx, byID, int64(len(fof)))...)
+		}
+	}
+	if len(candidates) == 0 {
+		return set, store(ctx, set)
+	}
+
+	ids := make([]primitive.ObjectID, len(candidates))
+	for i, u := range candidates {
+		ids[i] = u.ID
+	}
+
+	// 2. Graph signals for all candidates at once
+	mutual := map[primitive.ObjectID]int{}
+	if len(followees) > 0 {
+		mutual = countBy(ctx, "follows", bson.M{"follower_id": bson.M{"$in": followees}, "followee_id": bson.M{"$in": ids}}, "$followee_id")
+	}
+	topics := followedTopics(ctx, userID)
+	shared := map[primitive.ObjectID]int{}
+	if len(topics) > 0 {
+		shared = countBy(ctx, "tag_follows", bson.M{"tag": bson.M{"$in": topics}, "user_id": bson.M{"$in": ids}}, "$user_id")
+	}
+
+	// 3. Score
+	now := time.Now()
+	for _, u := range candidates {
+		item := score(viewer, viewerCrops, u, mutual[u.ID], shared[u.ID], now)
+		if item.Score >= recommend_models.MinScore {
+			set.Items = append(set.Items, item)
+		}
+	}
+	sort.Slice(set.Items, func(i, j int) bool {
+		if set.Items[i].Score != set.Items[j].Score {
+			return set.Items[i].Score > set.Items[j].Score
+		}
+		return set.Items[i].UserID.Hex() > set.Items[j].UserID.Hex()
+	})
+	if len(set.Items) > recommend_models.MaxStored {
+		set.Items = set.Items[:recommend_models.MaxStored]
+	}
+
+	return set, store(ctx, set)
+}
+
+// score combines the features of one candidate and explains the strong ones
+func score(viewer auth.User, viewerCrops map[string]string, cand auth.User, mutual, sharedTopics int, now time.Time) recommend_models.Item {
+	item := recommend_models.Item{UserID: cand.ID, Features: map[string]float64{}}
+	reason := func(code, msg string) {
+		item.Reasons = append(item.Reasons, recommend_models.Reason{Code: code, Message: msg})
+	}
+
+	// Crop overlap: Jaccard index of crop names
+	candCrops := cropSet(cand.Crops)
+	var common []string
+	for key, name := range candCrops {
+		if _, ok := viewerCrops[key]; ok {
+			common = append(common, name)
+		}
+	}
+	sort.Strings(common)
+	if union := len(viewerCrops) + len(candCrops) - len(common); union > 0 {
+		item.Features["crop"] = float64(len(common)) / float64(union)
+	}
+	if len(common) > 0 {
+		reason(recommend_models.ReasonSharedCrops, "Also grows "+strings.Join(common, ", "))
+	}
+
+	// Distance: exponential decay
+	if viewer.GeoLocation != nil && cand.GeoLocation != nil {
+		v, c := viewer.GeoLocation.Coordinates, cand.GeoLocation.Coordinates
+		km := utils.Haversine(v[1], v[0], c[1], c[0])
+		item.Features["distance"] = math.Exp(-km / recommend_models.DistanceScaleKm)
+		if km <= recommend_models.DistanceScaleKm {
+			reason(recommend_models.ReasonNearby, fmt.Sprintf("%.0f km away", math.Max(km, 1)))
+		}
+	}
+
+	// Mutual follows and shared topics: saturating counts, 1 -> 0.5, 3 -> 0.75
+	if mutual > 0 {
+		item.Features["mutual"] = 1 - 1/float64(1+mutual)
+		reason(recommend_models.ReasonMutual, fmt.Sprintf("Followed by %d people you follow", mutual))
+	}
+	if sharedTopics > 0 {
+		item.Features["groups"] = 1 - 1/float64(1+sharedTopics)
+		reason(recommend_models.ReasonGroups, fmt.Sprintf("Follows %d of your topics", sharedTopics))
+	}
+
+	if viewer.RegionalLanguage != "" && strings.EqualFold(viewer.RegionalLanguage, cand.RegionalLanguage) {
+		item.Features["language"] = 1
+		reason(recommend_models.ReasonLanguage, "Speaks "+cand.RegionalLanguage)
+	}
+
+	if !cand.LastActiveAt.IsZero() {
+		days := now.Sub(cand.LastActiveAt).Hours() / 24
+		item.Features["recency"] = math.Exp(-math.Max(days, 0) / recommend_models.RecencyScaleDays)
+		if days <= 7 {
+			reason(recommend_models.ReasonActive, "Active this week")
+		}
+	}
+
+	f := item.Features
+	item.Score = recommend_models.W_Crop*f["crop"] +
+		recommend_models.W_Distance*f["distance"] +
+		recommend_models.W_Mutual*f["mutual"] +
+		recommend_models.W_Groups*f["groups"] +
+		recommend_models.W_Language*f["language"] +
+		recommend_models.W_Recency*f["recency"]
+	item.Score = math.Round(item.Score*1e4) / 1e4
+	if item.Reasons == nil {
+		item.Reasons = []recommend_models.Reason{}
+	}
+	return item
+}
+
+// cropSet maps lowercased crop names to their display names
+func cropSet(crops []auth.Crop) map[string]string {
+	set := make(map[string]string, len(crops))
+	for _, crop := range crops {
+		if key := strings.ToLower(strings.TrimSpace(crop.Name)); key != "" {
+			set[key] = strings.TrimSpace(crop.Name)
+		}
+	}
+	return set
+}
+
+func findUsers(ctx context.Context, filter bson.M, limit int64) []auth.User {
+	cursor, err := database.GetCollection("users").Find(ctx, filter, options.Find().SetProjection(candidateProjection).SetLimit(limit))
+	if err != nil {
+		return nil
+	}
+	var users []auth.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil
+	}
+	return users
+}
+
+// topFollowed returns the users most followed by the given followers, skipping excluded ones
+func topFollowed(ctx context.Context, followers, excluded []primitive.ObjectID, limit int) []primitive.ObjectID {
+	cursor, err := database.GetCollection("follows").Aggregate(ctx, []bson.M{
+		{"$match": bson.M{"follower_id": bson.M{"$in": followers}, "followee_id": bson.M{"$nin": excluded}}},
+		{"$group": bson.M{"_id": "$followee_id", "n": bson.M{"$sum": 1}}},
+		{"$sort": bson.D{{Key: "n", Value: -1}, {Key: "_id", Value: 1}}},
+		{"$limit": limit},
+	})
+	if err != nil {
+		return nil
+	}
+	var rows []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil
+	}
+	ids := make([]primitive.ObjectID, len(rows))
+	for i, r := range rows {
+		ids[i] = r.ID
+	}
+	return ids
+}
+
+// countBy counts matching documents per value of key
+func countBy(ctx context.Context, collection string, match bson.M, key string) map[primitive.ObjectID]int {
+	counts := map[primitive.ObjectID]int{}
+	cursor, err := database.GetCollection(collection).Aggregate(ctx, []bson.M{
+		{"$match": match},
+		{"$group": bson.M{"_id": key, "n": bson.M{"$sum": 1}}},
+	})
+	if err != nil {
+		return counts
+	}
+	var rows []struct {
+		ID primitive.ObjectID `bson:"_id"`
+		N  int                `bson:"n"`
+	}
+	if cursor.All(ctx, &rows) == nil {
+		for _, r := range rows {
+			counts[r.ID] = r.N
+		}
+	}
+	return counts
+}
+
+// idsOf reads one ObjectID field from every matching document
+func idsOf(ctx context.Context, collection string, filter bson.M, field string) []primitive.ObjectID {
+	cursor, err := database.GetCollection(collection).Find(ctx, filter, options.Find().SetProjection(bson.M{field: 1}))
+	if err != nil {
+		return nil
+	}
+	var rows []bson.M
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil
+	}
+	ids := make([]primitive.ObjectID, 0, len(rows))
+	for _, r := range rows {
+		if id, ok := r[field].(primitive.ObjectID); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// followedTopics returns the topics the user follows
+func followedTopics(ctx context.Context, userID primitive.ObjectID) []string {
+	cursor, err := database.GetCollection("tag_follows").Find(ctx, bson.M{"user_id": userID}, options.Find().SetProjection(bson.M{"tag": 1}))
+	if err != nil {
+		return nil
+	}
+	var rows []struct {
+		Tag string `bson:"tag"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil
+	}
+	tags := make([]string, 0, len(rows))
+	for _, r := range rows {
+		tags = append(tags, r.Tag)
+	}
+	return tags
+}
+
+func store(ctx context.Context, set recommend_models.Set) error {
+	_, err := database.GetCollection("farmer_recommendations").ReplaceOne(ctx, bson.M{"_id": set.UserID}, set, options.Replace().SetUpsert(true))
+	return err
+}
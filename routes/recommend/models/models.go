@@ -0,0 +1,59 @@
+package recommend_models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Feature weights; they add up to 1 so scores stay in [0, 1]
+const (
+	W_Crop     = 0.30
+	W_Distance = 0.20
+	W_Mutual   = 0.20
+	W_Groups   = 0.10
+	W_Language = 0.10
+	W_Recency  = 0.10
+)
+
+const (
+	CandidateRadiusKm   = 100 // Nearby candidates are searched within this radius
+	DistanceScaleKm     = 25  // Distance score halves roughly every 17 km (exp(-d/25))
+	RecencyScaleDays    = 14  // Activity score decays with days since last active
+	MaxNearby           = 300 // Nearby candidates scored per user
+	MaxFriendsOfFriends = 200 // Friends-of-friends candidates scored per user
+	MaxStored           = 50  // Recommendations kept per user
+	MinScore            = 0.05
+	StaleAfter          = 24 * time.Hour // Reads recompute a set older than this
+)
+
+// Reason codes, so clients can localize the explanation
+const (
+	ReasonSharedCrops = "shared_crops"
+	ReasonNearby      = "nearby"
+	ReasonMutual      = "mutual_follows"
+	ReasonGroups      = "shared_topics"
+	ReasonLanguage    = "same_language"
+	ReasonActive      = "recently_active"
+)
+
+// Reason explains one part of why a farmer was recommended
+type Reason struct {
+	Code    string `bson:"code" json:"code"`
+	Message string `bson:"message" json:"message"`
+}
+
+// Item is one scored candidate
+type Item struct {
+	UserID   primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Score    float64            `bson:"score" json:"score"`
+	Features map[string]float64 `bson:"features" json:"features"`
+	Reasons  []Reason           `bson:"reasons" json:"reasons"`
+}
+
+// Set is the precomputed list for one user, best first (collection: farmer_recommendations, _id = user)
+type Set struct {
+	UserID     primitive.ObjectID `bson:"_id" json:"user_id"`
+	Items      []Item             `bson:"items" json:"items"`
+	ComputedAt time.Time          `bson:"computed_at" json:"computed_at"`
+}
@@ -0,0 +1,141 @@
+package recommend
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"Agromi/database"
+	"Agromi/routes/auth"
+	recommend_models "Agromi/routes/recommend/models"
+	"Agromi/routes/social"
+	"Agromi/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	jobInterval     = 6 * time.Hour
+	activeFarmerAge = 30 * 24 * time.Hour // The job only refreshes farmers active this recently
+)
+
+// GetRecommendations returns farmers the caller may want to follow, with the reasons for each.
+// Reads the precomputed set and recomputes it when missing or stale. Query: limit (default 10), refresh=true.
+// Users followed or blocked since the set was computed are dropped.
+func GetRecommendations(c *gin.Context) {
+	userID := auth.CurrentUserID(c)
+	limit := utils.ParseLimit(c.Query("limit"), 10, recommend_models.MaxStored)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var set recommend_models.Set
+	err := database.GetCollection("farmer_recommendations").FindOne(ctx, bson.M{"_id": userID}).Decode(&set)
+	if err != nil || c.Query("refresh") == "true" || time.Since(set.ComputedAt) > recommend_models.StaleAfter {
+		if set, err = Compute(ctx, userID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute recommendations"})
+			return
+		}
+	}
+
+	skip := map[primitive.ObjectID]bool{}
+	for _, id := range idsOf(ctx, "follows", bson.M{"follower_id": userID}, "followee_id") {
+		skip[id] = true
+	}
+	for _, id := range social.BlockedIDs(ctx, userID) {
+		skip[id] = true
+	}
+	var items []recommend_models.Item
+	var ids []primitive.ObjectID
+	for _, item := range set.Items {
+		if skip[item.UserID] {
+			continue
+		}
+		items = append(items, item)
+		ids = append(ids, item.UserID)
+		if int64(len(items)) == limit {
+			break
+		}
+	}
+
+	profiles := map[primitive.ObjectID]interface{}{}
+	if len(ids) > 0 {
+		cursor, err := database.GetCollection("users").Find(ctx, bson.M{"_id": bson.M{"$in": ids}, "is_blocked": bson.M{"$ne": true}})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		var users []auth.User
+		if err := cursor.All(ctx, &users); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error parsing users"})
+			return
+		}
+		for i, view := range auth.Views(ctx, userID, users) {
+			profiles[users[i].ID] = view
+		}
+	}
+
+	results := make([]gin.H, 0, len(items))
+	for _, item := range items {
+		profile, ok := profiles[item.UserID]
+		if !ok {
+			continue // Deleted or blocked by an admin since computing
+		}
+		results = append(results, gin.H{"user": profile, "score": item.Score, "reasons": item.Reasons})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recommendations": results, "computed_at": set.ComputedAt})
+}
+
+// runRecommendationJob precomputes recommendations for recently active farmers
+func runRecommendationJob() {
+	if !database.WaitForClient(30 * time.Second) {
+		log.Println("Recommendations: database not ready, job not started")
+		return
+	}
+
+	ticker := time.NewTicker(jobInterval)
+	defer ticker.Stop()
+	for {
+		computeAll()
+		<-ticker.C
+	}
+}
+
+func computeAll() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	filter := bson.M{
+		"user_type":      "farmer",
+		"is_blocked":     bson.M{"$ne": true},
+		"last_active_at": bson.M{"$gte": time.Now().Add(-activeFarmerAge)},
+	}
+	cursor, err := database.GetCollection("users").Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		log.Println("Recommendations: user query failed:", err)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	users, failed := 0, 0
+	for cursor.Next(ctx) {
+		var u struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if cursor.Decode(&u) != nil {
+			continue
+		}
+		users++
+		userCtx, cancelUser := context.WithTimeout(ctx, 30*time.Second)
+		if _, err := Compute(userCtx, u.ID); err != nil {
+			failed++
+		}
+		cancelUser()
+	}
+	log.Printf("Recommendations: computed for %d farmers (%d failed)", users, failed)
+}
@@ -0,0 +1,16 @@
+package recommend
+
+import (
+	"Agromi/core/router"
+	"Agromi/routes/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	router.Register(func(r *gin.Engine) {
+		r.GET("/api/farmer/recommendations", auth.RequireAuth(), auth.RequireRole(auth.RoleUser), GetRecommendations)
+	})
+
+	go runRecommendationJob()
+}
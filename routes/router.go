@@ -2,19 +2,33 @@ package routes
 
 import (
 	core_router "Agromi/core/router"
+	_ "Agromi/routes/account"             // Trigger init() for account deletion & data export
+	_ "Agromi/routes/admin/audit"         // Trigger init() for the admin audit log
 	_ "Agromi/routes/admin/consultant"    // Trigger init() for Admin Consultant
+	_ "Agromi/routes/admin/farm"          // Trigger init() for Admin farm plot queries
 	_ "Agromi/routes/admin/farmer"        // Trigger init() for farmer auth & profiles
 	_ "Agromi/routes/admin/farmer/filter" // Trigger init() for farmer analytics
 	_ "Agromi/routes/admin/finance"       // Trigger init() for Admin Finance
+	_ "Agromi/routes/admin/mandi"         // Trigger init() for mandi price imports
 	_ "Agromi/routes/admin/market"        // Trigger init() for Admin Marketplace
+	_ "Agromi/routes/admin/pest"          // Trigger init() for pest heatmap & outbreaks
 	_ "Agromi/routes/admin/social"        // Trigger init() for Admin Social module
+	_ "Agromi/routes/advisory"            // Trigger init() for weather advisories
 	_ "Agromi/routes/auth"                // Trigger init() for auth routes
+	_ "Agromi/routes/calendar"            // Trigger init() for crop calendar & reminders
 	_ "Agromi/routes/chat"                // Trigger init() for Chat module
 	_ "Agromi/routes/community"           // Trigger init() for Community module
 	_ "Agromi/routes/consultant"          // Trigger init() for User Consultant interaction
+	_ "Agromi/routes/farm"                // Trigger init() for farm plots & seasons
 	_ "Agromi/routes/farmer"              // Trigger init() for search, friends, suggestions
+	_ "Agromi/routes/ledger"              // Trigger init() for farm ledger & season reports
+	_ "Agromi/routes/mandi"               // Trigger init() for mandi prices & alerts
 	_ "Agromi/routes/market"              // Trigger init() for User Marketplace
+	_ "Agromi/routes/media"               // Trigger init() for media uploads
+	_ "Agromi/routes/pest"                // Trigger init() for pest incidents & outbreak alerts
+	_ "Agromi/routes/recommend"           // Trigger init() for farmer recommendations
 	_ "Agromi/routes/social"              // Trigger init() for Social module
+	_ "Agromi/routes/soil"                // Trigger init() for soil tests & fertilizer advice
 	"fmt"
 
 	"github.com/gin-gonic/gin"
@@ -0,0 +1,146 @@
+package social
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"Agromi/database"
+	"Agromi/routes/auth"
+	social_models "Agromi/routes/social/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	go createBlockIndexes()
+}
+
+// BlockUser blocks another user and removes follows in both directions
+func BlockUser(c *gin.Context) {
+	blockedID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	blockerID := auth.CurrentUserID(c)
+	if blockedID == blockerID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot block yourself"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	block := social_models.Block{
+		ID:        primitive.NewObjectID(),
+		BlockerID: blockerID,
+		BlockedID: blockedID,
+		CreatedAt: time.Now(),
+	}
+	if _, err := database.GetCollection("user_blocks").InsertOne(ctx, block); err != nil && !mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to block user"})
+		return
+	}
+
+	database.GetCollection("follows").DeleteMany(ctx, bson.M{"$or": []bson.M{
+		{"follower_id": blockerID, "followee_id": blockedID},
+		{"follower_id": blockedID, "followee_id": blockerID},
+	}})
+
+	c.JSON(http.StatusOK, gin.H{"message": "User blocked"})
+}
+
+// UnblockUser removes a block. Follows removed by the block are not restored.
+func UnblockUser(c *gin.Context) {
+	blockedID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := database.GetCollection("user_blocks").DeleteOne(ctx, bson.M{"blocker_id": auth.CurrentUserID(c), "blocked_id": blockedID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unblock user"})
+		return
+	}
+	if res.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User is not blocked"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User unblocked"})
+}
+
+// ListBlocks returns the users the caller has blocked, most recent first
+func ListBlocks(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	cursor, err := database.GetCollection("user_blocks").Find(ctx, bson.M{"blocker_id": auth.CurrentUserID(c)}, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	blocks := []social_models.Block{}
+	if err := cursor.All(ctx, &blocks); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Parse Error"})
+		return
+	}
+	c.JSON(http.StatusOK, blocks)
+}
+
+// BlockedIDs returns everyone the user blocked or was blocked by
+func BlockedIDs(ctx context.Context, userID primitive.ObjectID) []primitive.ObjectID {
+	cursor, err := database.GetCollection("user_blocks").Find(ctx, bson.M{"$or": []bson.M{
+		{"blocker_id": userID},
+		{"blocked_id": userID},
+	}})
+	if err != nil {
+		return nil
+	}
+	var blocks []social_models.Block
+	if err := cursor.All(ctx, &blocks); err != nil {
+		return nil
+	}
+	ids := make([]primitive.ObjectID, 0, len(blocks))
+	for _, b := range blocks {
+		if b.BlockerID == userID {
+			ids = append(ids, b.BlockedID)
+		} else {
+			ids = append(ids, b.BlockerID)
+		}
+	}
+	return ids
+}
+
+// createBlockIndexes makes blocks unique and backs both directions of BlockedIDs
+func createBlockIndexes() {
+	if !database.WaitForClient(30 * time.Second) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, _ = database.GetCollection("user_blocks").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "blocker_id", Value: 1}, {Key: "blocked_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "blocked_id", Value: 1}}},
+	})
+}
+
+func RegisterBlockRoutes(router *gin.RouterGroup) {
+	blocks := router.Group("/blocks", auth.RequireAuth(), auth.RequireRole(auth.RoleUser))
+	{
+		blocks.GET("", ListBlocks)
+		blocks.POST("/:id", BlockUser)
+		blocks.DELETE("/:id", UnblockUser)
+	}
+}
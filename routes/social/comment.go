@@ -2,19 +2,45 @@ package social
 
 import (
 	"context"
+	"log"
 	"net/http"
+	"os"
+	"strconv"
 	"time"
 
 	"Agromi/database"
+	"Agromi/routes/auth"
 	social_models "Agromi/routes/social/models"
+	"Agromi/utils"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
-// Helper to Create Notification
-func createNotification(ctx context.Context, recipientID primitive.ObjectID, notifType, message string, relatedID primitive.ObjectID) {
+func init() {
+	go createCommentIndexes()
+}
+
+// maxCommentDepth is read once from COMMENT_MAX_DEPTH so operators can change it without a release
+var maxCommentDepth = commentDepthFromEnv()
+
+func commentDepthFromEnv() int {
+	raw := os.Getenv("COMMENT_MAX_DEPTH")
+	if raw == "" {
+		return social_models.DefaultMaxCommentDepth
+	}
+	depth, err := strconv.Atoi(raw)
+	if err != nil || depth < 0 {
+		log.Println("social: ignoring invalid COMMENT_MAX_DEPTH", raw)
+		return social_models.DefaultMaxCommentDepth
+	}
+	return depth
+}
+
+// CreateNotification stores an in-app notification for recipientID
+func CreateNotification(ctx context.Context, recipientID primitive.ObjectID, notifType, message string, relatedID primitive.ObjectID) {
 	coll := database.GetCollection("notifications")
 	notif := social_models.Notification{
 		ID:          primitive.NewObjectID(),
@@ -28,7 +54,7 @@ func createNotification(ctx context.Context, recipientID primitive.ObjectID, not
 	coll.InsertOne(ctx, notif)
 }
 
-// CreateComment
+// CreateComment posts a top-level comment, or a reply when parent_id is set
 func CreateComment(c *gin.Context) {
 	var body struct {
 		TargetID   string `json:"target_id" binding:"required"`
@@ -36,7 +62,8 @@ func CreateComment(c *gin.Context) {
 		SenderName string `json:"sender_name" binding:"required"`
 		Text       string `json:"text" binding:"required"`
 		MediaURL   string `json:"media_url"`
-		OwnerID    string `json:"owner_id"` // ID of the user who owns the Target (Product/Profile) to notify
+		OwnerID    string `json:"owner_id"`  // ID of the user who owns the Target (Product/Profile) to notify
+		ParentID   string `json:"parent_id"` // Optional: comment being replied to
 	}
 
 	if err := c.ShouldBindJSON(&body); err != nil {
@@ -50,6 +77,8 @@ func CreateComment(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	coll := database.GetCollection("comments")
+
 	comment := social_models.Comment{
 		ID:         primitive.NewObjectID(),
 		TargetID:   targetObjID,
@@ -61,23 +90,76 @@ func CreateComment(c *gin.Context) {
 		UpdatedAt:  time.Now(),
 	}
 
-	coll := database.GetCollection("comments")
+	// Reply: inherit thread position from the parent
+	var parent social_models.Comment
+	if body.ParentID != "" {
+		parentObjID, err := primitive.ObjectIDFromHex(body.ParentID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parent_id"})
+			return
+		}
+		if err := coll.FindOne(ctx, bson.M{"_id": parentObjID}).Decode(&parent); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Parent comment not found"})
+			return
+		}
+		if parent.TargetID != targetObjID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parent comment belongs to a different target"})
+			return
+		}
+		if parent.Depth+1 > maxCommentDepth {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Maximum reply depth reached"})
+			return
+		}
+
+		comment.ParentID = parent.ID
+		comment.Depth = parent.Depth + 1
+		comment.Ancestors = append(append([]primitive.ObjectID{}, parent.Ancestors...), parent.ID)
+	}
+
+	comment.Mentions = resolveMentions(ctx, body.Text)
+
 	_, err := coll.InsertOne(ctx, comment)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post comment"})
 		return
 	}
 
+	notified := map[primitive.ObjectID]bool{senderObjID: true}
+
+	if !comment.ParentID.IsZero() {
+		coll.UpdateOne(ctx, bson.M{"_id": comment.ParentID}, bson.M{"$inc": bson.M{"reply_count": 1}})
+
+		// Notify Parent Author
+		if !notified[parent.SenderID] {
+			notified[parent.SenderID] = true
+			CreateNotification(ctx, parent.SenderID, "reply", body.SenderName+" replied to your comment.", comment.ID)
+		}
+	}
+
 	// Notify Owner
 	if body.OwnerID != "" && body.OwnerID != body.SenderID {
 		ownerObjID, _ := primitive.ObjectIDFromHex(body.OwnerID)
-		createNotification(ctx, ownerObjID, "comment", body.SenderName+" commented on your post.", comment.ID)
+		if !notified[ownerObjID] {
+			notified[ownerObjID] = true
+			CreateNotification(ctx, ownerObjID, "comment", body.SenderName+" commented on your post.", comment.ID)
+		}
+	}
+
+	// Notify Mentioned Users
+	for _, userID := range comment.Mentions {
+		if notified[userID] {
+			continue
+		}
+		notified[userID] = true
+		CreateNotification(ctx, userID, "mention", body.SenderName+" mentioned you in a comment.", comment.ID)
 	}
 
 	c.JSON(http.StatusCreated, gin.H{"message": "Comment posted", "id": comment.ID})
 }
 
-// ListComments
+// ListComments returns every comment on a target, replies included, as a bare array.
+// This is the shape existing clients read; threaded paging lives in ListCommentsPage.
+// Query: target_id (required), viewer_id (optional, fills my_reaction).
 func ListComments(c *gin.Context) {
 	targetID := c.Query("target_id")
 	if targetID == "" {
@@ -96,29 +178,158 @@ func ListComments(c *gin.Context) {
 		return
 	}
 
-	var comments []social_models.Comment
+	comments := []social_models.Comment{}
 	if err = cursor.All(ctx, &comments); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Parse Error"})
 		return
 	}
 
+	viewerID, _ := primitive.ObjectIDFromHex(c.Query("viewer_id"))
+	attachCommentReactions(ctx, comments, viewerID)
+
 	c.JSON(http.StatusOK, comments)
 }
 
-// UpdateComment (Sender Only)
+// ListCommentsPage returns one page of a comment thread.
+// Query: target_id (required), parent_id (replies of that comment; top-level if empty),
+// sort (newest|oldest|top), limit, cursor (next_cursor from the previous page),
+// viewer_id (optional, fills my_reaction).
+func ListCommentsPage(c *gin.Context) {
+	targetID := c.Query("target_id")
+	if targetID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "target_id required"})
+		return
+	}
+
+	objID, _ := primitive.ObjectIDFromHex(targetID)
+
+	match := bson.M{"target_id": objID}
+	if parentID := c.Query("parent_id"); parentID != "" {
+		parentObjID, err := primitive.ObjectIDFromHex(parentID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parent_id"})
+			return
+		}
+		match["parent_id"] = parentObjID
+	} else {
+		match["parent_id"] = bson.M{"$exists": false}
+	}
+
+	sortBy := c.DefaultQuery("sort", social_models.CommentSortNewest)
+	var sortField string
+	var sortDir int
+	switch sortBy {
+	case social_models.CommentSortNewest:
+		sortField, sortDir = "created_at", -1
+	case social_models.CommentSortOldest:
+		sortField, sortDir = "created_at", 1
+	case social_models.CommentSortTop:
+		sortField, sortDir = "likes_count", -1
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be newest, oldest or top"})
+		return
+	}
+
+	limit := utils.ParseLimit(c.Query("limit"), social_models.DefaultCommentLimit, social_models.MaxCommentLimit)
+
+	pipeline := []bson.M{
+		{"$match": match},
+		// Like counts come from the reaction counters, like everywhere else
+		{"$lookup": bson.M{"from": "reaction_counts", "localField": "_id", "foreignField": "_id", "as": "like_stats"}},
+		{"$addFields": bson.M{"likes_count": bson.M{"$ifNull": []interface{}{bson.M{"$arrayElemAt": []interface{}{"$like_stats.counts." + social_models.ReactionLike, 0}}, 0}}}},
+		{"$project": bson.M{"like_stats": 0}},
+	}
+
+	if cursorStr := c.Query("cursor"); cursorStr != "" {
+		cur, err := utils.DecodeCursor(cursorStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		var key interface{} = cur.Key
+		if sortField == "created_at" {
+			key = time.UnixMilli(int64(cur.Key))
+		}
+		op := "$lt"
+		if sortDir == 1 {
+			op = "$gt"
+		}
+		pipeline = append(pipeline, bson.M{"$match": bson.M{"$or": []bson.M{
+			{sortField: bson.M{op: key}},
+			{sortField: key, "_id": bson.M{op: cur.ID}},
+		}}})
+	}
+
+	pipeline = append(pipeline,
+		bson.M{"$sort": bson.D{{Key: sortField, Value: sortDir}, {Key: "_id", Value: sortDir}}},
+		bson.M{"$limit": limit + 1}, // One extra to know if another page exists
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	coll := database.GetCollection("comments")
+	cursor, err := coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+
+	comments := []social_models.Comment{}
+	if err = cursor.All(ctx, &comments); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Parse Error"})
+		return
+	}
+
+	nextCursor := ""
+	if int64(len(comments)) > limit {
+		comments = comments[:limit]
+		last := comments[len(comments)-1]
+		key := float64(last.LikesCount)
+		if sortField == "created_at" {
+			key = float64(last.CreatedAt.UnixMilli())
+		}
+		nextCursor = utils.EncodeCursor(key, last.ID)
+	}
+
+	viewerID, _ := primitive.ObjectIDFromHex(c.Query("viewer_id"))
+	attachCommentReactions(ctx, comments, viewerID)
+
+	c.JSON(http.StatusOK, gin.H{"comments": comments, "next_cursor": nextCursor})
+}
+
+// attachCommentReactions fills reaction counters, like counts and the viewer's own reaction
+func attachCommentReactions(ctx context.Context, comments []social_models.Comment, viewerID primitive.ObjectID) {
+	ids := make([]primitive.ObjectID, len(comments))
+	for i := range comments {
+		ids[i] = comments[i].ID
+	}
+	counts := ReactionCounts(ctx, ids)
+	mine := ViewerReactions(ctx, viewerID, ids)
+	for i := range comments {
+		comments[i].ReactionCounts = counts[comments[i].ID]
+		comments[i].LikesCount = counts[comments[i].ID][social_models.ReactionLike]
+		comments[i].MyReaction = mine[comments[i].ID]
+	}
+}
+
+// UpdateComment (Sender Only) edits the signed-in user's own comment
 func UpdateComment(c *gin.Context) {
 	var body struct {
-		ID       string `json:"id" binding:"required"`
-		SenderID string `json:"sender_id" binding:"required"`
-		Text     string `json:"text" binding:"required"`
+		ID   string `json:"id" binding:"required"`
+		Text string `json:"text" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	commentID, _ := primitive.ObjectIDFromHex(body.ID)
-	senderID, _ := primitive.ObjectIDFromHex(body.SenderID)
+	commentID, err := primitive.ObjectIDFromHex(body.ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return
+	}
+	senderID := auth.CurrentUserID(c)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -138,40 +349,82 @@ func UpdateComment(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Comment updated"})
 }
 
-// DeleteComment (Sender or Admin)
+// DeleteComment (Sender Only) removes the signed-in user's comment and its replies.
+// Admins delete other users' comments through the admin social routes.
 func DeleteComment(c *gin.Context) {
-	id := c.Param("id")              // Comment ID
-	senderID := c.Query("sender_id") // If provided, ensures ownership. If empty, assumes Admin overrides (in real app, check context)
-
-	commentID, _ := primitive.ObjectIDFromHex(id)
+	commentID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	deleted, err := DeleteCommentThread(ctx, bson.M{"_id": commentID, "sender_id": auth.CurrentUserID(c)})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Delete failed"})
+		return
+	}
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found or unauthorized"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted", "deleted_count": deleted})
+}
+
+// DeleteCommentThread deletes the comment matching filter together with all of its replies
+// and keeps the parent's reply count in step. Returns the number of comments removed.
+func DeleteCommentThread(ctx context.Context, filter bson.M) (int64, error) {
 	coll := database.GetCollection("comments")
 
-	filter := bson.M{"_id": commentID}
-	if senderID != "" {
-		sID, _ := primitive.ObjectIDFromHex(senderID)
-		filter["sender_id"] = sID
+	var comment social_models.Comment
+	if err := coll.FindOneAndDelete(ctx, filter).Decode(&comment); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 0, nil
+		}
+		return 0, err
 	}
 
-	res, err := coll.DeleteOne(ctx, filter)
+	res, err := coll.DeleteMany(ctx, bson.M{"ancestors": comment.ID})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Delete failed"})
-		return
+		return 1, err
 	}
-	if res.DeletedCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found or unauthorized"})
+
+	if !comment.ParentID.IsZero() {
+		coll.UpdateOne(ctx, bson.M{"_id": comment.ParentID}, bson.M{"$inc": bson.M{"reply_count": -1}})
+	}
+
+	return 1 + res.DeletedCount, nil
+}
+
+// createCommentIndexes backs thread listing and sub-thread deletion
+func createCommentIndexes() {
+	if !database.WaitForClient(30 * time.Second) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted"})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	comments := database.GetCollection("comments")
+	_, _ = comments.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "target_id", Value: 1}, {Key: "parent_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "ancestors", Value: 1}}},
+	})
+
+	// Likes are grouped per target when the reaction counters are rebuilt
+	likes := database.GetCollection("likes")
+	_, _ = likes.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "target_id", Value: 1}, {Key: "action", Value: 1}},
+	})
 }
 
 func RegisterCommentRoutes(router *gin.RouterGroup) {
 	router.POST("/comment/create", CreateComment)
 	router.GET("/comment/list", ListComments)
-	router.PUT("/comment/update", UpdateComment)
-	router.DELETE("/comment/delete/:id", DeleteComment)
+	router.GET("/v2/comment/list", ListCommentsPage)
+	router.PUT("/comment/update", auth.RequireAuth(), UpdateComment)
+	router.DELETE("/comment/delete/:id", auth.RequireAuth(), DeleteComment)
 }
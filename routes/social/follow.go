@@ -50,7 +50,7 @@ func FollowUser(c *gin.Context) {
 	}
 	coll.InsertOne(ctx, follow)
 
-	createNotification(ctx, followeeID, "follow", "You have a new follower!", followerID)
+	CreateNotification(ctx, followeeID, "follow", "You have a new follower!", followerID)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Followed"})
 }
@@ -68,7 +68,7 @@ func NotifyFollowersOfNewPost(userIDStr string, postIDStr string, postTitle stri
 	cursor.All(ctx, &follows)
 
 	for _, f := range follows {
-		createNotification(ctx, f.FollowerID, "new_post", "New post by someone you follow: "+postTitle, postID)
+		CreateNotification(ctx, f.FollowerID, "new_post", "New post by someone you follow: "+postTitle, postID)
 	}
 }
 
@@ -0,0 +1,62 @@
+package social
+
+import (
+	"context"
+	"regexp"
+
+	"Agromi/database"
+	social_models "Agromi/routes/social/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Mentions are written as @[Name](userID), the markup the client's autocomplete inserts.
+// Names are not unique, so only the ID decides who is notified; the name is display text.
+var mentionPattern = regexp.MustCompile(`@\[[^\]]*\]\(([0-9a-fA-F]{24})\)`)
+
+// extractMentions returns the distinct user IDs mentioned in text, capped at MaxMentions
+func extractMentions(text string) []primitive.ObjectID {
+	seen := map[primitive.ObjectID]bool{}
+	var ids []primitive.ObjectID
+	for _, m := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		id, err := primitive.ObjectIDFromHex(m[1])
+		if err != nil || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+		if len(ids) >= social_models.MaxMentions {
+			break
+		}
+	}
+	return ids
+}
+
+// resolveMentions keeps the mentioned user IDs that belong to existing users
+func resolveMentions(ctx context.Context, text string) []primitive.ObjectID {
+	mentioned := extractMentions(text)
+	if len(mentioned) == 0 {
+		return nil
+	}
+
+	opts := options.Find().SetProjection(bson.M{"_id": 1}).SetLimit(social_models.MaxMentions)
+	cursor, err := database.GetCollection("users").Find(ctx, bson.M{"_id": bson.M{"$in": mentioned}}, opts)
+	if err != nil {
+		return nil
+	}
+
+	var users []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, u.ID)
+	}
+	return ids
+}
@@ -14,30 +14,119 @@ type Comment struct {
 	SenderName string             `bson:"sender_name" json:"sender_name"`
 	Text       string             `bson:"text" json:"text"`
 	MediaURL   string             `bson:"media_url,omitempty" json:"media_url,omitempty"`
-	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt  time.Time          `bson:"updated_at" json:"updated_at"`
+
+	// Threading: top-level comments have no ParentID and Depth 0
+	ParentID   primitive.ObjectID   `bson:"parent_id,omitempty" json:"parent_id,omitempty"`
+	Ancestors  []primitive.ObjectID `bson:"ancestors,omitempty" json:"-"` // Root first, used to delete whole sub-threads
+	Depth      int                  `bson:"depth" json:"depth"`
+	ReplyCount int                  `bson:"reply_count" json:"reply_count"` // Direct replies only
+
+	Mentions   []primitive.ObjectID `bson:"mentions,omitempty" json:"mentions,omitempty"`
+	LikesCount int                  `bson:"likes_count" json:"likes_count"` // Computed from reaction_counts on read
+
+	// Filled per request, never stored
+	ReactionCounts map[string]int `bson:"-" json:"reaction_counts,omitempty"`
+	MyReaction     string         `bson:"-" json:"my_reaction,omitempty"`
+
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
 }
 
-// Like/Dislike Structure
+// Comment thread config
+const (
+	DefaultMaxCommentDepth = 3 // Replies deeper than this are rejected; COMMENT_MAX_DEPTH overrides it
+	DefaultCommentLimit    = 20
+	MaxCommentLimit        = 100
+	MaxMentions            = 10
+)
+
+// Comment sort orders
+const (
+	CommentSortNewest = "newest"
+	CommentSortOldest = "oldest"
+	CommentSortTop    = "top"
+)
+
+// Like/Dislike Structure (one reaction per sender per target)
 type Like struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	TargetID  primitive.ObjectID `bson:"target_id" json:"target_id"`
-	SenderID  primitive.ObjectID `bson:"sender_id" json:"sender_id"`
-	Action    string             `bson:"action" json:"action"` // "like", "dislike"
-	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	TargetID   primitive.ObjectID `bson:"target_id" json:"target_id"`
+	TargetType string             `bson:"target_type,omitempty" json:"target_type,omitempty"` // "post", "comment", "product", "user"
+	SenderID   primitive.ObjectID `bson:"sender_id" json:"sender_id"`
+	Action     string             `bson:"action" json:"action"` // One of ReactionTypes
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// Reaction types
+const (
+	ReactionLike       = "like"
+	ReactionDislike    = "dislike"
+	ReactionHelpful    = "helpful"
+	ReactionInsightful = "insightful"
+)
+
+var ReactionTypes = []string{ReactionLike, ReactionDislike, ReactionHelpful, ReactionInsightful}
+
+// IsValidReaction reports whether action is one of ReactionTypes
+func IsValidReaction(action string) bool {
+	for _, t := range ReactionTypes {
+		if t == action {
+			return true
+		}
+	}
+	return false
+}
+
+// Reaction target types
+const (
+	TargetPost    = "post"
+	TargetComment = "comment"
+	TargetProduct = "product"
+	TargetUser    = "user"
+)
+
+// ReactionCount holds the per-target counters, kept in step with the likes collection via $inc
+type ReactionCount struct {
+	TargetID   primitive.ObjectID `bson:"_id" json:"target_id"`
+	TargetType string             `bson:"target_type,omitempty" json:"target_type,omitempty"`
+	Counts     map[string]int     `bson:"counts" json:"counts"`
+	UpdatedAt  time.Time          `bson:"updated_at" json:"updated_at"`
 }
 
 // Review Structure
 type Review struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	TargetID  primitive.ObjectID `bson:"target_id" json:"target_id"` // Consultant or Product ID
-	SenderID  primitive.ObjectID `bson:"sender_id" json:"sender_id"`
-	Rating    float64            `bson:"rating" json:"rating"` // 1-5
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	TargetID      primitive.ObjectID `bson:"target_id" json:"target_id"`     // Consultant or Product ID
+	TargetType    string             `bson:"target_type" json:"target_type"` // "consultant" or "product"
+	SenderID      primitive.ObjectID `bson:"sender_id" json:"sender_id"`
+	InteractionID primitive.ObjectID `bson:"interaction_id" json:"interaction_id"` // Completed order or consultation backing the review
+	Rating        float64            `bson:"rating" json:"rating"`                 // Whole stars, 1-5
+	Text          string             `bson:"text" json:"text"`
+	Reply         *ReviewReply       `bson:"reply,omitempty" json:"reply,omitempty"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// ReviewReply is the owner's public response to a review
+type ReviewReply struct {
+	OwnerID   primitive.ObjectID `bson:"owner_id" json:"owner_id"`
 	Text      string             `bson:"text" json:"text"`
 	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
 	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
 }
 
+// Review target types
+const (
+	ReviewTargetConsultant = "consultant"
+	ReviewTargetProduct    = "product"
+)
+
+// ReviewTargetCollections maps a review target type to the collection holding its rating
+var ReviewTargetCollections = map[string]string{
+	ReviewTargetConsultant: "consultants",
+	ReviewTargetProduct:    "market_products",
+}
+
 // Follow Structure
 type Follow struct {
 	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
@@ -46,11 +135,19 @@ type Follow struct {
 	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
 }
 
+// Block hides two users from each other's recommendations (collection: user_blocks)
+type Block struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	BlockerID primitive.ObjectID `bson:"blocker_id" json:"blocker_id"`
+	BlockedID primitive.ObjectID `bson:"blocked_id" json:"blocked_id"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
 // Notification Structure
 type Notification struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	RecipientID primitive.ObjectID `bson:"recipient_id" json:"recipient_id"`
-	Type        string             `bson:"type" json:"type"` // "comment", "like", "follow", "new_post"
+	Type        string             `bson:"type" json:"type"` // "comment", "reply", "mention", "like", "follow", "new_post"
 	Message     string             `bson:"message" json:"message"`
 	RelatedID   primitive.ObjectID `bson:"related_id,omitempty" json:"related_id,omitempty"` // ID of comment/post/user
 	IsRead      bool               `bson:"is_read" json:"is_read"`
@@ -2,137 +2,479 @@ package social
 
 import (
 	"context"
+	"log"
 	"net/http"
 	"time"
 
 	"Agromi/database"
+	"Agromi/routes/auth"
 	social_models "Agromi/routes/social/models"
+	"Agromi/utils"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// ToggleLike
+func init() {
+	go createReactionIndexes()
+}
+
+// reactionTargets maps each reactable collection to its target type, checked in this order
+var reactionTargets = []struct {
+	Collection string
+	Type       string
+}{
+	{"community_posts", social_models.TargetPost},
+	{"comments", social_models.TargetComment},
+	{"market_products", social_models.TargetProduct},
+	{"users", social_models.TargetUser},
+}
+
+// reactionTargetType finds what the target is; ok is false if it does not exist
+func reactionTargetType(ctx context.Context, targetID primitive.ObjectID) (string, bool, error) {
+	for _, t := range reactionTargets {
+		n, err := database.GetCollection(t.Collection).CountDocuments(ctx, bson.M{"_id": targetID}, options.Count().SetLimit(1))
+		if err != nil {
+			return "", false, err
+		}
+		if n > 0 {
+			return t.Type, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// ToggleLike sets the signed-in user's reaction on a target.
+// Sending the same action again removes it; sending a different action switches it.
+// The target type is looked up, so a client cannot file a reaction under the wrong kind.
 func ToggleLike(c *gin.Context) {
 	var body struct {
 		TargetID string `json:"target_id" binding:"required"`
-		SenderID string `json:"sender_id" binding:"required"`
-		Action   string `json:"action" binding:"required"` // "like" or "dislike"
+		Action   string `json:"action" binding:"required"` // One of ReactionTypes
 		OwnerID  string `json:"owner_id"`                  // To notify
 	}
 	if err := c.ShouldBindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !social_models.IsValidReaction(body.Action) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid action", "allowed": social_models.ReactionTypes})
+		return
+	}
 
-	targetID, _ := primitive.ObjectIDFromHex(body.TargetID)
-	senderID, _ := primitive.ObjectIDFromHex(body.SenderID)
+	targetID, err := primitive.ObjectIDFromHex(body.TargetID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid target_id"})
+		return
+	}
+	senderID := auth.CurrentUserID(c)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	targetType, found, err := reactionTargetType(ctx, targetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Target not found"})
+		return
+	}
+
 	coll := database.GetCollection("likes")
 
 	// Check if exists
 	filter := bson.M{"target_id": targetID, "sender_id": senderID}
 	var existing social_models.Like
-	err := coll.FindOne(ctx, filter).Decode(&existing)
+	err = coll.FindOne(ctx, filter).Decode(&existing)
 
-	if err == nil {
-		// Update existing
-		coll.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"action": body.Action}})
-	} else {
+	deltas := map[string]int{}
+	myReaction := body.Action
+
+	switch {
+	case err == nil && existing.Action == body.Action:
+		// Toggle off. Matching on the old action makes concurrent toggles count once.
+		res, err := coll.DeleteOne(ctx, bson.M{"_id": existing.ID, "action": existing.Action})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove reaction"})
+			return
+		}
+		if res.DeletedCount == 1 {
+			deltas[existing.Action] = -1
+		}
+		myReaction = ""
+	case err == nil:
+		// Switch reaction
+		res, err := coll.UpdateOne(ctx, bson.M{"_id": existing.ID, "action": existing.Action}, bson.M{"$set": bson.M{"action": body.Action}})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update reaction"})
+			return
+		}
+		if res.ModifiedCount == 1 {
+			deltas[existing.Action] = -1
+			deltas[body.Action] = 1
+		}
+	case err == mongo.ErrNoDocuments:
 		// Insert new
 		like := social_models.Like{
-			ID:        primitive.NewObjectID(),
-			TargetID:  targetID,
-			SenderID:  senderID,
-			Action:    body.Action,
-			CreatedAt: time.Now(),
+			ID:         primitive.NewObjectID(),
+			TargetID:   targetID,
+			TargetType: targetType,
+			SenderID:   senderID,
+			Action:     body.Action,
+			CreatedAt:  time.Now(),
 		}
-		coll.InsertOne(ctx, like)
+		if _, err := coll.InsertOne(ctx, like); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				c.JSON(http.StatusConflict, gin.H{"error": "Reaction changed concurrently, retry"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save reaction"})
+			return
+		}
+		deltas[body.Action] = 1
 
-		// Notify if new like
-		if body.OwnerID != "" && body.OwnerID != body.SenderID && body.Action == "like" {
+		// Notify if new positive reaction
+		if body.OwnerID != "" && body.OwnerID != senderID.Hex() && body.Action != social_models.ReactionDislike {
 			ownerObjID, _ := primitive.ObjectIDFromHex(body.OwnerID)
-			createNotification(ctx, ownerObjID, "like", "Someone liked your post.", targetID)
+			msg := "Someone liked your post."
+			if body.Action != social_models.ReactionLike {
+				msg = "Someone found your post " + body.Action + "."
+			}
+			CreateNotification(ctx, ownerObjID, "like", msg, targetID)
 		}
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Reaction updated"})
+	applyReactionDeltas(ctx, targetID, targetType, deltas)
+
+	counts := ReactionCounts(ctx, []primitive.ObjectID{targetID})[targetID]
+	c.JSON(http.StatusOK, gin.H{"message": "Reaction updated", "my_reaction": myReaction, "reaction_counts": counts})
 }
 
-// AddReview
-func AddReview(c *gin.Context) {
-	var body struct {
-		TargetID string  `json:"target_id" binding:"required"` // ConsultantID or ProductID
-		SenderID string  `json:"sender_id" binding:"required"`
-		Rating   float64 `json:"rating" binding:"required"`
-		Text     string  `json:"text"`
+// applyReactionDeltas moves the per-target counters by the given amounts.
+// reaction_counts is the only stored count; like counts on posts and comments are read from it.
+func applyReactionDeltas(ctx context.Context, targetID primitive.ObjectID, targetType string, deltas map[string]int) {
+	inc := bson.M{}
+	for action, d := range deltas {
+		if d != 0 {
+			inc["counts."+action] = d
+		}
 	}
-	if err := c.ShouldBindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if len(inc) == 0 {
 		return
 	}
 
-	targetID, _ := primitive.ObjectIDFromHex(body.TargetID)
-	senderID, _ := primitive.ObjectIDFromHex(body.SenderID)
+	update := bson.M{
+		"$inc":         inc,
+		"$set":         bson.M{"updated_at": time.Now()},
+		"$setOnInsert": bson.M{"target_type": targetType},
+	}
+	database.GetCollection("reaction_counts").UpdateOne(ctx, bson.M{"_id": targetID}, update, database.UpsertOpt)
+}
+
+// backfillReactionCounts rebuilds reaction_counts from the likes collection once, for
+// reactions stored before the counters existed. A marker in migrations keeps it from repeating.
+func backfillReactionCounts(ctx context.Context) error {
+	const name = "reaction_counts_backfill"
+	migrations := database.GetCollection("migrations")
+	if n, err := migrations.CountDocuments(ctx, bson.M{"_id": name}); err != nil || n > 0 {
+		return err
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	_, err := database.GetCollection("likes").Aggregate(ctx, []bson.M{
+		{"$group": bson.M{
+			"_id":         bson.M{"target": "$target_id", "action": "$action"},
+			"n":           bson.M{"$sum": 1},
+			"target_type": bson.M{"$first": "$target_type"},
+		}},
+		{"$group": bson.M{
+			"_id":         "$_id.target",
+			"target_type": bson.M{"$first": "$target_type"},
+			"counts":      bson.M{"$push": bson.M{"k": "$_id.action", "v": "$n"}},
+		}},
+		{"$project": bson.M{"target_type": 1, "counts": bson.M{"$arrayToObject": "$counts"}, "updated_at": "$$NOW"}},
+		{"$merge": bson.M{"into": "reaction_counts", "on": "_id", "whenMatched": "merge", "whenNotMatched": "insert"}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = migrations.InsertOne(ctx, bson.M{"_id": name, "done_at": time.Now()})
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+// ReactionCounts returns the counters for each target (targets without reactions are absent)
+func ReactionCounts(ctx context.Context, targetIDs []primitive.ObjectID) map[primitive.ObjectID]map[string]int {
+	result := map[primitive.ObjectID]map[string]int{}
+	if len(targetIDs) == 0 {
+		return result
+	}
+
+	cursor, err := database.GetCollection("reaction_counts").Find(ctx, bson.M{"_id": bson.M{"$in": targetIDs}})
+	if err != nil {
+		return result
+	}
+
+	var docs []social_models.ReactionCount
+	if err := cursor.All(ctx, &docs); err != nil {
+		return result
+	}
+
+	for _, d := range docs {
+		counts := map[string]int{}
+		for action, n := range d.Counts {
+			if n > 0 {
+				counts[action] = n
+			}
+		}
+		result[d.TargetID] = counts
+	}
+	return result
+}
+
+// ViewerReactions returns the viewer's own reaction on each target they reacted to
+func ViewerReactions(ctx context.Context, viewerID primitive.ObjectID, targetIDs []primitive.ObjectID) map[primitive.ObjectID]string {
+	result := map[primitive.ObjectID]string{}
+	if viewerID.IsZero() || len(targetIDs) == 0 {
+		return result
+	}
+
+	filter := bson.M{"sender_id": viewerID, "target_id": bson.M{"$in": targetIDs}}
+	opts := options.Find().SetProjection(bson.M{"target_id": 1, "action": 1})
+	cursor, err := database.GetCollection("likes").Find(ctx, filter, opts)
+	if err != nil {
+		return result
+	}
+
+	var likes []social_models.Like
+	if err := cursor.All(ctx, &likes); err != nil {
+		return result
+	}
+
+	for _, l := range likes {
+		result[l.TargetID] = l.Action
+	}
+	return result
+}
+
+// ListReactions returns who reacted to a target, newest first.
+// Query: target_id (required), action (optional filter), limit, cursor.
+func ListReactions(c *gin.Context) {
+	targetID, err := primitive.ObjectIDFromHex(c.Query("target_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Valid target_id required"})
+		return
+	}
+
+	match := bson.M{"target_id": targetID}
+	if action := c.Query("action"); action != "" {
+		if !social_models.IsValidReaction(action) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid action"})
+			return
+		}
+		match["action"] = action
+	}
+
+	if cursorStr := c.Query("cursor"); cursorStr != "" {
+		cur, err := utils.DecodeCursor(cursorStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		t := time.UnixMilli(int64(cur.Key))
+		match["$or"] = []bson.M{
+			{"created_at": bson.M{"$lt": t}},
+			{"created_at": t, "_id": bson.M{"$lt": cur.ID}},
+		}
+	}
+
+	limit := utils.ParseLimit(c.Query("limit"), 20, 100)
+
+	pipeline := []bson.M{
+		{"$match": match},
+		{"$sort": bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{"$limit": limit + 1},
+		{"$lookup": bson.M{"from": "users", "localField": "sender_id", "foreignField": "_id", "as": "user"}},
+		{"$unwind": bson.M{"path": "$user", "preserveNullAndEmptyArrays": true}},
+		{"$project": bson.M{
+			"user_id":           "$sender_id",
+			"name":              "$user.name",
+			"profile_photo_url": "$user.profile_photo_url",
+			"action":            1,
+			"created_at":        1,
+		}},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	coll := database.GetCollection("reviews")
+	cursor, err := database.GetCollection("likes").Aggregate(ctx, pipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
 
-	// Upsert Review
-	filter := bson.M{"target_id": targetID, "sender_id": senderID}
-	update := bson.M{
-		"$set": bson.M{
-			"rating":     body.Rating,
-			"text":       body.Text,
-			"updated_at": time.Now(),
-		},
-		"$setOnInsert": bson.M{
-			"created_at": time.Now(),
-			"_id":        primitive.NewObjectID(),
-		},
-	}
-	_, err := coll.UpdateOne(ctx, filter, update, database.UpsertOpt)
+	var reactors []struct {
+		ID              primitive.ObjectID `bson:"_id" json:"-"`
+		UserID          primitive.ObjectID `bson:"user_id" json:"user_id"`
+		Name            string             `bson:"name" json:"name"`
+		ProfilePhotoURL string             `bson:"profile_photo_url" json:"profile_photo_url,omitempty"`
+		Action          string             `bson:"action" json:"action"`
+		CreatedAt       time.Time          `bson:"created_at" json:"created_at"`
+	}
+	if err := cursor.All(ctx, &reactors); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Parse Error"})
+		return
+	}
+
+	nextCursor := ""
+	if int64(len(reactors)) > limit {
+		reactors = reactors[:limit]
+		last := reactors[len(reactors)-1]
+		nextCursor = utils.EncodeCursor(float64(last.CreatedAt.UnixMilli()), last.ID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reactions": reactors, "next_cursor": nextCursor})
+}
+
+// GetReactionCounts returns the counters for one target, plus the viewer's reaction if viewer_id is given
+func GetReactionCounts(c *gin.Context) {
+	targetID, err := primitive.ObjectIDFromHex(c.Query("target_id"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save review"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Valid target_id required"})
 		return
 	}
+	viewerID, _ := primitive.ObjectIDFromHex(c.Query("viewer_id"))
 
-	// Recalculate Average (Simple approach)
-	go updateAverageRating(targetID)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ids := []primitive.ObjectID{targetID}
+	counts := ReactionCounts(ctx, ids)[targetID]
+	if counts == nil {
+		counts = map[string]int{}
+	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Review saved"})
+	c.JSON(http.StatusOK, gin.H{
+		"target_id":       targetID,
+		"reaction_counts": counts,
+		"my_reaction":     ViewerReactions(ctx, viewerID, ids)[targetID],
+	})
 }
 
-func updateAverageRating(targetID primitive.ObjectID) {
-	ctx := context.TODO()
-	coll := database.GetCollection("reviews")
+// createReactionIndexes enforces one reaction (and one review) per sender per target
+// and backfills the counters
+func createReactionIndexes() {
+	if !database.WaitForClient(30 * time.Second) {
+		return
+	}
 
-	pipeline := []bson.M{
-		{"$match": bson.M{"target_id": targetID}},
-		{"$group": bson.M{"_id": "$target_id", "avgRating": bson.M{"$avg": "$rating"}, "count": bson.M{"$sum": 1}}},
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	likes := database.GetCollection("likes")
+	likesDeduped := createUniqueBySender(ctx, likes, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "target_id", Value: 1}, {Key: "sender_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "target_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "sender_id", Value: 1}}},
+	})
+	if len(likesDeduped) > 0 {
+		// Counters were built with the duplicates; rebuild them below
+		if _, err := database.GetCollection("migrations").DeleteOne(ctx, bson.M{"_id": "reaction_counts_backfill"}); err != nil {
+			log.Println("reaction counts backfill reset:", err)
+		}
+	}
+
+	reviews := database.GetCollection("reviews")
+	for targetID, targetType := range createUniqueBySender(ctx, reviews, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "target_id", Value: 1}, {Key: "sender_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "target_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	}) {
+		if targetType == "" {
+			continue // Legacy review without a known target type
+		}
+		if err := RecomputeRating(ctx, targetType, targetID); err != nil {
+			log.Println("review rating recompute:", err)
+		}
+	}
+
+	if err := backfillReactionCounts(ctx); err != nil {
+		log.Println("reaction counts backfill:", err)
 	}
+}
 
-	cursor, _ := coll.Aggregate(ctx, pipeline)
-	var result struct {
-		AvgRating float64 `bson:"avgRating"`
-		Count     int     `bson:"count"`
+// createUniqueBySender builds the indexes of a collection holding one document per
+// (target_id, sender_id). If older duplicates block the unique index, all but the newest
+// are removed and the build retried. Returns the targets that lost documents.
+func createUniqueBySender(ctx context.Context, coll *mongo.Collection, models []mongo.IndexModel) map[primitive.ObjectID]string {
+	_, err := coll.Indexes().CreateMany(ctx, models)
+	if err == nil {
+		return nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		log.Println(coll.Name(), "indexes:", err)
+		return nil
 	}
-	if cursor.Next(ctx) {
-		cursor.Decode(&result)
 
-		// Update Target (Generic approach - try updating Consultant and Product collections)
-		database.GetCollection("consultants").UpdateOne(ctx, bson.M{"_id": targetID}, bson.M{"$set": bson.M{"rating": result.AvgRating, "review_count": result.Count}})
-		database.GetCollection("market_products").UpdateOne(ctx, bson.M{"_id": targetID}, bson.M{"$set": bson.M{"rating": result.AvgRating, "review_count": result.Count}})
+	deduped, err := dedupeBySender(ctx, coll)
+	if err != nil {
+		log.Println(coll.Name(), "dedupe:", err)
+	}
+	if _, err := coll.Indexes().CreateMany(ctx, models); err != nil {
+		log.Println(coll.Name(), "indexes:", err)
+	}
+	return deduped
+}
+
+// dedupeBySender keeps the newest document per (target_id, sender_id) and returns the
+// target type of every target that lost documents
+func dedupeBySender(ctx context.Context, coll *mongo.Collection) (map[primitive.ObjectID]string, error) {
+	cursor, err := coll.Aggregate(ctx, []bson.M{
+		{"$sort": bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{"$group": bson.M{
+			"_id":         bson.M{"target": "$target_id", "sender": "$sender_id"},
+			"ids":         bson.M{"$push": "$_id"},
+			"target_type": bson.M{"$first": "$target_type"},
+		}},
+		{"$match": bson.M{"ids.1": bson.M{"$exists": true}}},
+	}, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	deduped := map[primitive.ObjectID]string{}
+	for cursor.Next(ctx) {
+		var group struct {
+			ID struct {
+				Target primitive.ObjectID `bson:"target"`
+			} `bson:"_id"`
+			IDs        []primitive.ObjectID `bson:"ids"`
+			TargetType string               `bson:"target_type"`
+		}
+		if err := cursor.Decode(&group); err != nil {
+			return deduped, err
+		}
+		if _, err := coll.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": group.IDs[1:]}}); err != nil {
+			return deduped, err
+		}
+		deduped[group.ID.Target] = group.TargetType
 	}
+	return deduped, cursor.Err()
 }
 
 func RegisterReactionRoutes(router *gin.RouterGroup) {
-	router.POST("/reaction/like", ToggleLike)
-	router.POST("/reaction/review", AddReview)
+	router.POST("/reaction/like", auth.RequireAuth(), ToggleLike)
+	router.GET("/reaction/list", ListReactions)
+	router.GET("/reaction/counts", GetReactionCounts)
+	RegisterReviewRoutes(router)
 }
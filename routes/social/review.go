@@ -0,0 +1,345 @@
+package social
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"Agromi/database"
+	"Agromi/routes/auth"
+	consultant_models "Agromi/routes/consultant/models"
+	market "Agromi/routes/market/models"
+	social_models "Agromi/routes/social/models"
+	"Agromi/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var errNoInteraction = errors.New("no completed interaction")
+
+// AddReview creates or updates the signed-in user's review of a consultant or product.
+// Only users with a completed order/rental or a finished consultation may review.
+func AddReview(c *gin.Context) {
+	var body struct {
+		TargetID   string  `json:"target_id" binding:"required"` // ConsultantID or ProductID
+		TargetType string  `json:"target_type" binding:"required,oneof=consultant product"`
+		Rating     float64 `json:"rating" binding:"required,min=1,max=5"`
+		Text       string  `json:"text"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if body.Rating != math.Trunc(body.Rating) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Rating must be a whole number of stars (1-5)"})
+		return
+	}
+
+	targetID, err := primitive.ObjectIDFromHex(body.TargetID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid target_id"})
+		return
+	}
+	senderID := auth.CurrentUserID(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	interactionID, err := findInteraction(ctx, body.TargetType, targetID, senderID)
+	if err == errNoInteraction {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only review after a completed order, rental or consultation"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+
+	coll := database.GetCollection("reviews")
+
+	// Upsert Review
+	filter := bson.M{"target_id": targetID, "sender_id": senderID}
+	update := bson.M{
+		"$set": bson.M{
+			"target_type":    body.TargetType,
+			"interaction_id": interactionID,
+			"rating":         body.Rating,
+			"text":           body.Text,
+			"updated_at":     time.Now(),
+		},
+		"$setOnInsert": bson.M{
+			"created_at": time.Now(),
+			"_id":        primitive.NewObjectID(),
+		},
+	}
+	_, err = coll.UpdateOne(ctx, filter, update, database.UpsertOpt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save review"})
+		return
+	}
+
+	if err := RecomputeRating(ctx, body.TargetType, targetID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Review saved but rating update failed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Review saved"})
+}
+
+// findInteraction returns the completed order or consultation that entitles sender to review target.
+// Completions from before sessions were required carry no completed_by and do not count.
+func findInteraction(ctx context.Context, targetType string, targetID, senderID primitive.ObjectID) (primitive.ObjectID, error) {
+	var collName string
+	var filter bson.M
+
+	switch targetType {
+	case social_models.ReviewTargetProduct:
+		collName = "market_orders"
+		filter = bson.M{"product_id": targetID, "buyer_id": senderID, "status": market.OrderStatusCompleted, "completed_by": bson.M{"$exists": true}}
+	case social_models.ReviewTargetConsultant:
+		collName = "consultations"
+		filter = bson.M{"consultant_id": targetID, "farmer_id": senderID, "status": consultant_models.ConsultationCompleted, "completed_by": bson.M{"$exists": true}}
+	default:
+		return primitive.NilObjectID, errNoInteraction
+	}
+
+	var doc struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	opts := options.FindOne().SetSort(bson.M{"completed_at": -1}).SetProjection(bson.M{"_id": 1})
+	err := database.GetCollection(collName).FindOne(ctx, filter, opts).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return primitive.NilObjectID, errNoInteraction
+	}
+	return doc.ID, err
+}
+
+// RecomputeRating rewrites the average rating and review count on the reviewed entity
+func RecomputeRating(ctx context.Context, targetType string, targetID primitive.ObjectID) error {
+	collName, ok := social_models.ReviewTargetCollections[targetType]
+	if !ok {
+		return errors.New("unknown review target type: " + targetType)
+	}
+
+	pipeline := []bson.M{
+		{"$match": bson.M{"target_id": targetID}},
+		{"$group": bson.M{"_id": "$target_id", "avgRating": bson.M{"$avg": "$rating"}, "count": bson.M{"$sum": 1}}},
+	}
+
+	cursor, err := database.GetCollection("reviews").Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	// No rows means the last review was removed: reset to zero
+	var result struct {
+		AvgRating float64 `bson:"avgRating"`
+		Count     int     `bson:"count"`
+	}
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&result); err != nil {
+			return err
+		}
+	}
+
+	_, err = database.GetCollection(collName).UpdateOne(ctx, bson.M{"_id": targetID}, bson.M{"$set": bson.M{"rating": result.AvgRating, "review_count": result.Count}})
+	return err
+}
+
+// ListReviews returns reviews of a target, newest first (cursor paginated)
+func ListReviews(c *gin.Context) {
+	targetID, err := primitive.ObjectIDFromHex(c.Query("target_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Valid target_id required"})
+		return
+	}
+
+	filter := bson.M{"target_id": targetID}
+	if cursorStr := c.Query("cursor"); cursorStr != "" {
+		cur, err := utils.DecodeCursor(cursorStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		t := time.UnixMilli(int64(cur.Key))
+		filter["$or"] = []bson.M{
+			{"created_at": bson.M{"$lt": t}},
+			{"created_at": t, "_id": bson.M{"$lt": cur.ID}},
+		}
+	}
+
+	limit := utils.ParseLimit(c.Query("limit"), 20, 100)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).SetLimit(limit + 1)
+	cursor, err := database.GetCollection("reviews").Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+
+	reviews := []social_models.Review{}
+	if err := cursor.All(ctx, &reviews); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Parse Error"})
+		return
+	}
+
+	nextCursor := ""
+	if int64(len(reviews)) > limit {
+		reviews = reviews[:limit]
+		last := reviews[len(reviews)-1]
+		nextCursor = utils.EncodeCursor(float64(last.CreatedAt.UnixMilli()), last.ID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reviews": reviews, "next_cursor": nextCursor})
+}
+
+// GetReviewSummary returns the average rating and the star distribution of a target
+func GetReviewSummary(c *gin.Context) {
+	targetID, err := primitive.ObjectIDFromHex(c.Query("target_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Valid target_id required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pipeline := []bson.M{
+		{"$match": bson.M{"target_id": targetID}},
+		{"$group": bson.M{"_id": "$rating", "count": bson.M{"$sum": 1}}},
+	}
+	cursor, err := database.GetCollection("reviews").Aggregate(ctx, pipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+
+	var buckets []struct {
+		Rating float64 `bson:"_id"`
+		Count  int     `bson:"count"`
+	}
+	if err := cursor.All(ctx, &buckets); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Parse Error"})
+		return
+	}
+
+	distribution := map[string]int{"1": 0, "2": 0, "3": 0, "4": 0, "5": 0}
+	total, sum := 0, 0.0
+	for _, b := range buckets {
+		// Older reviews may hold fractional ratings; bucket them by nearest star
+		star := int(math.Round(b.Rating))
+		if star < 1 || star > 5 {
+			continue
+		}
+		distribution[strconv.Itoa(star)] += b.Count
+		total += b.Count
+		sum += b.Rating * float64(b.Count)
+	}
+
+	avg := 0.0
+	if total > 0 {
+		avg = sum / float64(total)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"target_id":    targetID,
+		"average":      avg,
+		"review_count": total,
+		"distribution": distribution,
+	})
+}
+
+// ReplyToReview lets the signed-in owner of the reviewed consultant profile or product respond publicly
+func ReplyToReview(c *gin.Context) {
+	var body struct {
+		ReviewID string `json:"review_id" binding:"required"`
+		Text     string `json:"text" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reviewID, err := primitive.ObjectIDFromHex(body.ReviewID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review_id"})
+		return
+	}
+	ownerID := auth.CurrentUserID(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	coll := database.GetCollection("reviews")
+	var review social_models.Review
+	if err := coll.FindOne(ctx, bson.M{"_id": reviewID}).Decode(&review); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+		return
+	}
+
+	// Ownership: a consultant owns their own profile; a product is owned by owner_id
+	ownerFilter := bson.M{"_id": review.TargetID}
+	if review.TargetType == social_models.ReviewTargetProduct {
+		ownerFilter["owner_id"] = ownerID
+	} else if review.TargetID != ownerID || auth.CurrentUserType(c) != auth.RoleConsultant {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the owner can reply"})
+		return
+	}
+	if collName, ok := social_models.ReviewTargetCollections[review.TargetType]; ok {
+		count, _ := database.GetCollection(collName).CountDocuments(ctx, ownerFilter)
+		if count == 0 {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only the owner can reply"})
+			return
+		}
+	}
+
+	now := time.Now()
+	reply := social_models.ReviewReply{OwnerID: ownerID, Text: body.Text, CreatedAt: now, UpdatedAt: now}
+	if review.Reply != nil {
+		reply.CreatedAt = review.Reply.CreatedAt
+	}
+
+	if _, err := coll.UpdateOne(ctx, bson.M{"_id": reviewID}, bson.M{"$set": bson.M{"reply": reply}}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save reply"})
+		return
+	}
+
+	CreateNotification(ctx, review.SenderID, "review_reply", "The owner replied to your review.", review.ID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reply saved"})
+}
+
+// DeleteReview removes a review and refreshes the target's rating. Used by admin moderation.
+func DeleteReview(ctx context.Context, reviewID primitive.ObjectID) (bool, error) {
+	var review social_models.Review
+	err := database.GetCollection("reviews").FindOneAndDelete(ctx, bson.M{"_id": reviewID}).Decode(&review)
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if review.TargetType == "" {
+		return true, nil // Legacy review without a known target type; nothing safe to recompute
+	}
+	return true, RecomputeRating(ctx, review.TargetType, review.TargetID)
+}
+
+func RegisterReviewRoutes(router *gin.RouterGroup) {
+	router.POST("/reaction/review", auth.RequireAuth(), auth.RequireRole(auth.RoleUser), AddReview)
+	router.GET("/reaction/review/list", ListReviews)
+	router.GET("/reaction/review/summary", GetReviewSummary)
+	router.PUT("/reaction/review/reply", auth.RequireAuth(), ReplyToReview)
+}
@@ -13,6 +13,7 @@ func init() {
 			RegisterCommentRoutes(socialGroup)
 			RegisterReactionRoutes(socialGroup)
 			RegisterFollowRoutes(socialGroup)
+			RegisterBlockRoutes(socialGroup)
 			RegisterNotificationRoutes(socialGroup)
 		}
 	})
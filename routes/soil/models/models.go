@@ -0,0 +1,81 @@
+package soil_models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Sources of a soil test
+const (
+	SourceManual = "manual"
+	SourceSHC    = "shc" // Imported from a Soil Health Card
+)
+
+// Values are one soil sample's results. Nil means not tested.
+// N, P and K are available nutrients in kg/ha (P and K elemental), EC in dS/m,
+// organic carbon in percent and secondary/micronutrients in ppm (mg/kg).
+type Values struct {
+	N  *float64 `bson:"n,omitempty" json:"n,omitempty"`
+	P  *float64 `bson:"p,omitempty" json:"p,omitempty"`
+	K  *float64 `bson:"k,omitempty" json:"k,omitempty"`
+	PH *float64 `bson:"ph,omitempty" json:"ph,omitempty"`
+	EC *float64 `bson:"ec,omitempty" json:"ec,omitempty"`
+	OC *float64 `bson:"oc,omitempty" json:"oc,omitempty"`
+	S  *float64 `bson:"s,omitempty" json:"s,omitempty"`
+	Zn *float64 `bson:"zn,omitempty" json:"zn,omitempty"`
+	Fe *float64 `bson:"fe,omitempty" json:"fe,omitempty"`
+	Cu *float64 `bson:"cu,omitempty" json:"cu,omitempty"`
+	Mn *float64 `bson:"mn,omitempty" json:"mn,omitempty"`
+	B  *float64 `bson:"b,omitempty" json:"b,omitempty"`
+}
+
+// SoilTest is one soil sample of a plot (collection: soil_tests)
+type SoilTest struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OwnerID    primitive.ObjectID `bson:"owner_id" json:"owner_id"`
+	PlotID     primitive.ObjectID `bson:"plot_id" json:"plot_id"`
+	SampleDate time.Time          `bson:"sample_date" json:"sample_date"`
+	Lab        string             `bson:"lab,omitempty" json:"lab,omitempty"`
+	CardNumber string             `bson:"card_number,omitempty" json:"card_number,omitempty"`
+	Source     string             `bson:"source" json:"source"`
+	Notes      string             `bson:"notes,omitempty" json:"notes,omitempty"`
+
+	Values  Values            `bson:"values" json:"values"`
+	Ratings map[string]string `bson:"ratings" json:"ratings"` // Parameter -> low/medium/high etc.
+
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+// Nutrients is a dose in kg/ha of N, P2O5 and K2O
+type Nutrients struct {
+	N    float64 `bson:"n" json:"n"`
+	P2O5 float64 `bson:"p2o5" json:"p2o5"`
+	K2O  float64 `bson:"k2o" json:"k2o"`
+}
+
+// Product is one fertilizer to apply
+type Product struct {
+	Name       string  `bson:"name" json:"name"`
+	KgPerHa    float64 `bson:"kg_per_ha" json:"kg_per_ha"`
+	KgOnPlot   float64 `bson:"kg_on_plot,omitempty" json:"kg_on_plot,omitempty"`
+	BagsOnPlot float64 `bson:"bags_on_plot,omitempty" json:"bags_on_plot,omitempty"` // 50 kg bags
+}
+
+// Recommendation is a fertilizer plan for a crop and target yield (collection: soil_recommendations)
+type Recommendation struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OwnerID     primitive.ObjectID `bson:"owner_id" json:"owner_id"`
+	PlotID      primitive.ObjectID `bson:"plot_id" json:"plot_id"`
+	TestID      primitive.ObjectID `bson:"test_id" json:"test_id"`
+	Crop        string             `bson:"crop" json:"crop"`
+	TargetYield float64            `bson:"target_yield" json:"target_yield"` // Quintal per hectare
+	Method      string             `bson:"method" json:"method"`             // "stcr" or "rdf" (soil-rating adjusted)
+	AreaHa      float64            `bson:"area_ha,omitempty" json:"area_ha,omitempty"`
+
+	Nutrients Nutrients `bson:"nutrients" json:"nutrients"`
+	Products  []Product `bson:"products" json:"products"`
+	Notes     []string  `bson:"notes,omitempty" json:"notes,omitempty"`
+
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
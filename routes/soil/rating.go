@@ -0,0 +1,91 @@
+package soil
+
+import (
+	soil_models "Agromi/routes/soil/models"
+)
+
+// threshold rates a value below Low as "low", at or above High as "high", else "medium"
+type threshold struct {
+	Low, High float64
+}
+
+// Soil Health Card ratings (kg/ha for N, P, K; % for OC; ppm for S and micronutrients).
+// Micronutrients only have a sufficiency limit, so High equals Low.
+var thresholds = map[string]threshold{
+	"n":  {280, 560},
+	"p":  {10, 25},
+	"k":  {110, 280},
+	"oc": {0.5, 0.75},
+	"s":  {10, 20},
+	"zn": {0.6, 0.6},
+	"fe": {4.5, 4.5},
+	"cu": {0.2, 0.2},
+	"mn": {2, 2},
+	"b":  {0.5, 0.5},
+}
+
+// params lists each parameter with its value, in card order
+func params(v soil_models.Values) []struct {
+	Key   string
+	Value *float64
+} {
+	return []struct {
+		Key   string
+		Value *float64
+	}{
+		{"ph", v.PH}, {"ec", v.EC}, {"oc", v.OC}, {"n", v.N}, {"p", v.P}, {"k", v.K},
+		{"s", v.S}, {"zn", v.Zn}, {"fe", v.Fe}, {"cu", v.Cu}, {"mn", v.Mn}, {"b", v.B},
+	}
+}
+
+// rate returns the card rating of one parameter
+func rate(key string, v float64) string {
+	switch key {
+	case "ph":
+		switch {
+		case v < 5.5:
+			return "strongly_acidic"
+		case v < 6.5:
+			return "acidic"
+		case v <= 7.5:
+			return "neutral"
+		case v <= 8.5:
+			return "alkaline"
+		}
+		return "sodic"
+	case "ec":
+		if v < 1 {
+			return "normal"
+		}
+		if v < 3 {
+			return "slightly_saline"
+		}
+		return "saline"
+	}
+
+	t := thresholds[key]
+	if t.Low == t.High {
+		if v < t.Low {
+			return "deficient"
+		}
+		return "sufficient"
+	}
+	switch {
+	case v < t.Low:
+		return "low"
+	case v >= t.High:
+		return "high"
+	}
+	return "medium"
+}
+
+// ratings rates every tested parameter
+func ratings(v soil_models.Values) map[string]string {
+	out := map[string]string{}
+	for _, p := range params(v) {
+		if p.Value != nil {
+			out[p.Key] = rate(p.Key, *p.Value)
+		}
+	}
+	return out
+}
@@ -0,0 +1,269 @@
+package soil
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"Agromi/database"
+	"Agromi/routes/auth"
+	farm_models "Agromi/routes/farm/models"
+	soil_models "Agromi/routes/soil/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// stcr holds Soil Test Crop Response targeted-yield equations (ICAR-AICRP, general coefficients):
+// dose = a*T - b*soil for each nutrient, T = target yield in q/ha, soil test in kg/ha.
+// State-specific equations differ; these are the commonly published averages.
+type stcr struct {
+	NT, NS float64
+	PT, PS float64
+	KT, KS float64
+}
+
+// cropProfile is what the engine knows about a crop
+type cropProfile struct {
+	TypicalYield float64    // q/ha, the default target
+	RDF          [3]float64 // Recommended N, P2O5, K2O kg/ha for a medium-fertility soil
+	STCR         *stcr
+}
+
+var crops = map[string]cropProfile{
+	"wheat":     {45, [3]float64{120, 60, 40}, &stcr{4.75, 0.47, 3.37, 2.71, 2.29, 0.24}},
+	"rice":      {50, [3]float64{100, 50, 50}, &stcr{4.25, 0.45, 3.35, 3.20, 2.25, 0.20}},
+	"paddy":     {50, [3]float64{100, 50, 50}, &stcr{4.25, 0.45, 3.35, 3.20, 2.25, 0.20}},
+	"maize":     {50, [3]float64{120, 60, 40}, &stcr{4.10, 0.44, 2.60, 2.90, 1.80, 0.20}},
+	"cotton":    {20, [3]float64{100, 50, 50}, &stcr{12.4, 0.55, 5.20, 3.40, 6.30, 0.35}},
+	"soybean":   {20, [3]float64{25, 60, 40}, &stcr{2.50, 0.20, 6.70, 4.30, 4.00, 0.25}},
+	"chickpea":  {15, [3]float64{20, 40, 20}, nil},
+	"gram":      {15, [3]float64{20, 40, 20}, nil},
+	"mustard":   {18, [3]float64{80, 40, 40}, nil},
+	"groundnut": {20, [3]float64{20, 40, 40}, nil},
+	"sugarcane": {800, [3]float64{250, 115, 115}, nil},
+	"potato":    {250, [3]float64{150, 80, 100}, nil},
+	"onion":     {250, [3]float64{100, 50, 50}, nil},
+	"tomato":    {300, [3]float64{120, 60, 60}, nil},
+}
+
+// Fertilizer grades (fraction of the nutrient)
+const (
+	ureaN   = 0.46
+	dapN    = 0.18
+	dapP2O5 = 0.46
+	sspP2O5 = 0.16
+	mopK2O  = 0.60
+	bagKg   = 50.0
+)
+
+// ratingFactor adjusts a recommended dose by the soil rating, as on Soil Health Cards
+func ratingFactor(rating string) float64 {
+	switch rating {
+	case "low":
+		return 1.25
+	case "high":
+		return 0.75
+	}
+	return 1
+}
+
+// clampDose keeps a computed dose between a maintenance floor and 1.5x the standard dose
+func clampDose(v, rdf float64) float64 {
+	return math.Round(math.Max(rdf*0.25, math.Min(v, rdf*1.5)))
+}
+
+// recommend computes nutrient doses and products for a soil test, crop and target yield (q/ha)
+func recommend(t soil_models.SoilTest, profile cropProfile, target float64) (string, soil_models.Nutrients, []soil_models.Product, []string) {
+	var n soil_models.Nutrients
+	var notes []string
+	rdf := profile.RDF
+	method := "rdf"
+
+	if profile.STCR != nil && t.Values.N != nil && t.Values.P != nil && t.Values.K != nil {
+		e := profile.STCR
+		method = "stcr"
+		n.N = clampDose(e.NT*target-e.NS**t.Values.N, rdf[0])
+		n.P2O5 = clampDose(e.PT*target-e.PS**t.Values.P, rdf[1])
+		n.K2O = clampDose(e.KT*target-e.KS**t.Values.K, rdf[2])
+	} else {
+		scale := target / profile.TypicalYield
+		dose := func(i int, key string) float64 {
+			f := 1.0
+			if r, ok := t.Ratings[key]; ok {
+				f = ratingFactor(r)
+			} else {
+				notes = append(notes, fmt.Sprintf("%s not tested, standard dose used", strings.ToUpper(key)))
+			}
+			return math.Round(rdf[i] * scale * f)
+		}
+		n.N, n.P2O5, n.K2O = dose(0, "n"), dose(1, "p"), dose(2, "k")
+	}
+
+	// Phosphorus as SSP when sulphur is short (SSP carries 12% S), else DAP; the rest of N as urea
+	var products []soil_models.Product
+	nFromP := 0.0
+	if n.P2O5 > 0 {
+		if t.Ratings["s"] == "low" {
+			products = append(products, soil_models.Product{Name: "SSP", KgPerHa: math.Round(n.P2O5 / sspP2O5)})
+			notes = append(notes, "Sulphur is low: phosphorus given as single super phosphate, which also supplies sulphur")
+		} else {
+			dap := n.P2O5 / dapP2O5
+			nFromP = dap * dapN
+			products = append(products, soil_models.Product{Name: "DAP", KgPerHa: math.Round(dap)})
+		}
+	}
+	if urea := (n.N - nFromP) / ureaN; urea > 0 {
+		products = append(products, soil_models.Product{Name: "Urea", KgPerHa: math.Round(urea)})
+	}
+	if n.K2O > 0 {
+		products = append(products, soil_models.Product{Name: "MOP", KgPerHa: math.Round(n.K2O / mopK2O)})
+	}
+
+	// Micronutrients and amendments
+	if t.Ratings["zn"] == "deficient" {
+		products = append(products, soil_models.Product{Name: "Zinc sulphate", KgPerHa: 25})
+	}
+	if t.Ratings["b"] == "deficient" {
+		products = append(products, soil_models.Product{Name: "Borax", KgPerHa: 10})
+	}
+	if t.Ratings["fe"] == "deficient" {
+		notes = append(notes, "Iron is deficient: spray 0.5% ferrous sulphate on the crop")
+	}
+	switch t.Ratings["ph"] {
+	case "strongly_acidic":
+		notes = append(notes, "Soil is strongly acidic: apply agricultural lime as advised by your soil lab")
+	case "sodic":
+		notes = append(notes, "Soil is sodic: apply gypsum as advised by your soil lab")
+	}
+	if t.Ratings["oc"] == "low" {
+		notes = append(notes, "Organic carbon is low: add farmyard manure or compost")
+	}
+	if t.Ratings["ec"] == "saline" {
+		notes = append(notes, "Soil is saline: prefer salt-tolerant varieties and leach with good-quality water")
+	}
+	if n.N > 0 {
+		notes = append(notes, "Apply all P and K and a third of the N at sowing; split the remaining N at later growth stages")
+	}
+	return method, n, products, notes
+}
+
+// Recommend computes a fertilizer plan for a plot from its latest soil test (or test_id)
+func Recommend(c *gin.Context) {
+	var body struct {
+		PlotID      string  `json:"plot_id" binding:"required"`
+		TestID      string  `json:"test_id"` // Defaults to the plot's latest test
+		Crop        string  `json:"crop" binding:"required"`
+		TargetYield float64 `json:"target_yield"` // q/ha, defaults to the crop's typical yield
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	plotID, err := primitive.ObjectIDFromHex(body.PlotID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid plot_id"})
+		return
+	}
+	crop := strings.ToLower(strings.TrimSpace(body.Crop))
+	profile, ok := crops[crop]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No fertilizer data for this crop yet"})
+		return
+	}
+	if body.TargetYield == 0 {
+		body.TargetYield = profile.TypicalYield
+	}
+	if body.TargetYield < profile.TypicalYield*0.3 || body.TargetYield > profile.TypicalYield*2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("target_yield must be between %.0f and %.0f q/ha for %s", profile.TypicalYield*0.3, profile.TypicalYield*2, crop)})
+		return
+	}
+	ownerID := auth.CurrentUserID(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var plot farm_models.Plot
+	if err := database.GetCollection("farm_plots").FindOne(ctx, bson.M{"_id": plotID, "owner_id": ownerID}, options.FindOne().SetProjection(bson.M{"area_hectares": 1})).Decode(&plot); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Plot not found"})
+		return
+	}
+
+	filter := bson.M{"owner_id": ownerID, "plot_id": plotID}
+	if body.TestID != "" {
+		if filter["_id"], err = primitive.ObjectIDFromHex(body.TestID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid test_id"})
+			return
+		}
+	}
+	var test soil_models.SoilTest
+	err = database.GetCollection("soil_tests").FindOne(ctx, filter, options.FindOne().SetSort(bson.M{"sample_date": -1})).Decode(&test)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No soil test for this plot"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	method, nutrients, products, notes := recommend(test, profile, body.TargetYield)
+	if plot.AreaHectares > 0 {
+		for i := range products {
+			products[i].KgOnPlot = math.Round(products[i].KgPerHa*plot.AreaHectares*10) / 10
+			products[i].BagsOnPlot = math.Ceil(products[i].KgOnPlot/bagKg*2) / 2
+		}
+	}
+	if time.Since(test.SampleDate) > 3*365*24*time.Hour {
+		notes = append(notes, "This soil test is more than 3 years old; a new test is recommended")
+	}
+
+	rec := soil_models.Recommendation{
+		ID:          primitive.NewObjectID(),
+		OwnerID:     ownerID,
+		PlotID:      plotID,
+		TestID:      test.ID,
+		Crop:        crop,
+		TargetYield: body.TargetYield,
+		Method:      method,
+		AreaHa:      math.Round(plot.AreaHectares*1000) / 1000,
+		Nutrients:   nutrients,
+		Products:    products,
+		Notes:       notes,
+		CreatedAt:   time.Now(),
+	}
+	database.GetCollection("soil_recommendations").InsertOne(ctx, rec)
+
+	c.JSON(http.StatusOK, rec)
+}
+
+// ListRecommendations returns past fertilizer plans, newest first; plot_id narrows to one plot
+func ListRecommendations(c *gin.Context) {
+	filter := bson.M{"owner_id": auth.CurrentUserID(c)}
+	if id, err := primitive.ObjectIDFromHex(c.Query("plot_id")); err == nil {
+		filter["plot_id"] = id
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(100)
+	cursor, err := database.GetCollection("soil_recommendations").Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	recs := []soil_models.Recommendation{}
+	if err := cursor.All(ctx, &recs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error parsing recommendations"})
+		return
+	}
+	c.JSON(http.StatusOK, recs)
+}
@@ -0,0 +1,137 @@
+package soil
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	soil_models "Agromi/routes/soil/models"
+)
+
+func ptr(v float64) *float64 { return &v }
+
+func TestRecommend(t *testing.T) {
+	tests := []struct {
+		name     string
+		test     soil_models.SoilTest
+		crop     string
+		target   float64
+		method   string
+		doses    soil_models.Nutrients
+		products []soil_models.Product
+		note     string // One note that must be present
+	}{
+		{
+			name:   "stcr wheat",
+			test:   soil_models.SoilTest{Values: soil_models.Values{N: ptr(280), P: ptr(20), K: ptr(250)}},
+			crop:   "wheat",
+			target: 45,
+			method: "stcr",
+			// N 4.75*45-0.47*280, P2O5 3.37*45-2.71*20 capped at 1.5x60, K2O 2.29*45-0.24*250
+			doses:    soil_models.Nutrients{N: 82, P2O5: 90, K2O: 43},
+			products: []soil_models.Product{{Name: "DAP", KgPerHa: 196}, {Name: "Urea", KgPerHa: 102}, {Name: "MOP", KgPerHa: 72}},
+			note:     "split the remaining N",
+		},
+		{
+			name:   "stcr rich soil falls to the maintenance floor",
+			test:   soil_models.SoilTest{Values: soil_models.Values{N: ptr(600), P: ptr(80), K: ptr(600)}},
+			crop:   "wheat",
+			target: 45,
+			method: "stcr",
+			doses:  soil_models.Nutrients{N: 30, P2O5: 15, K2O: 10},
+			products: []soil_models.Product{
+				{Name: "DAP", KgPerHa: 33}, {Name: "Urea", KgPerHa: 52}, {Name: "MOP", KgPerHa: 17},
+			},
+		},
+		{
+			name:     "stcr crop without a full test uses rdf",
+			test:     soil_models.SoilTest{Values: soil_models.Values{N: ptr(280), P: ptr(20)}},
+			crop:     "wheat",
+			target:   45,
+			method:   "rdf",
+			doses:    soil_models.Nutrients{N: 120, P2O5: 60, K2O: 40},
+			products: []soil_models.Product{{Name: "DAP", KgPerHa: 130}, {Name: "Urea", KgPerHa: 210}, {Name: "MOP", KgPerHa: 67}},
+			note:     "K not tested, standard dose used",
+		},
+		{
+			name:     "rdf scaled by ratings",
+			test:     soil_models.SoilTest{Ratings: map[string]string{"n": "low", "p": "high"}},
+			crop:     "chickpea",
+			target:   15,
+			method:   "rdf",
+			doses:    soil_models.Nutrients{N: 25, P2O5: 30, K2O: 20},
+			products: []soil_models.Product{{Name: "DAP", KgPerHa: 65}, {Name: "Urea", KgPerHa: 29}, {Name: "MOP", KgPerHa: 33}},
+			note:     "K not tested, standard dose used",
+		},
+		{
+			name:   "rdf scaled by target yield",
+			test:   soil_models.SoilTest{Ratings: map[string]string{"n": "medium", "p": "medium", "k": "medium"}},
+			crop:   "mustard",
+			target: 27,
+			method: "rdf",
+			doses:  soil_models.Nutrients{N: 120, P2O5: 60, K2O: 60},
+			products: []soil_models.Product{
+				{Name: "DAP", KgPerHa: 130}, {Name: "Urea", KgPerHa: 210}, {Name: "MOP", KgPerHa: 100},
+			},
+		},
+		{
+			name: "low sulphur and zinc",
+			test: soil_models.SoilTest{Ratings: map[string]string{
+				"n": "medium", "p": "medium", "k": "medium", "s": "low", "zn": "deficient",
+			}},
+			crop:   "groundnut",
+			target: 20,
+			method: "rdf",
+			doses:  soil_models.Nutrients{N: 20, P2O5: 40, K2O: 40},
+			products: []soil_models.Product{
+				{Name: "SSP", KgPerHa: 250}, {Name: "Urea", KgPerHa: 43}, {Name: "MOP", KgPerHa: 67}, {Name: "Zinc sulphate", KgPerHa: 25},
+			},
+			note: "single super phosphate",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method, doses, products, notes := recommend(tt.test, crops[tt.crop], tt.target)
+			if method != tt.method {
+				t.Errorf("method = %q, want %q", method, tt.method)
+			}
+			if doses != tt.doses {
+				t.Errorf("doses = %+v, want %+v", doses, tt.doses)
+			}
+			if !reflect.DeepEqual(products, tt.products) {
+				t.Errorf("products = %+v, want %+v", products, tt.products)
+			}
+			if tt.note != "" && !strings.Contains(strings.Join(notes, "\n"), tt.note) {
+				t.Errorf("notes %q do not mention %q", notes, tt.note)
+			}
+		})
+	}
+}
+
+func TestConvertUnit(t *testing.T) {
+	tests := []struct {
+		key, unit string
+		in, want  float64
+	}{
+		{"n", "kg/ha", 280, 280},
+		{"n", "ppm", 100, 224},
+		{"p", "mg/kg", 10, 22.4},
+		{"p", "kg P2O5/ha", 50, 21.82},
+		{"p", "ppm P2O5", 10, 9.775},
+		{"k", "kg K2O / ha", 200, 166.02},
+		{"k", "kg/ha", 200, 200},
+		{"oc", "%", 0.6, 0.6},
+		{"oc", "g/kg", 6, 0.6},
+		{"ec", "dS/m", 0.4, 0.4},
+		{"ec", "µS/cm", 400, 0.4},
+		{"ec", "uS/cm", 1200, 1.2},
+		{"ph", "", 7.2, 7.2},
+		{"zn", "ppm", 0.8, 0.8},
+	}
+	for _, tt := range tests {
+		if got := convertUnit(tt.key, tt.unit, tt.in); math.Abs(got-tt.want) > 1e-3 {
+			t.Errorf("convertUnit(%q, %q, %v) = %v, want %v", tt.key, tt.unit, tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package soil
+
+import (
+	"context"
+	"time"
+
+	"Agromi/core/router"
+	"Agromi/database"
+	"Agromi/routes/auth"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func init() {
+	router.Register(func(r *gin.Engine) {
+		group := r.Group("/api/soil", auth.RequireAuth(), auth.RequireRole(auth.RoleUser))
+		{
+			group.POST("/tests", CreateTest)
+			group.POST("/tests/import", ImportCard)
+			group.GET("/tests", ListTests)
+			group.DELETE("/tests/:id", DeleteTest)
+			group.GET("/trends", Trends)
+
+			group.POST("/recommend", Recommend)
+			group.GET("/recommendations", ListRecommendations)
+		}
+	})
+
+	go createSoilIndexes()
+}
+
+// createSoilIndexes backs per-plot history and latest-test lookups
+func createSoilIndexes() {
+	if !database.WaitForClient(30 * time.Second) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, _ = database.GetCollection("soil_tests").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "plot_id", Value: 1}, {Key: "sample_date", Value: -1}}},
+	})
+	_, _ = database.GetCollection("soil_recommendations").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+}
@@ -0,0 +1,432 @@
+package soil
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"Agromi/database"
+	"Agromi/routes/auth"
+	soil_models "Agromi/routes/soil/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Plausible ranges per parameter, to catch unit mix-ups
+var valueRanges = map[string][2]float64{
+	"n": {0, 2000}, "p": {0, 500}, "k": {0, 3000},
+	"ph": {0, 14}, "ec": {0, 20}, "oc": {0, 10},
+	"s": {0, 500}, "zn": {0, 100}, "fe": {0, 500}, "cu": {0, 100}, "mn": {0, 500}, "b": {0, 50},
+}
+
+// Date layouts used on Soil Health Cards and by clients
+var dateLayouts = []string{"2006-01-02", "02-01-2006", "02/01/2006", time.RFC3339}
+
+func parseDate(s string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, strings.TrimSpace(s)); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid sample_date %q", s)
+}
+
+// validateValues checks ranges and that something was tested
+func validateValues(v soil_models.Values) error {
+	tested := 0
+	for _, p := range params(v) {
+		if p.Value == nil {
+			continue
+		}
+		tested++
+		r := valueRanges[p.Key]
+		if *p.Value < r[0] || *p.Value > r[1] {
+			return fmt.Errorf("%s must be between %g and %g", p.Key, r[0], r[1])
+		}
+	}
+	if tested == 0 {
+		return errors.New("at least one parameter required")
+	}
+	return nil
+}
+
+// ownsPlot checks the plot belongs to the farmer
+func ownsPlot(ctx context.Context, ownerID, plotID primitive.ObjectID) bool {
+	n, _ := database.GetCollection("farm_plots").CountDocuments(ctx, bson.M{"_id": plotID, "owner_id": ownerID})
+	return n > 0
+}
+
+// saveTest validates and stores a test; it writes the HTTP response
+func saveTest(c *gin.Context, t soil_models.SoilTest) {
+	if err := validateValues(t.Values); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if t.SampleDate.After(time.Now().Add(24 * time.Hour)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sample_date is in the future"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if !ownsPlot(ctx, t.OwnerID, t.PlotID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Plot not found"})
+		return
+	}
+
+	t.ID = primitive.NewObjectID()
+	t.Ratings = ratings(t.Values)
+	t.CreatedAt = time.Now()
+	if _, err := database.GetCollection("soil_tests").InsertOne(ctx, t); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save soil test"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, t)
+}
+
+// CreateTest records a soil test entered by hand
+func CreateTest(c *gin.Context) {
+	var body struct {
+		PlotID     string             `json:"plot_id" binding:"required"`
+		SampleDate string             `json:"sample_date" binding:"required"`
+		Lab        string             `json:"lab"`
+		CardNumber string             `json:"card_number"`
+		Notes      string             `json:"notes"`
+		Values     soil_models.Values `json:"values"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	plotID, err := primitive.ObjectIDFromHex(body.PlotID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid plot_id"})
+		return
+	}
+	date, err := parseDate(body.SampleDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	saveTest(c, soil_models.SoilTest{
+		OwnerID:    auth.CurrentUserID(c),
+		PlotID:     plotID,
+		SampleDate: date,
+		Lab:        strings.TrimSpace(body.Lab),
+		CardNumber: strings.TrimSpace(body.CardNumber),
+		Notes:      strings.TrimSpace(body.Notes),
+		Source:     soil_models.SourceManual,
+		Values:     body.Values,
+	})
+}
+
+// cardParameter maps a Soil Health Card parameter name, e.g. "Available Nitrogen (N)", to its key
+func cardParameter(name string) string {
+	name = strings.ToLower(name)
+	if i, j := strings.LastIndex(name, "("), strings.LastIndex(name, ")"); i >= 0 && j > i {
+		symbol := strings.TrimSpace(name[i+1 : j])
+		if _, ok := valueRanges[symbol]; ok {
+			return symbol
+		}
+	}
+	keywords := []struct{ word, key string }{
+		{"nitrogen", "n"}, {"phosph", "p"}, {"potass", "k"}, {"sulphur", "s"}, {"sulfur", "s"},
+		{"zinc", "zn"}, {"iron", "fe"}, {"copper", "cu"}, {"mangan", "mn"}, {"boron", "b"},
+		{"organic carbon", "oc"}, {"conductivity", "ec"}, {"ph", "ph"},
+	}
+	for _, k := range keywords {
+		if strings.Contains(name, k.word) {
+			return k.key
+		}
+	}
+	return ""
+}
+
+// convertUnit brings a card value into the units of soil_models.Values
+func convertUnit(key, unit string, v float64) float64 {
+	unit = strings.ToLower(strings.ReplaceAll(unit, " ", ""))
+	switch key {
+	case "n", "p", "k":
+		if strings.Contains(unit, "ppm") || strings.Contains(unit, "mg/kg") {
+			v *= 2.24 // 15 cm furrow slice
+		}
+		if key == "p" && strings.Contains(unit, "p2o5") {
+			v *= 0.4364
+		}
+		if key == "k" && strings.Contains(unit, "k2o") {
+			v *= 0.8301
+		}
+	case "oc":
+		if strings.Contains(unit, "g/kg") {
+			v /= 10
+		}
+	case "ec":
+		if strings.Contains(unit, "µs") || strings.Contains(unit, "us/cm") {
+			v /= 1000
+		}
+	}
+	return v
+}
+
+// setValue stores a parsed card value under its key
+func setValue(v *soil_models.Values, key string, value float64) {
+	x := value
+	targets := map[string]**float64{
+		"n": &v.N, "p": &v.P, "k": &v.K, "ph": &v.PH, "ec": &v.EC, "oc": &v.OC,
+		"s": &v.S, "zn": &v.Zn, "fe": &v.Fe, "cu": &v.Cu, "mn": &v.Mn, "b": &v.B,
+	}
+	if t, ok := targets[key]; ok {
+		*t = &x
+	}
+}
+
+type cardRow struct {
+	Parameter string `json:"parameter"`
+	Value     string `json:"value"`
+	Unit      string `json:"unit"`
+}
+
+// cardValues converts the card's parameter table; unknown rows are reported and skipped
+func cardValues(rows []cardRow) (soil_models.Values, []string) {
+	var v soil_models.Values
+	var skipped []string
+	for _, r := range rows {
+		key := cardParameter(r.Parameter)
+		value, err := strconv.ParseFloat(strings.TrimSpace(r.Value), 64)
+		if key == "" || err != nil {
+			skipped = append(skipped, r.Parameter)
+			continue
+		}
+		setValue(&v, key, convertUnit(key, r.Unit, value))
+	}
+	return v, skipped
+}
+
+// readCardCSV reads the parameter table of a card export: Parameter, Test Value (or Value), Unit
+func readCardCSV(r io.Reader) ([]cardRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("empty or unreadable CSV")
+	}
+	col := map[string]int{}
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		col[strings.ReplaceAll(h, " ", "_")] = i
+	}
+	valueCol, ok := col["test_value"]
+	if !ok {
+		valueCol, ok = col["value"]
+	}
+	paramCol, ok2 := col["parameter"]
+	if !ok || !ok2 {
+		return nil, errors.New("CSV needs Parameter and Test Value columns")
+	}
+	unitCol, hasUnit := col["unit"]
+
+	var rows []cardRow
+	for {
+		rec, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if paramCol >= len(rec) || valueCol >= len(rec) {
+			continue
+		}
+		row := cardRow{Parameter: rec[paramCol], Value: rec[valueCol]}
+		if hasUnit && unitCol < len(rec) {
+			row.Unit = rec[unitCol]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// ImportCard imports a Soil Health Card: JSON with a parameters table, or a multipart
+// "file" CSV of the table with plot_id, card_number, sample_date and lab as form fields
+func ImportCard(c *gin.Context) {
+	var body struct {
+		PlotID     string    `json:"plot_id" form:"plot_id"`
+		CardNumber string    `json:"card_number" form:"card_number"`
+		SampleDate string    `json:"sample_date" form:"sample_date"`
+		Lab        string    `json:"lab" form:"lab"`
+		Parameters []cardRow `json:"parameters"`
+	}
+
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		if err := c.ShouldBind(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		fh, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file required"})
+			return
+		}
+		f, err := fh.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot read file"})
+			return
+		}
+		defer f.Close()
+		if body.Parameters, err = readCardCSV(io.LimitReader(f, 1<<20)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	} else if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plotID, err := primitive.ObjectIDFromHex(body.PlotID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid plot_id"})
+		return
+	}
+	date, err := parseDate(body.SampleDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	values, skipped := cardValues(body.Parameters)
+
+	notes := ""
+	if len(skipped) > 0 {
+		notes = "Not imported: " + strings.Join(skipped, ", ")
+	}
+	saveTest(c, soil_models.SoilTest{
+		OwnerID:    auth.CurrentUserID(c),
+		PlotID:     plotID,
+		SampleDate: date,
+		Lab:        strings.TrimSpace(body.Lab),
+		CardNumber: strings.TrimSpace(body.CardNumber),
+		Notes:      notes,
+		Source:     soil_models.SourceSHC,
+		Values:     values,
+	})
+}
+
+// ListTests returns the farmer's soil tests, newest sample first; plot_id narrows to one plot
+func ListTests(c *gin.Context) {
+	filter := bson.M{"owner_id": auth.CurrentUserID(c)}
+	if id, err := primitive.ObjectIDFromHex(c.Query("plot_id")); err == nil {
+		filter["plot_id"] = id
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "sample_date", Value: -1}}).SetLimit(200)
+	cursor, err := database.GetCollection("soil_tests").Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	tests := []soil_models.SoilTest{}
+	if err := cursor.All(ctx, &tests); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error parsing soil tests"})
+		return
+	}
+	c.JSON(http.StatusOK, tests)
+}
+
+// DeleteTest removes one of the farmer's soil tests
+func DeleteTest(c *gin.Context) {
+	testID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := database.GetCollection("soil_tests").DeleteOne(ctx, bson.M{"_id": testID, "owner_id": auth.CurrentUserID(c)})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete soil test"})
+		return
+	}
+	if res.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Soil test not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Soil test deleted"})
+}
+
+// Trends returns each parameter's values over time for a plot, for charts
+func Trends(c *gin.Context) {
+	plotID, err := primitive.ObjectIDFromHex(c.Query("plot_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Valid plot_id required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "sample_date", Value: 1}})
+	cursor, err := database.GetCollection("soil_tests").Find(ctx, bson.M{"owner_id": auth.CurrentUserID(c), "plot_id": plotID}, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	var tests []soil_models.SoilTest
+	if err := cursor.All(ctx, &tests); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error parsing soil tests"})
+		return
+	}
+
+	type point struct {
+		Date   time.Time `json:"date"`
+		Value  float64   `json:"value"`
+		Rating string    `json:"rating"`
+	}
+	type trend struct {
+		Parameter string  `json:"parameter"`
+		Points    []point `json:"points"`
+		Change    float64 `json:"change"` // Last minus first
+	}
+
+	series := map[string]*trend{}
+	var keys []string
+	for _, t := range tests {
+		for _, p := range params(t.Values) {
+			if p.Value == nil {
+				continue
+			}
+			s, ok := series[p.Key]
+			if !ok {
+				s = &trend{Parameter: p.Key}
+				series[p.Key] = s
+				keys = append(keys, p.Key)
+			}
+			s.Points = append(s.Points, point{Date: t.SampleDate, Value: *p.Value, Rating: rate(p.Key, *p.Value)})
+		}
+	}
+
+	out := make([]trend, 0, len(keys))
+	for _, k := range keys {
+		s := series[k]
+		s.Change = s.Points[len(s.Points)-1].Value - s.Points[0].Value
+		out = append(out, *s)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"plot_id": plotID, "tests": len(tests), "parameters": out})
+}
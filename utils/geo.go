@@ -2,6 +2,8 @@ package utils
 
 import (
 	"math"
+
+	"go.mongodb.org/mongo-driver/bson"
 )
 
 // Haversine calculates distance between two points in km
@@ -17,3 +19,29 @@ func Haversine(lat1, lon1, lat2, lon2 float64) float64 {
 
 	return R * c
 }
+
+// HaversineExpr builds an aggregation expression computing the distance in km between
+// (lat, lon) and the GeoJSON point stored at field. Evaluates to null if the field is missing.
+func HaversineExpr(field string, lat, lon float64) bson.M {
+	const R = 6371
+	lat1 := lat * (math.Pi / 180.0)
+	lon1 := lon * (math.Pi / 180.0)
+
+	lon2 := bson.M{"$degreesToRadians": bson.M{"$arrayElemAt": []interface{}{"$" + field + ".coordinates", 0}}}
+	lat2 := bson.M{"$degreesToRadians": bson.M{"$arrayElemAt": []interface{}{"$" + field + ".coordinates", 1}}}
+
+	halfDLat := bson.M{"$divide": []interface{}{bson.M{"$subtract": []interface{}{lat2, lat1}}, 2}}
+	halfDLon := bson.M{"$divide": []interface{}{bson.M{"$subtract": []interface{}{lon2, lon1}}, 2}}
+	sinSq := func(x bson.M) bson.M { return bson.M{"$pow": []interface{}{bson.M{"$sin": x}, 2}} }
+
+	a := bson.M{"$add": []interface{}{
+		sinSq(halfDLat),
+		bson.M{"$multiply": []interface{}{math.Cos(lat1), bson.M{"$cos": lat2}, sinSq(halfDLon)}},
+	}}
+	c := bson.M{"$multiply": []interface{}{2, bson.M{"$atan2": []interface{}{
+		bson.M{"$sqrt": a},
+		bson.M{"$sqrt": bson.M{"$subtract": []interface{}{1, a}}},
+	}}}}
+
+	return bson.M{"$multiply": []interface{}{R, c}}
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Cursor marks the last item of a page so the next page can resume after it.
+// Key holds the primary sort value (unix millis for dates, the raw number for
+// counts/scores) and ID breaks ties between items sharing the same key.
+type Cursor struct {
+	Key  float64            `json:"k"`
+	ID   primitive.ObjectID `json:"id"`
+	AsOf int64              `json:"t,omitempty"` // Unix millis the first page was computed at (time-dependent scores)
+}
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// EncodeCursor returns an opaque, URL-safe cursor string
+func EncodeCursor(key float64, id primitive.ObjectID) string {
+	raw, _ := json.Marshal(Cursor{Key: key, ID: id})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// EncodeScoreCursor is EncodeCursor for rankings that depend on the current time.
+// Later pages reuse asOf so every item keeps the score it had on the first page.
+func EncodeScoreCursor(score float64, id primitive.ObjectID, asOf time.Time) string {
+	raw, _ := json.Marshal(Cursor{Key: score, ID: id, AsOf: asOf.UnixMilli()})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor parses a cursor produced by EncodeCursor
+func DecodeCursor(s string) (Cursor, error) {
+	var cur Cursor
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cur, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(raw, &cur); err != nil || cur.ID.IsZero() {
+		return cur, ErrInvalidCursor
+	}
+	return cur, nil
+}
+
+// ParseLimit reads a page size from a query value, falling back to def and capping at max
+func ParseLimit(s string, def, max int64) int64 {
+	limit, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || limit <= 0 {
+		return def
+	}
+	if limit > max {
+		return max
+	}
+	return limit
+}
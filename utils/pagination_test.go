@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	id := primitive.NewObjectID()
+	asOf := time.UnixMilli(1700000000123)
+
+	tests := []struct {
+		name string
+		enc  string
+		want Cursor
+	}{
+		{"date key", EncodeCursor(1700000000000, id), Cursor{Key: 1700000000000, ID: id}},
+		{"zero key", EncodeCursor(0, id), Cursor{Key: 0, ID: id}},
+		{"fractional score", EncodeScoreCursor(2.75, id, asOf), Cursor{Key: 2.75, ID: id, AsOf: asOf.UnixMilli()}},
+		{"negative score", EncodeScoreCursor(-0.5, id, asOf), Cursor{Key: -0.5, ID: id, AsOf: asOf.UnixMilli()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeCursor(tt.enc)
+			if err != nil {
+				t.Fatalf("DecodeCursor: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{"empty", ""},
+		{"not base64", "!!!"},
+		{"not json", "bm90IGpzb24"},
+		{"missing id", EncodeCursor(10, primitive.NilObjectID)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCursor(tt.in); err != ErrInvalidCursor {
+				t.Errorf("DecodeCursor(%q) error = %v, want ErrInvalidCursor", tt.in, err)
+			}
+		})
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"", 20},
+		{"abc", 20},
+		{"0", 20},
+		{"-5", 20},
+		{"1", 1},
+		{"50", 50},
+		{"100", 100},
+		{"101", 100},
+		{"99999999999999999999", 20}, // Overflows int64
+	}
+	for _, tt := range tests {
+		if got := ParseLimit(tt.in, 20, 100); got != tt.want {
+			t.Errorf("ParseLimit(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
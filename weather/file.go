@@ -0,0 +1,55 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileProvider serves forecasts from JSON fixtures, for tests and local development.
+// It reads "<cell>.json" (cell key with ':' replaced by '_') and falls back to "default.json".
+// Days without a date are placed day_offset days from today, so fixtures never go stale.
+type FileProvider struct {
+	Dir string
+}
+
+func NewFileProvider(dir string) *FileProvider {
+	return &FileProvider{Dir: dir}
+}
+
+func (p *FileProvider) Name() string { return "file" }
+
+func (p *FileProvider) Forecast(ctx context.Context, lat, lon float64) (Forecast, error) {
+	cell, clat, clon := Cell(lat, lon)
+
+	raw, err := os.ReadFile(filepath.Join(p.Dir, strings.ReplaceAll(cell, ":", "_")+".json"))
+	if os.IsNotExist(err) {
+		raw, err = os.ReadFile(filepath.Join(p.Dir, "default.json"))
+	}
+	if os.IsNotExist(err) {
+		return Forecast{}, ErrNoForecast
+	}
+	if err != nil {
+		return Forecast{}, err
+	}
+
+	var f Forecast
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return Forecast{}, err
+	}
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	for i := range f.Days {
+		if f.Days[i].Date.IsZero() {
+			f.Days[i].Date = today.AddDate(0, 0, f.Days[i].DayOffset)
+		}
+	}
+	f.Cell, f.Lat, f.Lon = cell, clat, clon
+	f.Provider = p.Name()
+	f.FetchedAt = now
+	return f, nil
+}
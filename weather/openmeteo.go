@@ -0,0 +1,98 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+// OpenMeteo is the Open-Meteo forecast API (no key required)
+type OpenMeteo struct {
+	BaseURL string
+	Days    int
+	Client  *http.Client
+}
+
+func NewOpenMeteo() *OpenMeteo {
+	base := os.Getenv("OPEN_METEO_URL")
+	if base == "" {
+		base = "https://api.open-meteo.com/v1/forecast"
+	}
+	return &OpenMeteo{BaseURL: base, Days: 7, Client: &http.Client{Timeout: 15 * time.Second}}
+}
+
+func (p *OpenMeteo) Name() string { return "openmeteo" }
+
+func (p *OpenMeteo) Forecast(ctx context.Context, lat, lon float64) (Forecast, error) {
+	cell, clat, clon := Cell(lat, lon)
+
+	q := url.Values{}
+	q.Set("latitude", strconv.FormatFloat(clat, 'f', 4, 64))
+	q.Set("longitude", strconv.FormatFloat(clon, 'f', 4, 64))
+	q.Set("daily", "temperature_2m_max,temperature_2m_min,precipitation_sum,precipitation_probability_max,wind_speed_10m_max,relative_humidity_2m_mean")
+	q.Set("timezone", "auto")
+	q.Set("forecast_days", strconv.Itoa(p.Days))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.BaseURL+"?"+q.Encode(), nil)
+	if err != nil {
+		return Forecast{}, err
+	}
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return Forecast{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Forecast{}, fmt.Errorf("weather: open-meteo returned %s", resp.Status)
+	}
+
+	var body struct {
+		Daily struct {
+			Time     []string   `json:"time"`
+			TempMax  []*float64 `json:"temperature_2m_max"`
+			TempMin  []*float64 `json:"temperature_2m_min"`
+			Rain     []*float64 `json:"precipitation_sum"`
+			RainProb []*float64 `json:"precipitation_probability_max"`
+			Wind     []*float64 `json:"wind_speed_10m_max"`
+			Humidity []*float64 `json:"relative_humidity_2m_mean"`
+		} `json:"daily"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return Forecast{}, err
+	}
+
+	// Missing values come back as null
+	at := func(s []*float64, i int) float64 {
+		if i < len(s) && s[i] != nil {
+			return *s[i]
+		}
+		return 0
+	}
+
+	f := Forecast{Cell: cell, Provider: p.Name(), Lat: clat, Lon: clon, FetchedAt: time.Now().UTC()}
+	d := body.Daily
+	for i, day := range d.Time {
+		date, err := time.Parse("2006-01-02", day)
+		if err != nil {
+			continue
+		}
+		f.Days = append(f.Days, Day{
+			Date:     date,
+			TempMaxC: at(d.TempMax, i),
+			TempMinC: at(d.TempMin, i),
+			RainMM:   at(d.Rain, i),
+			RainProb: at(d.RainProb, i),
+			WindKph:  at(d.Wind, i),
+			Humidity: at(d.Humidity, i),
+		})
+	}
+	if len(f.Days) == 0 {
+		return f, ErrNoForecast
+	}
+	return f, nil
+}
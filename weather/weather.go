@@ -0,0 +1,80 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sync"
+	"time"
+)
+
+// Day is one day of a forecast, in the cell's local calendar
+type Day struct {
+	Date      time.Time `bson:"date" json:"date"`
+	DayOffset int       `bson:"-" json:"day_offset,omitempty"` // File fixtures: days from today when Date is unset
+	TempMinC  float64   `bson:"temp_min_c" json:"temp_min_c"`
+	TempMaxC  float64   `bson:"temp_max_c" json:"temp_max_c"`
+	RainMM    float64   `bson:"rain_mm" json:"rain_mm"`
+	RainProb  float64   `bson:"rain_prob" json:"rain_prob"` // Percent
+	WindKph   float64   `bson:"wind_kph" json:"wind_kph"`
+	Humidity  float64   `bson:"humidity" json:"humidity"` // Mean relative humidity, percent
+}
+
+// Forecast is a daily forecast for one grid cell
+type Forecast struct {
+	Cell      string    `bson:"cell" json:"cell"`
+	Provider  string    `bson:"provider" json:"provider"`
+	Lat       float64   `bson:"lat" json:"lat"` // Cell centre
+	Lon       float64   `bson:"lon" json:"lon"`
+	Days      []Day     `bson:"days" json:"days"`
+	FetchedAt time.Time `bson:"fetched_at" json:"fetched_at"`
+}
+
+// Provider fetches daily forecasts for a point
+type Provider interface {
+	Name() string
+	Forecast(ctx context.Context, lat, lon float64) (Forecast, error)
+}
+
+// ErrNoForecast is returned when a provider has nothing for the location
+var ErrNoForecast = errors.New("weather: no forecast for this location")
+
+// CellSize is the forecast grid in degrees (~11 km); farmers in one cell share a forecast
+const CellSize = 0.1
+
+// Cell returns the grid cell key of a point and the cell's centre
+func Cell(lat, lon float64) (key string, centreLat, centreLon float64) {
+	row := math.Floor(lat / CellSize)
+	col := math.Floor(lon / CellSize)
+	centreLat = math.Round((row+0.5)*CellSize*1e4) / 1e4
+	centreLon = math.Round((col+0.5)*CellSize*1e4) / 1e4
+	return fmt.Sprintf("%.0f:%.0f", row, col), centreLat, centreLon
+}
+
+var (
+	defaultProvider Provider
+	providerOnce    sync.Once
+)
+
+// Default returns the provider configured by environment variables:
+//
+//	WEATHER_PROVIDER     "openmeteo" (default) or "file"
+//	WEATHER_FIXTURE_DIR  directory of the file provider (default ./testdata/weather)
+func Default() Provider {
+	providerOnce.Do(func() {
+		if os.Getenv("WEATHER_PROVIDER") == "file" {
+			dir := os.Getenv("WEATHER_FIXTURE_DIR")
+			if dir == "" {
+				dir = "./testdata/weather"
+			}
+			log.Println("weather: using file provider at", dir)
+			defaultProvider = NewFileProvider(dir)
+			return
+		}
+		defaultProvider = NewOpenMeteo()
+	})
+	return defaultProvider
+}